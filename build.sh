@@ -5,9 +5,9 @@ set -e  # 发生错误时终止脚本
 rm -rf build
 mkdir -p build
 
-# 2. 编译总服务
-echo "编译总服务..."
-go build -trimpath -o build/sky .
+# 2. 编译命令行工具（sky serve gateway 启动总服务）
+echo "编译命令行工具..."
+go build -trimpath -o build/sky ./cmd/sky
 
 # 3. 查找并编译所有子服务
 echo "查找并编译子服务..."
@@ -26,4 +26,4 @@ pwd
 
 # 5. 运行总服务
 echo "启动总服务..."
-./build/sky
+./build/sky serve gateway
@@ -0,0 +1,191 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"gorm.io/gorm"
+	"os"
+	securityModels "sky_ISService/services/security/repository/models"
+	systemRepository "sky_ISService/services/system/repository"
+	systemModels "sky_ISService/services/system/repository/models"
+	"sky_ISService/utils"
+	"strings"
+)
+
+// 未通过 -password 指定密码时读取的环境变量
+const adminPasswordEnv = "SKY_ADMIN_PASSWORD"
+
+const minPasswordLength = 8
+
+func runAdmin(args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("用法: sky admin create|reset-password [参数]")
+	}
+	switch args[0] {
+	case "create":
+		return createAdmin(args[1:])
+	case "reset-password":
+		return resetAdminPassword(args[1:])
+	default:
+		return fmt.Errorf("未知操作: %s（可选: create, reset-password）", args[0])
+	}
+}
+
+// 创建超级管理员：认证服务中的登录账号 + 系统服务中绑定超级管理员角色的管理员
+func createAdmin(args []string) error {
+	flags := flag.NewFlagSet("admin create", flag.ContinueOnError)
+	username := flags.String("username", "", "用户名（必填）")
+	email := flags.String("email", "", "邮箱（必填），登录时接收验证码")
+	fullName := flags.String("full-name", "", "全名")
+	password := flags.String("password", "", "密码，为空时读取环境变量 "+adminPasswordEnv+" 或从标准输入读取")
+	dev := devFlag(flags)
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+	if *username == "" || *email == "" {
+		return fmt.Errorf("-username 和 -email 必填")
+	}
+	if !utils.IsValidEmail(*email) {
+		return fmt.Errorf("邮箱格式不正确")
+	}
+	plain, err := readPassword(*password)
+	if err != nil {
+		return err
+	}
+
+	securityDB, err := openDB("security", *dev)
+	if err != nil {
+		return fmt.Errorf("[security] %v", err)
+	}
+	defer closeDB(securityDB)
+	systemDB, err := openDB("system", *dev)
+	if err != nil {
+		return fmt.Errorf("[system] %v", err)
+	}
+	defer closeDB(systemDB)
+
+	if exists, err := recordExists(securityDB, &securityModels.SkySecurityUser{}, *username); err != nil || exists {
+		return existsError(err, "认证服务", *username)
+	}
+	if exists, err := recordExists(systemDB, &systemModels.SkySystemAdmins{}, *username); err != nil || exists {
+		return existsError(err, "系统服务", *username)
+	}
+
+	// 登录时按原文比对密码（见 SecurityService.AdminLogin），这里与之保持一致
+	user := securityModels.SkySecurityUser{Username: *username, Password: plain, Email: *email}
+	if err := securityDB.Create(&user).Error; err != nil {
+		return fmt.Errorf("创建登录账号失败: %v", err)
+	}
+	err = systemDB.Transaction(func(tx *gorm.DB) error {
+		role, err := systemRepository.EnsureSuperAdminRole(tx)
+		if err != nil {
+			return err
+		}
+		admin := systemModels.SkySystemAdmins{
+			Username: *username,
+			Password: plain,
+			FullName: *fullName,
+			UserType: "00", // 系统管理员
+			Email:    *email,
+		}
+		if err := tx.Create(&admin).Error; err != nil {
+			return err
+		}
+		return tx.Create(&systemModels.AdminsRoles{AdminID: admin.ID, RoleID: role.ID}).Error
+	})
+	if err != nil {
+		// 两个服务的数据库不同，无法放在一个事务中，失败时删除已创建的登录账号
+		if cleanupErr := securityDB.Unscoped().Delete(&user).Error; cleanupErr != nil {
+			return fmt.Errorf("创建管理员失败: %v（清理登录账号 %s 也失败: %v）", err, *username, cleanupErr)
+		}
+		return fmt.Errorf("创建管理员失败: %v", err)
+	}
+
+	fmt.Printf("超级管理员 %s 已创建\n", *username)
+	return nil
+}
+
+// 重置管理员密码，同时更新认证服务与系统服务中的记录
+func resetAdminPassword(args []string) error {
+	flags := flag.NewFlagSet("admin reset-password", flag.ContinueOnError)
+	username := flags.String("username", "", "用户名（必填）")
+	password := flags.String("password", "", "新密码，为空时读取环境变量 "+adminPasswordEnv+" 或从标准输入读取")
+	dev := devFlag(flags)
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+	if *username == "" {
+		return fmt.Errorf("-username 必填")
+	}
+	plain, err := readPassword(*password)
+	if err != nil {
+		return err
+	}
+
+	securityDB, err := openDB("security", *dev)
+	if err != nil {
+		return fmt.Errorf("[security] %v", err)
+	}
+	defer closeDB(securityDB)
+	result := securityDB.Model(&securityModels.SkySecurityUser{}).
+		Where("username = ? AND is_deleted = false", *username).
+		Update("password", plain)
+	if result.Error != nil {
+		return fmt.Errorf("重置密码失败: %v", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("管理员 %s 不存在", *username)
+	}
+
+	systemDB, err := openDB("system", *dev)
+	if err != nil {
+		return fmt.Errorf("[system] %v", err)
+	}
+	defer closeDB(systemDB)
+	// UpdateColumn 不触发 BeforeUpdate（其依赖请求上下文中的时间）
+	err = systemDB.Model(&systemModels.SkySystemAdmins{}).
+		Where("username = ? AND is_deleted = false", *username).
+		UpdateColumn("password", plain).Error
+	if err != nil {
+		return fmt.Errorf("登录密码已重置，但同步系统服务中的密码失败: %v", err)
+	}
+
+	fmt.Printf("管理员 %s 的密码已重置\n", *username)
+	return nil
+}
+
+// 按 -password、环境变量、标准输入的顺序读取密码
+func readPassword(flagValue string) (string, error) {
+	password := flagValue
+	if password == "" {
+		password = os.Getenv(adminPasswordEnv)
+	}
+	if password == "" {
+		fmt.Fprint(os.Stderr, "密码: ")
+		line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+		if err != nil && line == "" {
+			return "", fmt.Errorf("读取密码失败: %v", err)
+		}
+		password = strings.TrimRight(line, "\r\n")
+	}
+	if len(password) < minPasswordLength {
+		return "", fmt.Errorf("密码长度不能少于 %d 位", minPasswordLength)
+	}
+	return password, nil
+}
+
+// 用户名是否已存在（排除已软删除的数据）
+func recordExists(db *gorm.DB, model interface{}, username string) (bool, error) {
+	var count int64
+	err := db.Model(model).Where("username = ? AND is_deleted = false", username).Count(&count).Error
+	return count > 0, err
+}
+
+func existsError(err error, service, username string) error {
+	if err != nil {
+		return fmt.Errorf("查询%s失败: %v", service, err)
+	}
+	return errors.New(service + "中已存在用户 " + username)
+}
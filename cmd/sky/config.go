@@ -0,0 +1,31 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"sky_ISService/config"
+	"sky_ISService/gateway/proxy"
+)
+
+func runConfig(args []string) error {
+	if len(args) == 0 || args[0] != "validate" {
+		return fmt.Errorf("用法: sky config validate [-file 配置文件]")
+	}
+	flags := flag.NewFlagSet("config validate", flag.ContinueOnError)
+	file := flags.String("file", "config/config.yml", "配置文件")
+	if err := parseFlags(flags, args[1:]); err != nil {
+		return err
+	}
+
+	c, err := config.ReadFile(*file)
+	if err != nil {
+		return err
+	}
+	// 与热加载使用相同的校验：取值范围 + 网关组件能否构建
+	if err := errors.Join(c.Validate(), proxy.ValidateConfig(c)); err != nil {
+		return fmt.Errorf("配置无效:\n%v", err)
+	}
+	fmt.Printf("%s 配置有效\n", *file)
+	return nil
+}
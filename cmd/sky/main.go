@@ -0,0 +1,91 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"sky_ISService/pkg/serve"
+	"strings"
+)
+
+// 子命令：名称 -> 执行函数，args 为子命令之后的参数
+var commands = map[string]func(args []string) error{
+	"serve":   runServe,
+	"dev":     runDev,
+	"migrate": runMigrate,
+	"seed":    runSeed,
+	"admin":   runAdmin,
+	"config":  runConfig,
+}
+
+const usage = `sky 命令行工具
+
+用法:
+  sky serve <服务>                        启动服务（%s）
+  sky dev                                 开发模式：网关与所有服务运行在一个进程，只需要数据库（默认 SQLite）
+  sky migrate up|down|status [参数]       执行、回滚数据库迁移或查看迁移状态
+  sky seed [-service 服务]                写入初始数据（超级管理员角色、系统管理菜单）
+  sky admin create [参数]                 创建超级管理员
+  sky admin reset-password [参数]         重置管理员密码
+  sky config validate [-file 配置文件]    校验配置文件
+
+在项目根目录执行，配置读取自 config/config.yml；子命令加 -h 查看参数
+migrate、seed、admin 加 -dev 时操作开发模式的数据库
+`
+
+func main() {
+	if len(os.Args) < 2 {
+		printUsage()
+		os.Exit(2)
+	}
+	name := os.Args[1]
+	if name == "-h" || name == "--help" || name == "help" {
+		printUsage()
+		return
+	}
+	command, ok := commands[name]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "未知命令: %s\n\n", name)
+		printUsage()
+		os.Exit(2)
+	}
+	if err := command(os.Args[2:]); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return
+		}
+		fmt.Fprintf(os.Stderr, "错误: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func printUsage() {
+	fmt.Fprintf(os.Stderr, usage, strings.Join(serve.Names(), "、"))
+}
+
+func runServe(args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("用法: sky serve <服务>，可选: %s", strings.Join(serve.Names(), ", "))
+	}
+	return serve.Run(args[0])
+}
+
+// 开发模式：Redis、RabbitMQ、Elasticsearch 与服务发现使用进程内实现，服务请求在进程内转发
+func runDev(args []string) error {
+	flags := flag.NewFlagSet("dev", flag.ContinueOnError)
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+	return serve.Run("dev")
+}
+
+// 解析子命令后的参数，不允许多余的位置参数
+func parseFlags(flags *flag.FlagSet, args []string) error {
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() > 0 {
+		return fmt.Errorf("多余的参数: %s", strings.Join(flags.Args(), " "))
+	}
+	return nil
+}
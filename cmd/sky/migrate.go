@@ -0,0 +1,155 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"gorm.io/gorm"
+	"os"
+	"sky_ISService/pkg/bootstrap"
+	"sky_ISService/pkg/migrate"
+	authModels "sky_ISService/services/auth/repository/models"
+	securityModels "sky_ISService/services/security/repository/models"
+	systemModels "sky_ISService/services/system/repository/models"
+	postgres "sky_ISService/shared/postgresql"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// 服务的数据库迁移
+type migrationTarget struct {
+	service    string
+	migrations []migrate.Migration
+}
+
+// 各服务的数据库迁移，按顺序处理
+var migrations = []migrationTarget{
+	{service: "security", migrations: securityModels.Migrations},
+	{service: "system", migrations: systemModels.Migrations},
+	{service: "auth", migrations: authModels.Migrations},
+}
+
+func runMigrate(args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("用法: sky migrate up|down|status [-service 服务]")
+	}
+	action := args[0]
+	switch action {
+	case "up", "down", "status":
+	default:
+		return fmt.Errorf("未知操作: %s（可选: up, down, status）", action)
+	}
+	flags := flag.NewFlagSet("migrate "+action, flag.ContinueOnError)
+	service := flags.String("service", "", "只处理指定服务的数据库（"+strings.Join(migrationServices(), ", ")+"），默认全部")
+	steps := flags.Int("steps", 1, "down: 回滚的迁移数量")
+	dev := devFlag(flags)
+	if err := parseFlags(flags, args[1:]); err != nil {
+		return err
+	}
+	if action == "down" && *steps <= 0 {
+		return fmt.Errorf("-steps 必须大于 0")
+	}
+	if action == "down" && *service == "" {
+		// 回滚需要明确服务，避免一次回滚所有数据库
+		return fmt.Errorf("回滚需要通过 -service 指定服务")
+	}
+
+	targets, err := migrationTargets(*service)
+	if err != nil {
+		return err
+	}
+	for _, target := range targets {
+		if err := migrateService(target, action, *steps, *dev); err != nil {
+			return fmt.Errorf("[%s] %v", target.service, err)
+		}
+	}
+	return nil
+}
+
+// 对单个服务的数据库执行迁移操作
+func migrateService(target migrationTarget, action string, steps int, dev bool) error {
+	db, err := openDB(target.service, dev)
+	if err != nil {
+		return err
+	}
+	defer closeDB(db)
+
+	migrator, err := migrate.New(db, target.service, target.migrations)
+	if err != nil {
+		return err
+	}
+	switch action {
+	case "up":
+		done, err := migrator.Up()
+		if err != nil {
+			return err
+		}
+		fmt.Printf("[%s] 执行了 %d 个迁移\n", target.service, len(done))
+	case "down":
+		done, err := migrator.Down(steps)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("[%s] 回滚了 %d 个迁移\n", target.service, len(done))
+	case "status":
+		return printMigrationStatus(target.service, migrator)
+	}
+	return nil
+}
+
+func printMigrationStatus(service string, migrator *migrate.Migrator) error {
+	statuses, err := migrator.Status()
+	if err != nil {
+		return err
+	}
+	writer := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintf(writer, "服务\t版本\t名称\t执行时间\n")
+	for _, status := range statuses {
+		appliedAt := "未执行"
+		if status.AppliedAt != nil {
+			appliedAt = status.AppliedAt.Local().Format(time.DateTime)
+		}
+		fmt.Fprintf(writer, "%s\t%d\t%s\t%s\n", service, status.Version, status.Name, appliedAt)
+	}
+	return writer.Flush()
+}
+
+func migrationServices() []string {
+	names := make([]string, 0, len(migrations))
+	for _, target := range migrations {
+		names = append(names, target.service)
+	}
+	return names
+}
+
+// 按服务名筛选迁移，为空时返回全部
+func migrationTargets(service string) ([]migrationTarget, error) {
+	if service == "" {
+		return migrations, nil
+	}
+	for _, target := range migrations {
+		if target.service == service {
+			return []migrationTarget{target}, nil
+		}
+	}
+	return nil, fmt.Errorf("未知服务: %s（可选: %s）", service, strings.Join(migrationServices(), ", "))
+}
+
+// 添加 -dev 参数：操作开发模式（sky dev）使用的数据库
+func devFlag(flags *flag.FlagSet) *bool {
+	return flags.Bool("dev", false, "使用开发模式（sky dev）的数据库，由 dev.database 配置，默认 SQLite")
+}
+
+// 打开服务的数据库：dev 为 true 时使用开发模式的数据库，否则使用 database.<服务名> 的 PostgreSQL 配置
+func openDB(service string, dev bool) (*gorm.DB, error) {
+	if dev {
+		return bootstrap.DevDatabase(service)
+	}
+	return postgres.InitPostgresConfig(service)
+}
+
+func closeDB(db *gorm.DB) {
+	if sqlDB, err := db.DB(); err == nil {
+		sqlDB.Close()
+	}
+}
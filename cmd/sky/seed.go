@@ -0,0 +1,47 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"gorm.io/gorm"
+	systemRepository "sky_ISService/services/system/repository"
+)
+
+// 各服务的初始数据，可重复执行
+var seeds = []struct {
+	service string
+	seed    func(db *gorm.DB) error
+}{
+	{service: "system", seed: systemRepository.Seed},
+}
+
+func runSeed(args []string) error {
+	flags := flag.NewFlagSet("seed", flag.ContinueOnError)
+	service := flags.String("service", "", "只写入指定服务的初始数据，默认全部")
+	dev := devFlag(flags)
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+
+	found := false
+	for _, seed := range seeds {
+		if *service != "" && seed.service != *service {
+			continue
+		}
+		found = true
+		db, err := openDB(seed.service, *dev)
+		if err != nil {
+			return fmt.Errorf("[%s] %v", seed.service, err)
+		}
+		err = seed.seed(db)
+		closeDB(db)
+		if err != nil {
+			return fmt.Errorf("[%s] 写入初始数据失败（是否已执行 sky migrate up？）: %v", seed.service, err)
+		}
+		fmt.Printf("[%s] 初始数据已写入\n", seed.service)
+	}
+	if !found {
+		return fmt.Errorf("服务 %s 没有初始数据", *service)
+	}
+	return nil
+}
@@ -5,11 +5,17 @@ import (
 	"github.com/spf13/viper"
 	"path/filepath"
 	"sync"
+	"sync/atomic"
+	"time"
 )
 
-// Config 定义一个全局变量来存储配置
-var Config *InitStructureConfig
-var once sync.Once
+// 配置文件路径（相对于工作目录）
+const configFile = "config/config.yml"
+
+var (
+	current atomic.Pointer[InitStructureConfig] // 当前生效的配置，重新加载时整体替换
+	loadMu  sync.Mutex                          // 保证首次加载只读取一次配置文件
+)
 
 // ServerConfig 网关总服务
 type ServerConfig struct {
@@ -28,6 +34,7 @@ type SecurityConfig struct {
 	Addr    string `mapstructure:"addr"`
 	Weight1 int    `mapstructure:"weight1"`
 	Weight2 int    `mapstructure:"weight2"`
+	Version string `mapstructure:"version"` // 部署版本，注册到 Consul 元数据，网关据此划分灰度分组
 }
 
 // SystemConfig 系统服务配置
@@ -38,6 +45,16 @@ type SystemConfig struct {
 	Addr    string `mapstructure:"addr"`
 	Weight1 int    `mapstructure:"weight1"`
 	Weight2 int    `mapstructure:"weight2"`
+	Version string `mapstructure:"version"` // 部署版本，注册到 Consul 元数据，网关据此划分灰度分组
+}
+
+// AuthConfig 用户认证服务配置
+type AuthConfig struct {
+	Host    string `mapstructure:"host"`
+	Port    string `mapstructure:"port"`
+	Addr    string `mapstructure:"addr"`
+	Weight1 int    `mapstructure:"weight1"`
+	Version string `mapstructure:"version"` // 部署版本，注册到 Consul 元数据，网关据此划分灰度分组
 }
 
 // 默认服务配置
@@ -46,12 +63,305 @@ type defaultConfig struct {
 	Weight int    `mapstructure:"weight"`
 }
 
+// DiscoveryConfig 网关服务发现配置
+type DiscoveryConfig struct {
+	WaitTime time.Duration `mapstructure:"wait_time"` // Consul 阻塞查询的最长等待时间（如 5m）
+	Exclude  []string      `mapstructure:"exclude"`   // 不参与代理的服务名（默认 gateway、consul）
+}
+
+// HealthCheckConfig 网关节点健康检查配置
+type HealthCheckConfig struct {
+	Path             string        `mapstructure:"path"`               // 主动探测路径（默认 /health，各服务均已提供；返回 2xx 视为存活）
+	Interval         time.Duration `mapstructure:"interval"`           // 主动探测间隔
+	Timeout          time.Duration `mapstructure:"timeout"`            // 单次探测超时
+	FailureThreshold int           `mapstructure:"failure_threshold"`  // 连续失败多少次后摘除节点
+	BaseEjectionTime time.Duration `mapstructure:"base_ejection_time"` // 首次摘除时长，之后按摘除次数递增
+	MaxEjectionTime  time.Duration `mapstructure:"max_ejection_time"`  // 最长摘除时长
+	SlowStart        time.Duration `mapstructure:"slow_start"`         // 重新接入后权重逐步恢复的时长
+}
+
+// CircuitBreakerConfig 熔断器配置
+type CircuitBreakerConfig struct {
+	FailureRatio     float64       `mapstructure:"failure_ratio"`      // 窗口内失败率达到该值时熔断
+	MinRequests      int           `mapstructure:"min_requests"`       // 窗口内请求数达到该值才计算失败率
+	Window           time.Duration `mapstructure:"window"`             // 滚动统计窗口
+	OpenTimeout      time.Duration `mapstructure:"open_timeout"`       // 熔断持续时间，到期后进入半开状态
+	HalfOpenRequests int           `mapstructure:"half_open_requests"` // 半开状态允许的试探请求数，全部成功后恢复
+	PerNode          bool          `mapstructure:"per_node"`           // 是否额外按节点熔断
+}
+
+// RetryConfig 网关上游重试配置：只重试幂等方法或携带幂等键的请求，每次重试选择未尝试过的节点
+type RetryConfig struct {
+	Attempts             int           `mapstructure:"attempts"`               // 最大尝试次数（含首次），默认 2，1 表示不重试
+	RetryOn              []int         `mapstructure:"retry_on"`               // 需要重试的上游响应码，默认 502、503、504；连接失败始终重试
+	BaseBackoff          time.Duration `mapstructure:"base_backoff"`           // 首次重试前的退避时间，之后指数增长并加入随机抖动，默认 25ms
+	MaxBackoff           time.Duration `mapstructure:"max_backoff"`            // 最长退避时间，默认 250ms
+	MaxBodySize          int64         `mapstructure:"max_body_size"`          // 为重试缓冲的最大请求体字节数，超过时不重试，默认 1MB
+	BudgetRatio          float64       `mapstructure:"budget_ratio"`           // 重试预算：最近 10 秒内重试数不超过请求数的该比例，默认 0.2
+	BudgetMinPerSecond   int           `mapstructure:"budget_min_per_second"`  // 请求量很小时每秒至少允许的重试数，默认 3
+	IdempotencyKeyHeader string        `mapstructure:"idempotency_key_header"` // 幂等键请求头，携带时 POST、PATCH 也可重试，默认 Idempotency-Key
+}
+
+// TransportConfig 网关上游连接池配置，所有上游节点共用
+type TransportConfig struct {
+	MaxIdleConns          int           `mapstructure:"max_idle_conns"`          // 所有节点合计的最大空闲连接数，默认 1024
+	MaxIdleConnsPerHost   int           `mapstructure:"max_idle_conns_per_host"` // 每个节点的最大空闲连接数，默认 128
+	MaxConnsPerHost       int           `mapstructure:"max_conns_per_host"`      // 每个节点的最大连接数，默认不限制
+	IdleConnTimeout       time.Duration `mapstructure:"idle_conn_timeout"`       // 空闲连接保留时间，默认 90s
+	DialTimeout           time.Duration `mapstructure:"dial_timeout"`            // 建立连接超时，默认 5s
+	KeepAlive             time.Duration `mapstructure:"keep_alive"`              // TCP keep-alive 探测间隔，默认 30s
+	TLSHandshakeTimeout   time.Duration `mapstructure:"tls_handshake_timeout"`   // TLS 握手超时，默认 5s
+	ResponseHeaderTimeout time.Duration `mapstructure:"response_header_timeout"` // 发送请求后等待响应头的超时，默认 30s，应不小于路由超时
+	H2C                   bool          `mapstructure:"h2c"`                     // 使用明文 HTTP/2 连接上游（上游需支持 h2c），WebSocket 与 SSE 仍使用 HTTP/1.1
+}
+
+// UpstreamConfig 网关上游服务配置
+type UpstreamConfig struct {
+	Balancer       string               `mapstructure:"balancer"`        // 负载均衡策略：random（默认）、round_robin、least_request、consistent_hash
+	HashOn         string               `mapstructure:"hash_on"`         // 一致性哈希取值来源：ip（默认）、header、jwt
+	HashHeader     string               `mapstructure:"hash_header"`     // hash_on 为 header 时使用的请求头
+	CircuitBreaker CircuitBreakerConfig `mapstructure:"circuit_breaker"` // 覆盖网关默认的熔断配置，未配置的项沿用默认值
+	Retry          RetryConfig          `mapstructure:"retry"`           // 覆盖网关默认的重试配置，未配置的项沿用默认值
+}
+
+// RateLimitPolicy 网关限流策略
+type RateLimitPolicy struct {
+	Name        string        `mapstructure:"name"`         // 策略名（用于 Redis key 和响应头）
+	RoutePrefix string        `mapstructure:"route_prefix"` // 适用的路由前缀（按路径段匹配），为空表示所有路由
+	KeyBy       string        `mapstructure:"key_by"`       // 限流维度：ip（默认）、api_key、user、route
+	Algorithm   string        `mapstructure:"algorithm"`    // 限流算法：token_bucket（默认）、sliding_window
+	Limit       int           `mapstructure:"limit"`        // 每个周期允许的请求数
+	Period      time.Duration `mapstructure:"period"`       // 周期（默认 1s）
+	Burst       int           `mapstructure:"burst"`        // 令牌桶容量（默认等于 limit，仅 token_bucket 生效）
+}
+
+// RateLimitConfig 网关限流配置，多个策略同时命中时全部生效
+type RateLimitConfig struct {
+	Policies []RateLimitPolicy `mapstructure:"policies"`
+}
+
+// ACLConfig 网关访问控制的静态条目（运行时条目通过管理接口维护并保存在 Redis）
+type ACLConfig struct {
+	Blacklist        []string            `mapstructure:"blacklist"`         // 黑名单 IP 或 CIDR
+	Whitelist        []string            `mapstructure:"whitelist"`         // 白名单 IP 或 CIDR
+	RestrictedRoutes map[string][]string `mapstructure:"restricted_routes"` // 受限路径（前缀或 glob）-> 允许访问的 IP 或 CIDR
+}
+
+// AdminConfig 网关管理接口配置
+type AdminConfig struct {
+	Token string `mapstructure:"token"` // 管理接口令牌（X-Admin-Token），为空时禁用管理接口
+}
+
+// SigningConfig 合作方开放接口的请求签名（HMAC-SHA256），携带 X-App-ID 的请求由网关验签，不再校验 JWT
+type SigningConfig struct {
+	MaxSkew     time.Duration      `mapstructure:"max_skew"`      // 签名时间与网关当前时间允许的最大偏差，默认 5 分钟；随机串在 2 倍时长内不能重复使用
+	MaxBodySize int64              `mapstructure:"max_body_size"` // 参与签名的最大请求体字节数，默认 10MB
+	Apps        []SigningAppConfig `mapstructure:"apps"`
+}
+
+// SigningAppConfig 合作方应用
+type SigningAppConfig struct {
+	AppID    string   `mapstructure:"app_id"`
+	Name     string   `mapstructure:"name"`
+	Secret   string   `mapstructure:"secret"`   // 签名密钥
+	Prefixes []string `mapstructure:"prefixes"` // 允许访问的路由前缀（按路径段匹配），为空表示所有路由
+	Roles    []string `mapstructure:"roles"`    // 转发给上游服务的角色
+	Disabled bool     `mapstructure:"disabled"`
+}
+
+// CORSConfig 跨域配置
+type CORSConfig struct {
+	AllowOrigins     []string      `mapstructure:"allow_origins"`     // 允许的来源，支持 https://*.example.com 和 *
+	AllowMethods     []string      `mapstructure:"allow_methods"`     // 允许的方法
+	AllowHeaders     []string      `mapstructure:"allow_headers"`     // 允许的请求头，* 表示允许预检请求声明的全部请求头
+	ExposeHeaders    []string      `mapstructure:"expose_headers"`    // 允许前端读取的响应头
+	AllowCredentials *bool         `mapstructure:"allow_credentials"` // 是否允许携带 Cookie 等凭证，不能与 * 来源同时使用
+	MaxAge           time.Duration `mapstructure:"max_age"`           // 预检结果缓存时间
+}
+
+// Credentials 是否允许携带凭证，未配置时不允许
+func (c CORSConfig) Credentials() bool {
+	return c.AllowCredentials != nil && *c.AllowCredentials
+}
+
+// AllowsAnyOrigin 是否允许任意来源（未配置来源时默认允许任意来源）
+func (c CORSConfig) AllowsAnyOrigin() bool {
+	if len(c.AllowOrigins) == 0 {
+		return true
+	}
+	for _, origin := range c.AllowOrigins {
+		if origin == "*" {
+			return true
+		}
+	}
+	return false
+}
+
+// Merge 合并路由级跨域配置，路由未配置的项沿用当前配置
+func (c CORSConfig) Merge(override *CORSConfig) CORSConfig {
+	if override == nil {
+		return c
+	}
+	merged := c
+	if len(override.AllowOrigins) > 0 {
+		merged.AllowOrigins = override.AllowOrigins
+	}
+	if len(override.AllowMethods) > 0 {
+		merged.AllowMethods = override.AllowMethods
+	}
+	if len(override.AllowHeaders) > 0 {
+		merged.AllowHeaders = override.AllowHeaders
+	}
+	if len(override.ExposeHeaders) > 0 {
+		merged.ExposeHeaders = override.ExposeHeaders
+	}
+	if override.AllowCredentials != nil {
+		merged.AllowCredentials = override.AllowCredentials
+	}
+	if override.MaxAge > 0 {
+		merged.MaxAge = override.MaxAge
+	}
+	return merged
+}
+
+// RouteCacheConfig 路由响应缓存配置
+type RouteCacheConfig struct {
+	Enabled     bool          `mapstructure:"enabled"`       // 是否缓存该路由的 GET 响应
+	TTL         time.Duration `mapstructure:"ttl"`           // 缓存时间，上游响应的 Cache-Control max-age 优先
+	VaryHeaders []string      `mapstructure:"vary_headers"`  // 参与缓存 key 的请求头
+	VaryUser    bool          `mapstructure:"vary_user"`     // 公开路由是否按调用方区分缓存（需要认证的路由始终按调用方区分）
+	Tags        []string      `mapstructure:"tags"`          // 缓存标签，用于按标签失效
+	MaxBodySize int64         `mapstructure:"max_body_size"` // 可缓存的最大响应体字节数，默认 1MB
+}
+
+// PluginConfig 网关插件配置
+type PluginConfig struct {
+	Name   string                 `mapstructure:"name"`   // 插件名：request_headers、response_headers、body_limit、timeout 或自行注册的插件
+	Config map[string]interface{} `mapstructure:"config"` // 插件参数
+	// 全局插件的位置，数值小的先执行，默认 1000；内置插件：cors 100、acl 200、api_key 300、signature 400、jwt 500、rate_limit 600、api_key_quota 700、signature_nonce 800。路由插件忽略该项，按配置顺序执行
+	Order int `mapstructure:"order"`
+}
+
+// RouteConfig 网关路由配置
+type RouteConfig struct {
+	Name          string           `mapstructure:"name"`           // 路由名
+	Prefix        string           `mapstructure:"prefix"`         // 路径前缀（按路径段匹配，最长前缀优先）
+	Host          string           `mapstructure:"host"`           // 匹配的 Host，支持 *.example.com，为空表示任意
+	Methods       []string         `mapstructure:"methods"`        // 匹配的 HTTP 方法，为空表示任意
+	Service       string           `mapstructure:"service"`        // 目标服务
+	StripPrefix   bool             `mapstructure:"strip_prefix"`   // 转发前去掉匹配的前缀
+	RewritePrefix string           `mapstructure:"rewrite_prefix"` // 转发前将匹配的前缀替换为该值（优先于 strip_prefix）
+	Timeout       time.Duration    `mapstructure:"timeout"`        // 请求超时时间，为空表示不限制
+	AuthRequired  *bool            `mapstructure:"auth_required"`  // 是否需要 JWT 认证，默认 true
+	Cache         RouteCacheConfig `mapstructure:"cache"`          // 响应缓存
+	CORS          *CORSConfig      `mapstructure:"cors"`           // 覆盖网关默认的跨域配置，未配置的项沿用默认值
+	Plugins       []PluginConfig   `mapstructure:"plugins"`        // 路由插件，在全局插件之后按顺序执行
+}
+
+// RequiresAuth 路由是否需要 JWT 认证
+func (r RouteConfig) RequiresAuth() bool {
+	return r.AuthRequired == nil || *r.AuthRequired
+}
+
+// GRPCRouteConfig HTTP/JSON 转 gRPC 路由配置
+type GRPCRouteConfig struct {
+	Name         string        `mapstructure:"name"`          // 路由名
+	Method       string        `mapstructure:"method"`        // HTTP 方法，默认 POST
+	Path         string        `mapstructure:"path"`          // HTTP 路径，{field} 形式的路径参数映射到请求消息的同名字段
+	Target       string        `mapstructure:"target"`        // gRPC 服务地址，如 127.0.0.1:9999（服务端需开启反射）
+	Service      string        `mapstructure:"service"`       // gRPC 服务全名，如 system.SystemService
+	RPC          string        `mapstructure:"rpc"`           // 方法名，如 VerifyIsSystemAdmin
+	Body         string        `mapstructure:"body"`          // 请求体映射：*（默认）映射整个请求消息，字段名映射到该字段，- 表示忽略请求体
+	Timeout      time.Duration `mapstructure:"timeout"`       // 调用超时，默认 10 秒
+	AuthRequired *bool         `mapstructure:"auth_required"` // 是否需要 JWT 认证，默认 true
+}
+
+// RequiresAuth 路由是否需要 JWT 认证
+func (r GRPCRouteConfig) RequiresAuth() bool {
+	return r.AuthRequired == nil || *r.AuthRequired
+}
+
+// CanaryConfig 灰度发布规则：命中请求头、Cookie 或用户的请求以及按比例抽样的请求转发到指定版本的节点
+type CanaryConfig struct {
+	Service string            `mapstructure:"service"`  // 服务名
+	Version string            `mapstructure:"version"`  // 灰度版本（节点在 Consul 元数据 version 中声明），其余节点为稳定版本
+	Percent float64           `mapstructure:"percent"`  // 按比例转发到灰度版本（0~100），同一用户始终落在同一版本
+	Headers map[string]string `mapstructure:"headers"`  // 请求头 -> 值，任一命中即转发到灰度版本，值为 * 表示只要存在
+	Cookies map[string]string `mapstructure:"cookies"`  // Cookie -> 值，规则同 headers
+	UserIDs []string          `mapstructure:"user_ids"` // JWT 中的 sub_id，命中即转发到灰度版本
+}
+
+// StreamingConfig WebSocket 与 SSE 长连接配置
+type StreamingConfig struct {
+	IdleTimeout   time.Duration `mapstructure:"idle_timeout"`     // 长连接空闲超时（双向均无数据），默认 5 分钟
+	FlushInterval time.Duration `mapstructure:"flush_interval"`   // 普通响应的刷新间隔，SSE 与 WebSocket 始终立即转发
+	MaxConnsPerIP int           `mapstructure:"max_conns_per_ip"` // 单个网关实例上每个客户端 IP 的最大长连接数，默认 20
+}
+
+// GatewayConfig 网关配置
+type GatewayConfig struct {
+	Discovery      DiscoveryConfig           `mapstructure:"discovery"`
+	HealthCheck    HealthCheckConfig         `mapstructure:"health_check"`
+	Services       map[string]UpstreamConfig `mapstructure:"services"` // 服务名 -> 上游配置
+	RateLimit      RateLimitConfig           `mapstructure:"rate_limit"`
+	ACL            ACLConfig                 `mapstructure:"acl"`
+	Admin          AdminConfig               `mapstructure:"admin"`
+	Routes         []RouteConfig             `mapstructure:"routes"`          // 路由表，为空时使用内置的默认路由
+	CircuitBreaker CircuitBreakerConfig      `mapstructure:"circuit_breaker"` // 上游服务默认的熔断配置
+	Retry          RetryConfig               `mapstructure:"retry"`           // 上游服务默认的重试配置
+	Transport      TransportConfig           `mapstructure:"transport"`       // 上游连接池
+	CORS           CORSConfig                `mapstructure:"cors"`            // 默认的跨域配置
+	Streaming      StreamingConfig           `mapstructure:"streaming"`       // WebSocket 与 SSE 长连接
+	GRPCRoutes     []GRPCRouteConfig         `mapstructure:"grpc_routes"`     // HTTP/JSON 转 gRPC 路由，为空时使用内置的默认路由
+	Canary         []CanaryConfig            `mapstructure:"canary"`          // 灰度发布规则（每个服务一条），可通过管理接口在运行时覆盖
+	Plugins        []PluginConfig            `mapstructure:"plugins"`         // 全局插件，与内置插件一起按 order 执行
+	Signing        SigningConfig             `mapstructure:"signing"`         // 合作方开放接口的请求签名
+}
+
+// TracingConfig 链路追踪配置
+type TracingConfig struct {
+	Exporter    string   `mapstructure:"exporter"`     // 导出方式：none（默认，只传递请求 ID 与链路 ID）、stdout、file
+	FilePath    string   `mapstructure:"file_path"`    // exporter 为 file 时的输出文件，每行一个调用段（JSON），默认 logs/traces.jsonl
+	SampleRatio *float64 `mapstructure:"sample_ratio"` // 新链路的采样率（0~1），未配置时为 1，0 表示不采样；上游已决定采样时沿用上游的决定
+}
+
 // PathConfig 配置结构
 type PathConfig struct {
 	Security string `mapstructure:"security"`
 	System   string `mapstructure:"system"`
 }
 
+// SupervisorConfig 网关托管的子服务进程
+type SupervisorConfig struct {
+	Disabled     bool                 `mapstructure:"disabled"`      // 不启动子服务（子服务单独部署时）
+	ReadyTimeout time.Duration        `mapstructure:"ready_timeout"` // 网关开始服务前等待所有子服务就绪的最长时间，默认 30s
+	StopTimeout  time.Duration        `mapstructure:"stop_timeout"`  // 子服务未单独配置时，发送 SIGTERM 后等待退出的时间，默认 10s
+	Services     []ChildServiceConfig `mapstructure:"services"`      // 为空时按 path_config 启动认证服务与系统服务
+}
+
+// ChildServiceConfig 子服务进程配置
+type ChildServiceConfig struct {
+	Name        string        `mapstructure:"name"`
+	Path        string        `mapstructure:"path"` // 可执行文件，相对路径按当前工作目录解析
+	Args        []string      `mapstructure:"args"`
+	Env         []string      `mapstructure:"env"` // 追加的环境变量，格式 KEY=VALUE
+	Dir         string        `mapstructure:"dir"`
+	Restart     string        `mapstructure:"restart"`      // 重启策略：always（默认）、on-failure、never
+	MinBackoff  time.Duration `mapstructure:"min_backoff"`  // 首次重启前的等待时间（默认 1s），之后指数增长
+	MaxBackoff  time.Duration `mapstructure:"max_backoff"`  // 默认 30s
+	MaxRestarts int           `mapstructure:"max_restarts"` // 连续重启次数上限，0 表示不限
+	ReadyURL    string        `mapstructure:"ready_url"`    // 就绪检查：HTTP GET 返回 2xx 视为就绪，如 http://127.0.0.1:8081/health
+	ReadyAddr   string        `mapstructure:"ready_addr"`   // 就绪检查：TCP 端口可连接视为就绪
+	StopTimeout time.Duration `mapstructure:"stop_timeout"`
+}
+
+// DevConfig 开发模式（sky dev）：网关与所有服务运行在同一进程中，Redis、RabbitMQ、Elasticsearch 与服务发现使用进程内实现
+type DevConfig struct {
+	Database   string `mapstructure:"database"`    // 数据库：sqlite（默认，所有服务共用一个文件）或 postgres（使用 database.<服务名> 的配置）
+	SQLitePath string `mapstructure:"sqlite_path"` // SQLite 数据库文件，默认 data/sky-dev.db
+	NoSeed     bool   `mapstructure:"no_seed"`     // 启动时不写入初始数据（超级管理员角色、系统管理菜单）
+}
+
 // ElasticsearchConfig Elasticsearch 配置结构
 type ElasticsearchConfig struct {
 	Host     string   `mapstructure:"host"`
@@ -110,6 +420,25 @@ type JWTSecret struct {
 	Secret string `mapstructure:"secret"`
 }
 
+// IdentityConfig 网关向上游服务传递用户身份的签名配置
+type IdentityConfig struct {
+	Secret      string        `mapstructure:"secret"`        // 签名密钥，网关与各服务需一致，为空时使用 jwt_secret.secret
+	MaxSkew     time.Duration `mapstructure:"max_skew"`      // 签名时间与服务当前时间允许的最大偏差，默认 1 分钟
+	MaxBodySize int64         `mapstructure:"max_body_size"` // 参与签名的最大请求体字节数，网关拒绝更大的请求，默认 10MB
+	// 允许不经网关直接访问的服务名，这些服务放行未携带网关签名的请求；其余服务一律拒绝（健康检查与指标接口除外）
+	AllowUnsigned []string `mapstructure:"allow_unsigned"`
+}
+
+// SignatureRequired 指定服务是否拒绝未携带网关签名的请求
+func (c IdentityConfig) SignatureRequired(service string) bool {
+	for _, name := range c.AllowUnsigned {
+		if name == service {
+			return false
+		}
+	}
+	return true
+}
+
 // AESSecret AES加密
 type AESSecret struct {
 	Secret string `mapstructure:"secret"`
@@ -121,11 +450,30 @@ type InitStructureConfig struct {
 	Server   ServerConfig   `mapstructure:"server"`
 	Security SecurityConfig `mapstructure:"security"`
 	System   SystemConfig   `mapstructure:"system"`
+	Auth     AuthConfig     `mapstructure:"auth"`
 	Default  defaultConfig  `mapstructure:"default"`
 
+	// 网关配置
+	Gateway GatewayConfig `mapstructure:"gateway"`
+
+	// 可信代理网段（IP 或 CIDR）：网关只信任这些地址追加的 X-Forwarded-For 或 Forwarded（见 forwarded_header），
+	// 服务只信任这些地址（以及本机）传入的 X-Real-IP；网关前的负载均衡和网关自身都应在其中
+	TrustedProxies []string `mapstructure:"trusted_proxies"`
+	// 可信代理传递客户端地址使用的请求头：X-Forwarded-For（默认）或 Forwarded，网关只读取该请求头
+	ForwardedHeader string `mapstructure:"forwarded_header"`
+
+	// 链路追踪
+	Tracing TracingConfig `mapstructure:"tracing"`
+
 	// 子服务路径
 	PathConfig PathConfig `mapstructure:"path_config"`
 
+	// 子服务进程托管
+	Supervisor SupervisorConfig `mapstructure:"supervisor"`
+
+	// 开发模式
+	Dev DevConfig `mapstructure:"dev"`
+
 	// 数据库配置
 	Database map[string]struct {
 		PostgreSQL PostgresSQLConfig `mapstructure:"postgresql"`
@@ -161,28 +509,62 @@ type InitStructureConfig struct {
 	// JWT
 	JWTSecret JWTSecret `mapstructure:"jwt_secret"`
 
+	// 网关传递给服务的用户身份签名
+	Identity IdentityConfig `mapstructure:"identity"`
+
 	// AES
 	AESSecret AESSecret `mapstructure:"aes_secret"`
 }
 
-// InitLoadConfig 加载配置文件
+// InitLoadConfig 加载配置文件：首次调用时读取并校验，之后返回当前生效的配置
 func InitLoadConfig() (*InitStructureConfig, error) {
+	if config := current.Load(); config != nil {
+		return config, nil
+	}
+
+	loadMu.Lock()
+	defer loadMu.Unlock()
+	if config := current.Load(); config != nil {
+		return config, nil
+	}
+	config, err := readConfig()
+	if err != nil {
+		return nil, err
+	}
+	if err := config.Validate(); err != nil {
+		return nil, fmt.Errorf("配置校验失败: %v", err)
+	}
+	current.Store(config)
+	return config, nil
+}
+
+// 读取并解析配置文件，每次使用独立的 viper 实例，避免与文件监听并发读写
+func readConfig() (*InitStructureConfig, error) {
+	return ReadFile(configFile)
+}
+
+// ReadFile 读取并解析指定的配置文件，不做校验，也不影响当前生效的配置（用于命令行校验配置文件）
+func ReadFile(path string) (*InitStructureConfig, error) {
 	var config InitStructureConfig
 
 	// 直接获取当前目录的绝对路径
-	absPath, err := filepath.Abs("config/config.yml")
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return nil, fmt.Errorf("无法获取配置文件路径: %v", err)
+	}
 
 	// 直接指定完整的配置文件路径
-	viper.SetConfigFile(absPath)
+	v := viper.New()
+	v.SetConfigFile(absPath)
 
 	// 读取配置文件
-	err = viper.ReadInConfig()
+	err = v.ReadInConfig()
 	if err != nil {
 		return nil, fmt.Errorf("无法读取配置文件: %v", err)
 	}
 
 	// 将配置文件解析为结构体
-	err = viper.Unmarshal(&config)
+	err = v.Unmarshal(&config)
 	if err != nil {
 		return nil, fmt.Errorf("无法解析配置文件: %v", err)
 	}
@@ -190,15 +572,16 @@ func InitLoadConfig() (*InitStructureConfig, error) {
 	return &config, nil
 }
 
-// GetConfig 获取全局配置
+// Use 直接替换当前生效的配置，不读取配置文件，也不通知订阅者（用于测试）
+func Use(config *InitStructureConfig) {
+	current.Store(config)
+}
+
+// GetConfig 获取当前生效的全局配置；配置文件重新加载后返回新的配置，调用方不应修改返回值
 func GetConfig() *InitStructureConfig {
-	once.Do(func() {
-		// 只在第一次调用时加载配置
-		config, err := InitLoadConfig()
-		if err != nil {
-			panic(fmt.Sprintf("加载配置失败: %v", err))
-		}
-		Config = config
-	})
-	return Config
+	config, err := InitLoadConfig()
+	if err != nil {
+		panic(fmt.Sprintf("加载配置失败: %v", err))
+	}
+	return config
 }
@@ -0,0 +1,222 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/spf13/viper"
+)
+
+// 配置文件变化后等待多久再重新加载（编辑器保存时通常会连续触发多次事件）
+const reloadDebounce = 300 * time.Millisecond
+
+var (
+	reloadMu    sync.Mutex // 串行执行重新加载，保证订阅者按顺序收到变更
+	watchOnce   sync.Once
+	subMu       sync.RWMutex
+	subscribers []*subscriber
+	validators  []func(*InitStructureConfig) error
+	nextSubID   int
+)
+
+type subscriber struct {
+	id int
+	fn func(prev, next *InitStructureConfig)
+}
+
+// Subscribe 订阅配置变更：新配置通过校验并生效后，按订阅顺序调用 fn；返回取消订阅的函数
+func Subscribe(fn func(prev, next *InitStructureConfig)) func() {
+	subMu.Lock()
+	defer subMu.Unlock()
+	nextSubID++
+	id := nextSubID
+	subscribers = append(subscribers, &subscriber{id: id, fn: fn})
+	return func() {
+		subMu.Lock()
+		defer subMu.Unlock()
+		for i, sub := range subscribers {
+			if sub.id == id {
+				subscribers = append(subscribers[:i:i], subscribers[i+1:]...)
+				return
+			}
+		}
+	}
+}
+
+// RegisterValidator 注册配置校验（如网关校验路由表、插件能否构建），重新加载时任一校验失败都会保留当前配置
+func RegisterValidator(fn func(*InitStructureConfig) error) {
+	subMu.Lock()
+	defer subMu.Unlock()
+	validators = append(validators, fn)
+}
+
+// Validate 校验配置中的取值范围，不依赖具体组件
+func (c *InitStructureConfig) Validate() error {
+	var errs []error
+	if c.Identity.MaxSkew < 0 {
+		errs = append(errs, fmt.Errorf("identity.max_skew 不能为负数"))
+	}
+	if c.Identity.MaxBodySize < 0 {
+		errs = append(errs, fmt.Errorf("identity.max_body_size 不能为负数"))
+	}
+	if ratio := c.Tracing.SampleRatio; ratio != nil && (*ratio < 0 || *ratio > 1) {
+		errs = append(errs, fmt.Errorf("tracing.sample_ratio 必须在 0~1 之间"))
+	}
+	retries := map[string]RetryConfig{"gateway.retry": c.Gateway.Retry}
+	for name, upstream := range c.Gateway.Services {
+		retries["gateway.services."+name+".retry"] = upstream.Retry
+	}
+	for name, retry := range retries {
+		if retry.Attempts < 0 || retry.BaseBackoff < 0 || retry.MaxBackoff < 0 || retry.MaxBodySize < 0 {
+			errs = append(errs, fmt.Errorf("%s 不能包含负数", name))
+		}
+		if retry.BudgetRatio < 0 || retry.BudgetRatio > 1 {
+			errs = append(errs, fmt.Errorf("%s.budget_ratio 必须在 0~1 之间", name))
+		}
+	}
+	for i, policy := range c.Gateway.RateLimit.Policies {
+		if policy.Limit < 0 || policy.Burst < 0 || policy.Period < 0 {
+			errs = append(errs, fmt.Errorf("gateway.rate_limit.policies[%d] 不能包含负数", i))
+		}
+	}
+	for i, canary := range c.Gateway.Canary {
+		if canary.Percent < 0 || canary.Percent > 100 {
+			errs = append(errs, fmt.Errorf("gateway.canary[%d].percent 必须在 0~100 之间", i))
+		}
+	}
+	// 允许任意来源时携带凭证等同于向所有网站开放登录态
+	corsConfigs := map[string]CORSConfig{"gateway.cors": c.Gateway.CORS}
+	for i, route := range c.Gateway.Routes {
+		corsConfigs[fmt.Sprintf("gateway.routes[%d].cors", i)] = c.Gateway.CORS.Merge(route.CORS)
+	}
+	for name, cors := range corsConfigs {
+		if cors.Credentials() && cors.AllowsAnyOrigin() {
+			errs = append(errs, fmt.Errorf("%s 允许携带凭证时 allow_origins 不能为 *（未配置时默认为 *）", name))
+		}
+	}
+	appIDs := make(map[string]bool)
+	for i, app := range c.Gateway.Signing.Apps {
+		if app.AppID == "" || app.Secret == "" {
+			errs = append(errs, fmt.Errorf("gateway.signing.apps[%d] 缺少 app_id 或 secret", i))
+		} else if appIDs[app.AppID] {
+			errs = append(errs, fmt.Errorf("gateway.signing.apps 中 app_id %s 重复", app.AppID))
+		}
+		appIDs[app.AppID] = true
+	}
+	if c.Supervisor.ReadyTimeout < 0 || c.Supervisor.StopTimeout < 0 {
+		errs = append(errs, fmt.Errorf("supervisor 的超时时间不能为负数"))
+	}
+	names := make(map[string]bool)
+	for i, service := range c.Supervisor.Services {
+		if service.Name == "" || service.Path == "" {
+			errs = append(errs, fmt.Errorf("supervisor.services[%d] 缺少 name 或 path", i))
+		} else if names[service.Name] {
+			errs = append(errs, fmt.Errorf("supervisor.services 中 name %s 重复", service.Name))
+		}
+		names[service.Name] = true
+		switch service.Restart {
+		case "", "always", "on-failure", "never":
+		default:
+			errs = append(errs, fmt.Errorf("supervisor.services[%d].restart 无效: %s", i, service.Restart))
+		}
+		if service.MinBackoff < 0 || service.MaxBackoff < 0 || service.MaxRestarts < 0 || service.StopTimeout < 0 {
+			errs = append(errs, fmt.Errorf("supervisor.services[%d] 不能包含负数", i))
+		}
+	}
+	switch strings.ToLower(c.ForwardedHeader) {
+	case "", "x-forwarded-for", "forwarded":
+	default:
+		errs = append(errs, fmt.Errorf("forwarded_header 无效: %s（可选 X-Forwarded-For、Forwarded）", c.ForwardedHeader))
+	}
+	switch c.Dev.Database {
+	case "", "sqlite", "postgres":
+	default:
+		errs = append(errs, fmt.Errorf("dev.database 无效: %s（可选 sqlite、postgres）", c.Dev.Database))
+	}
+	return errors.Join(errs...)
+}
+
+// Reload 重新读取配置文件：校验通过后整体替换当前配置并通知订阅者；读取或校验失败时返回错误，保留当前配置
+func Reload() error {
+	reloadMu.Lock()
+	defer reloadMu.Unlock()
+
+	config, err := readConfig()
+	if err != nil {
+		return err
+	}
+	if err := config.Validate(); err != nil {
+		return fmt.Errorf("配置校验失败: %v", err)
+	}
+	subMu.RLock()
+	checks := append([]func(*InitStructureConfig) error(nil), validators...)
+	subMu.RUnlock()
+	for _, validate := range checks {
+		if err := validate(config); err != nil {
+			return fmt.Errorf("配置校验失败: %v", err)
+		}
+	}
+
+	if prev := current.Swap(config); prev != nil {
+		notify(prev, config)
+	}
+	return nil
+}
+
+// 依次通知订阅者，单个订阅者 panic 不影响其他订阅者
+func notify(prev, next *InitStructureConfig) {
+	subMu.RLock()
+	subs := append([]*subscriber(nil), subscribers...)
+	subMu.RUnlock()
+	for _, sub := range subs {
+		func() {
+			defer func() {
+				if r := recover(); r != nil {
+					log.Printf("配置变更订阅者处理失败: %v", r)
+				}
+			}()
+			sub.fn(prev, next)
+		}()
+	}
+}
+
+// WatchConfig 监听配置文件，文件变化后自动重新加载；新配置无效时记录日志并继续使用上一份有效配置，多次调用只监听一次
+func WatchConfig() {
+	watchOnce.Do(func() {
+		absPath, err := filepath.Abs(configFile)
+		if err != nil {
+			log.Printf("无法监听配置文件: %v", err)
+			return
+		}
+
+		var (
+			mu    sync.Mutex
+			timer *time.Timer
+		)
+		// 独立的 viper 实例只用于接收文件事件，配置内容由 Reload 重新读取
+		v := viper.New()
+		v.SetConfigFile(absPath)
+		v.OnConfigChange(func(fsnotify.Event) {
+			mu.Lock()
+			defer mu.Unlock()
+			if timer != nil {
+				timer.Stop()
+			}
+			timer = time.AfterFunc(reloadDebounce, func() {
+				if err := Reload(); err != nil {
+					log.Printf("配置文件重新加载失败，继续使用当前配置: %v", err)
+					return
+				}
+				log.Printf("配置文件已重新加载: %s", absPath)
+			})
+		})
+		v.WatchConfig()
+		log.Printf("开始监听配置文件: %s", absPath)
+	})
+}
@@ -0,0 +1,111 @@
+package config
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestValidate(t *testing.T) {
+	allow := true
+	ratio := func(value float64) *float64 { return &value }
+	tests := []struct {
+		name    string
+		modify  func(c *InitStructureConfig)
+		wantErr string // 为空表示校验通过
+	}{
+		{name: "空配置"},
+		{name: "有效配置", modify: func(c *InitStructureConfig) {
+			c.Tracing.SampleRatio = ratio(0.5)
+			c.Gateway.CORS = CORSConfig{AllowOrigins: []string{"https://app.example.com"}, AllowCredentials: &allow}
+			c.Gateway.Canary = []CanaryConfig{{Service: "system", Version: "v2", Percent: 100}}
+			c.Supervisor.Services = []ChildServiceConfig{{Name: "auth", Path: "./auth", Restart: "on-failure"}}
+			c.ForwardedHeader = "forwarded"
+			c.Dev.Database = "postgres"
+		}},
+		{name: "负的时钟偏差", modify: func(c *InitStructureConfig) { c.Identity.MaxSkew = -time.Second }, wantErr: "identity.max_skew"},
+		{name: "负的请求体上限", modify: func(c *InitStructureConfig) { c.Identity.MaxBodySize = -1 }, wantErr: "identity.max_body_size"},
+		{name: "采样率超出范围", modify: func(c *InitStructureConfig) { c.Tracing.SampleRatio = ratio(1.5) }, wantErr: "tracing.sample_ratio"},
+		{name: "负的重试次数", modify: func(c *InitStructureConfig) { c.Gateway.Retry.Attempts = -1 }, wantErr: "gateway.retry 不能包含负数"},
+		{name: "服务的重试预算超出范围", modify: func(c *InitStructureConfig) {
+			c.Gateway.Services = map[string]UpstreamConfig{"system": {Retry: RetryConfig{BudgetRatio: 2}}}
+		}, wantErr: "gateway.services.system.retry.budget_ratio"},
+		{name: "负的限流上限", modify: func(c *InitStructureConfig) {
+			c.Gateway.RateLimit.Policies = []RateLimitPolicy{{Limit: -1}}
+		}, wantErr: "gateway.rate_limit.policies[0]"},
+		{name: "灰度比例超出范围", modify: func(c *InitStructureConfig) {
+			c.Gateway.Canary = []CanaryConfig{{Service: "system", Version: "v2", Percent: 101}}
+		}, wantErr: "gateway.canary[0].percent"},
+		{name: "默认任意来源时携带凭证", modify: func(c *InitStructureConfig) {
+			c.Gateway.CORS = CORSConfig{AllowCredentials: &allow}
+		}, wantErr: "gateway.cors 允许携带凭证"},
+		{name: "路由覆盖为任意来源时携带凭证", modify: func(c *InitStructureConfig) {
+			c.Gateway.CORS = CORSConfig{AllowOrigins: []string{"https://app.example.com"}, AllowCredentials: &allow}
+			c.Gateway.Routes = []RouteConfig{{Name: "open", CORS: &CORSConfig{AllowOrigins: []string{"*"}}}}
+		}, wantErr: "gateway.routes[0].cors"},
+		{name: "签名应用缺少密钥", modify: func(c *InitStructureConfig) {
+			c.Gateway.Signing.Apps = []SigningAppConfig{{AppID: "erp"}}
+		}, wantErr: "gateway.signing.apps[0]"},
+		{name: "签名应用重复", modify: func(c *InitStructureConfig) {
+			c.Gateway.Signing.Apps = []SigningAppConfig{{AppID: "erp", Secret: "a"}, {AppID: "erp", Secret: "b"}}
+		}, wantErr: "app_id erp 重复"},
+		{name: "子服务缺少路径", modify: func(c *InitStructureConfig) {
+			c.Supervisor.Services = []ChildServiceConfig{{Name: "auth"}}
+		}, wantErr: "supervisor.services[0] 缺少"},
+		{name: "子服务重名", modify: func(c *InitStructureConfig) {
+			c.Supervisor.Services = []ChildServiceConfig{{Name: "auth", Path: "a"}, {Name: "auth", Path: "b"}}
+		}, wantErr: "name auth 重复"},
+		{name: "无效的重启策略", modify: func(c *InitStructureConfig) {
+			c.Supervisor.Services = []ChildServiceConfig{{Name: "auth", Path: "a", Restart: "sometimes"}}
+		}, wantErr: "restart 无效"},
+		{name: "负的退避时间", modify: func(c *InitStructureConfig) {
+			c.Supervisor.Services = []ChildServiceConfig{{Name: "auth", Path: "a", MinBackoff: -time.Second}}
+		}, wantErr: "supervisor.services[0] 不能包含负数"},
+		{name: "负的就绪超时", modify: func(c *InitStructureConfig) { c.Supervisor.ReadyTimeout = -time.Second }, wantErr: "supervisor 的超时时间"},
+		{name: "无效的转发请求头", modify: func(c *InitStructureConfig) { c.ForwardedHeader = "X-Real-IP" }, wantErr: "forwarded_header 无效"},
+		{name: "无效的开发数据库", modify: func(c *InitStructureConfig) { c.Dev.Database = "mysql" }, wantErr: "dev.database 无效"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &InitStructureConfig{}
+			if tt.modify != nil {
+				tt.modify(c)
+			}
+			err := c.Validate()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("校验失败: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("得到错误 %v，期望包含 %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestNotify(t *testing.T) {
+	var calls []string
+	unsubscribeFirst := Subscribe(func(prev, next *InitStructureConfig) { calls = append(calls, "first") })
+	unsubscribePanic := Subscribe(func(prev, next *InitStructureConfig) { panic("订阅者失败") })
+	unsubscribeLast := Subscribe(func(prev, next *InitStructureConfig) { calls = append(calls, "last") })
+	t.Cleanup(func() {
+		unsubscribePanic()
+		unsubscribeLast()
+	})
+
+	// 按订阅顺序调用，单个订阅者 panic 不影响其他订阅者
+	notify(&InitStructureConfig{}, &InitStructureConfig{})
+	if strings.Join(calls, ",") != "first,last" {
+		t.Fatalf("调用顺序为 %v", calls)
+	}
+
+	// 取消订阅后不再调用
+	calls = nil
+	unsubscribeFirst()
+	notify(&InitStructureConfig{}, &InitStructureConfig{})
+	if strings.Join(calls, ",") != "last" {
+		t.Fatalf("取消订阅后调用了 %v", calls)
+	}
+}
@@ -0,0 +1,68 @@
+package controller
+
+import (
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"sky_ISService/gateway/dto"
+	"sky_ISService/gateway/middlewares"
+	"sky_ISService/gateway/proxy"
+	"sky_ISService/pkg/middleware"
+	"sky_ISService/utils"
+	"time"
+)
+
+type ACLController struct {
+	acl *proxy.ACL
+}
+
+func NewACLController(p *proxy.Proxy) *ACLController {
+	return &ACLController{acl: p.ACL()}
+}
+
+func (c *ACLController) ACLControllerRoutes(r *gin.Engine) {
+	// 创建前缀的路由组（管理接口需要 JWT 和管理令牌）
+	aclGroup := r.Group("/gateway/admin/acl", middleware.JWTAuthMiddleware(), middlewares.AdminTokenMiddleware())
+
+	// 查询所有访问控制条目
+	aclGroup.GET("", func(ctx *gin.Context) {
+		utils.Success(ctx, c.acl.List())
+	})
+
+	// 添加访问控制条目
+	aclGroup.POST("", func(ctx *gin.Context) {
+		var req dto.CreateACLEntryRequest
+		if err := ctx.ShouldBindJSON(&req); err != nil {
+			utils.Error(ctx, http.StatusBadRequest, "请求数据错误: "+err.Error())
+			return
+		}
+
+		expiresAt := req.ExpiresAt
+		if expiresAt == nil && req.TTLSeconds > 0 {
+			expiration := time.Now().Add(time.Duration(req.TTLSeconds) * time.Second)
+			expiresAt = &expiration
+		}
+		entry, err := c.acl.Add(ctx, proxy.ACLEntry{
+			Type:      req.Type,
+			CIDR:      req.CIDR,
+			Path:      req.Path,
+			Reason:    req.Reason,
+			ExpiresAt: expiresAt,
+			CreatedBy: fmt.Sprint(ctx.MustGet("user_id")),
+		})
+		if err != nil {
+			utils.Error(ctx, http.StatusBadRequest, err.Error())
+			return
+		}
+		utils.Success(ctx, entry)
+	})
+
+	// 删除访问控制条目
+	aclGroup.DELETE("/:id", func(ctx *gin.Context) {
+		if err := c.acl.Remove(ctx, ctx.Param("id")); err != nil {
+			utils.Error(ctx, http.StatusBadRequest, err.Error())
+			return
+		}
+		utils.Success(ctx, "删除成功")
+	})
+}
@@ -0,0 +1,124 @@
+package controller
+
+import (
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"sky_ISService/gateway/dto"
+	"sky_ISService/gateway/middlewares"
+	"sky_ISService/gateway/proxy"
+	"sky_ISService/pkg/middleware"
+	"sky_ISService/utils"
+	"time"
+)
+
+type APIKeyController struct {
+	apiKeys *proxy.APIKeys
+}
+
+func NewAPIKeyController(p *proxy.Proxy) *APIKeyController {
+	return &APIKeyController{apiKeys: p.APIKeys()}
+}
+
+func (c *APIKeyController) APIKeyControllerRoutes(r *gin.Engine) {
+	// 创建前缀的路由组（管理接口需要 JWT 和管理令牌）
+	apiKeyGroup := r.Group("/gateway/admin/apikeys", middleware.JWTAuthMiddleware(), middlewares.AdminTokenMiddleware())
+
+	// 查询所有 API Key（不含密钥）
+	apiKeyGroup.GET("", func(ctx *gin.Context) {
+		utils.Success(ctx, c.apiKeys.List())
+	})
+
+	// 查询 API Key
+	apiKeyGroup.GET("/:id", func(ctx *gin.Context) {
+		key, ok := c.apiKeys.Get(ctx.Param("id"))
+		if !ok {
+			utils.Error(ctx, http.StatusNotFound, "API Key 不存在")
+			return
+		}
+		utils.Success(ctx, key)
+	})
+
+	// 查询 API Key 当日、当月的配额使用情况
+	apiKeyGroup.GET("/:id/usage", func(ctx *gin.Context) {
+		usage, err := c.apiKeys.Usage(ctx, ctx.Param("id"))
+		if err != nil {
+			utils.Error(ctx, http.StatusBadRequest, err.Error())
+			return
+		}
+		utils.Success(ctx, usage)
+	})
+
+	// 创建 API Key，明文 Key 只在这里返回一次
+	apiKeyGroup.POST("", func(ctx *gin.Context) {
+		key, ok := bindAPIKey(ctx)
+		if !ok {
+			return
+		}
+		key.CreatedBy = fmt.Sprint(ctx.MustGet("user_id"))
+
+		created, plaintext, err := c.apiKeys.Create(ctx, key)
+		if err != nil {
+			utils.Error(ctx, http.StatusBadRequest, err.Error())
+			return
+		}
+		utils.Success(ctx, gin.H{
+			"api_key": created,
+			"key":     plaintext,
+		})
+	})
+
+	// 修改 API Key（名称、身份、授权范围、配额、停用、过期时间），密钥不变
+	apiKeyGroup.PUT("/:id", func(ctx *gin.Context) {
+		key, ok := bindAPIKey(ctx)
+		if !ok {
+			return
+		}
+		key.ID = ctx.Param("id")
+
+		updated, err := c.apiKeys.Update(ctx, key)
+		if err != nil {
+			utils.Error(ctx, http.StatusBadRequest, err.Error())
+			return
+		}
+		utils.Success(ctx, updated)
+	})
+
+	// 吊销 API Key
+	apiKeyGroup.DELETE("/:id", func(ctx *gin.Context) {
+		if err := c.apiKeys.Remove(ctx, ctx.Param("id")); err != nil {
+			utils.Error(ctx, http.StatusBadRequest, err.Error())
+			return
+		}
+		utils.Success(ctx, "删除成功")
+	})
+}
+
+// 解析创建（修改）请求，失败时已写出响应
+func bindAPIKey(ctx *gin.Context) (proxy.APIKey, bool) {
+	var req dto.SaveAPIKeyRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		utils.Error(ctx, http.StatusBadRequest, "请求数据错误: "+err.Error())
+		return proxy.APIKey{}, false
+	}
+
+	expiresAt := req.ExpiresAt
+	if expiresAt == nil && req.TTLSeconds > 0 {
+		expiration := time.Now().Add(time.Duration(req.TTLSeconds) * time.Second)
+		expiresAt = &expiration
+	}
+	scopes := make([]proxy.APIKeyScope, 0, len(req.Scopes))
+	for _, scope := range req.Scopes {
+		scopes = append(scopes, proxy.APIKeyScope{Prefix: scope.Prefix, Methods: scope.Methods})
+	}
+	return proxy.APIKey{
+		Name:         req.Name,
+		UserID:       req.UserID,
+		Roles:        req.Roles,
+		Scopes:       scopes,
+		DailyQuota:   req.DailyQuota,
+		MonthlyQuota: req.MonthlyQuota,
+		Disabled:     req.Disabled,
+		ExpiresAt:    expiresAt,
+	}, true
+}
@@ -0,0 +1,45 @@
+package controller
+
+import (
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"sky_ISService/gateway/dto"
+	"sky_ISService/gateway/middlewares"
+	"sky_ISService/gateway/proxy"
+	"sky_ISService/pkg/middleware"
+	"sky_ISService/shared/cache"
+	"sky_ISService/utils"
+)
+
+type CacheController struct {
+	cache *proxy.ResponseCache
+}
+
+func NewCacheController(p *proxy.Proxy) *CacheController {
+	return &CacheController{cache: p.ResponseCache()}
+}
+
+func (c *CacheController) CacheControllerRoutes(r *gin.Engine) {
+	// 创建前缀的路由组（管理接口需要 JWT 和管理令牌）
+	cacheGroup := r.Group("/gateway/admin/cache", middleware.JWTAuthMiddleware(), middlewares.AdminTokenMiddleware())
+
+	// 失效响应缓存（服务也可以向 gateway_cache_invalidation 队列发送同样格式的消息）
+	cacheGroup.POST("/invalidate", func(ctx *gin.Context) {
+		var req dto.InvalidateCacheRequest
+		if err := ctx.ShouldBindJSON(&req); err != nil {
+			utils.Error(ctx, http.StatusBadRequest, "请求数据错误: "+err.Error())
+			return
+		}
+		if len(req.Paths) == 0 && len(req.Tags) == 0 {
+			utils.Error(ctx, http.StatusBadRequest, "paths 与 tags 至少填写一项")
+			return
+		}
+
+		removed, err := c.cache.Invalidate(ctx, cache.Invalidation{Paths: req.Paths, Tags: req.Tags})
+		if err != nil {
+			utils.Error(ctx, http.StatusInternalServerError, err.Error())
+			return
+		}
+		utils.Success(ctx, gin.H{"removed": removed})
+	})
+}
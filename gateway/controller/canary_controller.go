@@ -0,0 +1,63 @@
+package controller
+
+import (
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"sky_ISService/gateway/dto"
+	"sky_ISService/gateway/middlewares"
+	"sky_ISService/gateway/proxy"
+	"sky_ISService/pkg/middleware"
+	"sky_ISService/utils"
+)
+
+type CanaryController struct {
+	canary *proxy.Canary
+}
+
+func NewCanaryController(p *proxy.Proxy) *CanaryController {
+	return &CanaryController{canary: p.Canary()}
+}
+
+func (c *CanaryController) CanaryControllerRoutes(r *gin.Engine) {
+	// 创建前缀的路由组（管理接口需要 JWT 和管理令牌）
+	canaryGroup := r.Group("/gateway/admin/canary", middleware.JWTAuthMiddleware(), middlewares.AdminTokenMiddleware())
+
+	// 查询当前生效的灰度规则
+	canaryGroup.GET("", func(ctx *gin.Context) {
+		utils.Success(ctx, c.canary.List())
+	})
+
+	// 设置服务的灰度规则（覆盖配置文件中的规则）
+	canaryGroup.PUT("/:service", func(ctx *gin.Context) {
+		var req dto.SetCanaryRuleRequest
+		if err := ctx.ShouldBindJSON(&req); err != nil {
+			utils.Error(ctx, http.StatusBadRequest, "请求数据错误: "+err.Error())
+			return
+		}
+
+		rule, err := c.canary.Set(ctx, proxy.CanaryRule{
+			Service:   ctx.Param("service"),
+			Version:   req.Version,
+			Percent:   req.Percent,
+			Headers:   req.Headers,
+			Cookies:   req.Cookies,
+			UserIDs:   req.UserIDs,
+			UpdatedBy: fmt.Sprint(ctx.MustGet("user_id")),
+		})
+		if err != nil {
+			utils.Error(ctx, http.StatusBadRequest, err.Error())
+			return
+		}
+		utils.Success(ctx, rule)
+	})
+
+	// 删除服务的运行时灰度规则
+	canaryGroup.DELETE("/:service", func(ctx *gin.Context) {
+		if err := c.canary.Remove(ctx, ctx.Param("service")); err != nil {
+			utils.Error(ctx, http.StatusBadRequest, err.Error())
+			return
+		}
+		utils.Success(ctx, "删除成功")
+	})
+}
@@ -0,0 +1,32 @@
+package controller
+
+import (
+	"github.com/gin-gonic/gin"
+	"sky_ISService/gateway/middlewares"
+	"sky_ISService/gateway/proxy"
+	"sky_ISService/pkg/middleware"
+	"sky_ISService/utils"
+)
+
+type HealthController struct {
+	proxy *proxy.Proxy
+}
+
+func NewHealthController(p *proxy.Proxy) *HealthController {
+	return &HealthController{proxy: p}
+}
+
+func (c *HealthController) HealthControllerRoutes(r *gin.Engine) {
+	// 创建前缀的路由组（管理接口需要 JWT 和管理令牌）
+	gatewayGroup := r.Group("/gateway", middleware.JWTAuthMiddleware(), middlewares.AdminTokenMiddleware())
+
+	// 查看所有上游节点的健康状态
+	gatewayGroup.GET("/health", func(ctx *gin.Context) {
+		utils.Success(ctx, c.proxy.HealthStatus())
+	})
+
+	// 查看上游服务（及节点）熔断器状态
+	gatewayGroup.GET("/breakers", func(ctx *gin.Context) {
+		utils.Success(ctx, c.proxy.CircuitStatus())
+	})
+}
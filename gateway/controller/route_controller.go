@@ -0,0 +1,59 @@
+package controller
+
+import (
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"sky_ISService/gateway/middlewares"
+	"sky_ISService/gateway/proxy"
+	"sky_ISService/pkg/middleware"
+	"sky_ISService/utils"
+)
+
+type RouteController struct {
+	proxy *proxy.Proxy
+}
+
+func NewRouteController(p *proxy.Proxy) *RouteController {
+	return &RouteController{proxy: p}
+}
+
+func (c *RouteController) RouteControllerRoutes(r *gin.Engine) {
+	// 创建前缀的路由组（管理接口需要 JWT 和管理令牌）
+	gatewayGroup := r.Group("/gateway", middleware.JWTAuthMiddleware(), middlewares.AdminTokenMiddleware())
+
+	// 查看生效的路由表（按匹配优先级排序）
+	// 传入 path（可选 method、host）时返回该请求命中的路由及转发路径，便于调试
+	gatewayGroup.GET("/routes", func(ctx *gin.Context) {
+		path := ctx.Query("path")
+		if path == "" {
+			utils.Success(ctx, c.proxy.Routes())
+			return
+		}
+
+		method := ctx.DefaultQuery("method", http.MethodGet)
+		req, err := http.NewRequest(method, path, nil)
+		if err != nil {
+			utils.Error(ctx, http.StatusBadRequest, "无效的请求路径")
+			return
+		}
+		if host := ctx.Query("host"); host != "" {
+			req.Host = host
+		}
+		route := c.proxy.MatchRoute(req)
+		utils.Success(ctx, gin.H{
+			"route":         route.Info(),
+			"upstream_path": route.UpstreamPath(req.URL.Path),
+		})
+	})
+	// 查看 HTTP/JSON 转 gRPC 路由
+	gatewayGroup.GET("/routes/grpc", func(ctx *gin.Context) {
+		utils.Success(ctx, c.proxy.GRPCTranscoder().Routes())
+	})
+	// 查看生效的全局插件（按执行顺序）和可在路由中使用的插件
+	gatewayGroup.GET("/plugins", func(ctx *gin.Context) {
+		utils.Success(ctx, gin.H{
+			"global":     c.proxy.Plugins(),
+			"registered": proxy.RegisteredPlugins(),
+		})
+	})
+}
@@ -0,0 +1,27 @@
+package controller
+
+import (
+	"github.com/gin-gonic/gin"
+	"sky_ISService/gateway/middlewares"
+	"sky_ISService/pkg/middleware"
+	"sky_ISService/pkg/supervisor"
+	"sky_ISService/utils"
+)
+
+type SupervisorController struct {
+	supervisor *supervisor.Supervisor
+}
+
+func NewSupervisorController(s *supervisor.Supervisor) *SupervisorController {
+	return &SupervisorController{supervisor: s}
+}
+
+func (c *SupervisorController) SupervisorControllerRoutes(r *gin.Engine) {
+	// 创建前缀的路由组（管理接口需要 JWT 和管理令牌）
+	gatewayGroup := r.Group("/gateway", middleware.JWTAuthMiddleware(), middlewares.AdminTokenMiddleware())
+
+	// 查看网关托管的子服务进程：PID、运行时长、重启次数、最近一次退出原因
+	gatewayGroup.GET("/supervisor", func(ctx *gin.Context) {
+		utils.Success(ctx, c.supervisor.Status())
+	})
+}
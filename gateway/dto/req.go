@@ -0,0 +1,47 @@
+package dto
+
+import "time"
+
+// CreateACLEntryRequest 添加访问控制条目请求
+type CreateACLEntryRequest struct {
+	Type       string     `json:"type" binding:"required,oneof=blacklist whitelist route"` // 条目类型
+	CIDR       string     `json:"cidr" binding:"required"`                                 // IP 或 CIDR
+	Path       string     `json:"path"`                                                    // 受限路径（route 类型必填）
+	Reason     string     `json:"reason" binding:"required"`                               // 添加原因
+	ExpiresAt  *time.Time `json:"expires_at"`                                              // 过期时间（RFC3339）
+	TTLSeconds int        `json:"ttl_seconds"`                                             // 有效秒数，与 expires_at 二选一
+}
+
+// InvalidateCacheRequest 失效响应缓存请求，paths 与 tags 至少填写一项
+type InvalidateCacheRequest struct {
+	Paths []string `json:"paths"` // 网关请求路径前缀
+	Tags  []string `json:"tags"`  // 缓存标签，route:<路由名> 表示整个路由
+}
+
+// SetCanaryRuleRequest 设置灰度规则请求，服务名取自路径参数
+type SetCanaryRuleRequest struct {
+	Version string            `json:"version" binding:"required"`      // 灰度版本（服务注册元数据 version）
+	Percent float64           `json:"percent" binding:"gte=0,lte=100"` // 按比例转发到灰度版本（0~100）
+	Headers map[string]string `json:"headers"`                         // 请求头 -> 值，值为 * 表示只要存在
+	Cookies map[string]string `json:"cookies"`                         // Cookie -> 值，值为 * 表示只要存在
+	UserIDs []string          `json:"user_ids"`                        // 直接进入灰度的用户 ID
+}
+
+// APIKeyScopeRequest API Key 授权范围
+type APIKeyScopeRequest struct {
+	Prefix  string   `json:"prefix" binding:"required"` // 路由前缀，按路径段匹配
+	Methods []string `json:"methods"`                   // 允许的 HTTP 方法，为空表示所有方法
+}
+
+// SaveAPIKeyRequest 创建（修改）API Key 请求
+type SaveAPIKeyRequest struct {
+	Name         string               `json:"name" binding:"required"`              // 名称（如 ERP 集成）
+	UserID       string               `json:"user_id"`                              // 转发给上游服务的用户 ID
+	Roles        []string             `json:"roles"`                                // 转发给上游服务的角色
+	Scopes       []APIKeyScopeRequest `json:"scopes" binding:"required,min=1,dive"` // 授权范围
+	DailyQuota   int64                `json:"daily_quota" binding:"gte=0"`          // 每日请求上限，0 表示不限
+	MonthlyQuota int64                `json:"monthly_quota" binding:"gte=0"`        // 每月请求上限，0 表示不限
+	Disabled     bool                 `json:"disabled"`                             // 是否停用
+	ExpiresAt    *time.Time           `json:"expires_at"`                           // 过期时间（RFC3339）
+	TTLSeconds   int                  `json:"ttl_seconds"`                          // 有效秒数，与 expires_at 二选一
+}
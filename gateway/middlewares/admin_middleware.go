@@ -0,0 +1,37 @@
+package middlewares
+
+import (
+	"crypto/subtle"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"sky_ISService/config"
+	"sky_ISService/pkg/identity"
+	"sky_ISService/utils"
+)
+
+// AdminTokenMiddleware 校验网关管理接口令牌（X-Admin-Token）与调用方的管理员角色（identity.AdminRole），需在 JWT 中间件之后使用。
+// 未配置令牌时拒绝所有请求
+func AdminTokenMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		token := config.GetConfig().Gateway.Admin.Token
+		if token == "" {
+			utils.Error(c, http.StatusForbidden, "未配置管理令牌，管理接口已禁用")
+			c.Abort()
+			return
+		}
+
+		if subtle.ConstantTimeCompare([]byte(c.GetHeader("X-Admin-Token")), []byte(token)) != 1 {
+			utils.Error(c, http.StatusForbidden, "无效的管理令牌")
+			c.Abort()
+			return
+		}
+
+		id, ok := identity.FromContext(c.Request.Context())
+		if !ok || !id.HasRole(identity.AdminRole) {
+			utils.Error(c, http.StatusForbidden, "需要管理员权限")
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
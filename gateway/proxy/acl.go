@@ -0,0 +1,397 @@
+package proxy
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/netip"
+	"path"
+	"sky_ISService/config"
+	"sky_ISService/shared/cache"
+	"sky_ISService/utils"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 访问控制条目类型
+const (
+	ACLBlacklist = "blacklist" // 黑名单：拒绝匹配的 IP
+	ACLWhitelist = "whitelist" // 白名单：启用后只允许匹配的 IP
+	ACLRoute     = "route"     // 受限路径：匹配路径只允许指定的 IP
+)
+
+const (
+	aclEntriesKey    = "gateway:acl:entries" // Redis Hash：条目 ID -> 条目 JSON
+	aclChannel       = "gateway:acl:changed" // 条目变更通知频道，用于多实例同步
+	aclReloadPeriod  = 30 * time.Second      // 定期全量加载，兜底丢失的变更通知
+	aclSourceConfig  = "config"              // 来自配置文件的条目
+	aclSourceRuntime = "runtime"             // 通过管理接口添加的条目
+)
+
+// ACLEntry 访问控制条目
+type ACLEntry struct {
+	ID        string     `json:"id"`
+	Type      string     `json:"type"`                 // blacklist、whitelist、route
+	CIDR      string     `json:"cidr"`                 // IP 或 CIDR，支持 IPv6
+	Path      string     `json:"path,omitempty"`       // 受限路径（仅 route 类型）：前缀 /admin、glob /admin/*/users 或 /admin/**
+	Reason    string     `json:"reason"`               // 添加原因
+	ExpiresAt *time.Time `json:"expires_at,omitempty"` // 过期时间，为空表示永久有效
+	CreatedAt time.Time  `json:"created_at"`
+	CreatedBy string     `json:"created_by,omitempty"`
+	Source    string     `json:"source"` // config 或 runtime
+}
+
+// 预解析后的条目
+type compiledACLEntry struct {
+	entry  ACLEntry
+	prefix netip.Prefix
+}
+
+// 判断条目是否已过期
+func (e *compiledACLEntry) expired(now time.Time) bool {
+	return e.entry.ExpiresAt != nil && !now.Before(*e.entry.ExpiresAt)
+}
+
+// ACL 网关访问控制列表：配置文件中的静态条目 + Redis 中的运行时条目，多个网关实例通过 Redis 发布订阅同步
+type ACL struct {
+	redisClient *cache.RedisClient
+
+	mu      sync.RWMutex
+	static  []*compiledACLEntry // 配置文件条目
+	runtime []*compiledACLEntry // Redis 条目
+
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewACL 创建访问控制列表，并加载配置文件中的静态条目
+func NewACL(redisClient *cache.RedisClient, settings config.ACLConfig) *ACL {
+	a := &ACL{redisClient: redisClient}
+	a.SetStatic(settings)
+	return a
+}
+
+// SetStatic 替换配置文件中的静态条目（配置文件重新加载时调用），Redis 中的运行时条目不受影响
+func (a *ACL) SetStatic(settings config.ACLConfig) {
+	var static []ACLEntry
+	for _, value := range settings.Blacklist {
+		static = append(static, ACLEntry{Type: ACLBlacklist, CIDR: value, Reason: "配置文件"})
+	}
+	for _, value := range settings.Whitelist {
+		static = append(static, ACLEntry{Type: ACLWhitelist, CIDR: value, Reason: "配置文件"})
+	}
+	for routePath, values := range settings.RestrictedRoutes {
+		for _, value := range values {
+			static = append(static, ACLEntry{Type: ACLRoute, CIDR: value, Path: routePath, Reason: "配置文件"})
+		}
+	}
+	compiledEntries := make([]*compiledACLEntry, 0, len(static))
+	for i, entry := range static {
+		entry.ID = fmt.Sprintf("config-%d", i)
+		entry.Source = aclSourceConfig
+		compiled, err := compileACLEntry(entry)
+		if err != nil {
+			log.Printf("忽略无效的 ACL 配置: %v", err)
+			continue
+		}
+		compiledEntries = append(compiledEntries, compiled)
+	}
+
+	a.mu.Lock()
+	a.static = compiledEntries
+	a.mu.Unlock()
+}
+
+// Start 加载 Redis 中的条目，并订阅变更通知
+func (a *ACL) Start() {
+	if a.redisClient == nil {
+		return
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	a.cancel = cancel
+
+	if err := a.Reload(ctx); err != nil {
+		log.Printf("加载 ACL 失败: %v", err)
+	}
+
+	a.wg.Add(1)
+	go func() {
+		defer a.wg.Done()
+		a.watch(ctx)
+	}()
+}
+
+// Stop 停止同步
+func (a *ACL) Stop() {
+	if a.cancel != nil {
+		a.cancel()
+	}
+	a.wg.Wait()
+}
+
+// Reload 从 Redis 全量加载运行时条目，并清理已过期的条目
+func (a *ACL) Reload(ctx context.Context) error {
+	values, err := a.redisClient.Client.HGetAll(ctx, aclEntriesKey).Result()
+	if err != nil {
+		return fmt.Errorf("读取 ACL 条目失败: %v", err)
+	}
+
+	now := time.Now()
+	var expiredIDs []string
+	runtime := make([]*compiledACLEntry, 0, len(values))
+	for id, value := range values {
+		var entry ACLEntry
+		if err := json.Unmarshal([]byte(value), &entry); err != nil {
+			log.Printf("忽略无法解析的 ACL 条目 %s: %v", id, err)
+			continue
+		}
+		compiled, err := compileACLEntry(entry)
+		if err != nil {
+			log.Printf("忽略无效的 ACL 条目 %s: %v", id, err)
+			continue
+		}
+		if compiled.expired(now) {
+			expiredIDs = append(expiredIDs, id)
+			continue
+		}
+		runtime = append(runtime, compiled)
+	}
+	if len(expiredIDs) > 0 {
+		a.redisClient.Client.HDel(ctx, aclEntriesKey, expiredIDs...)
+	}
+
+	a.mu.Lock()
+	a.runtime = runtime
+	a.mu.Unlock()
+	return nil
+}
+
+// Add 添加运行时条目，保存到 Redis 并通知其他网关实例
+func (a *ACL) Add(ctx context.Context, entry ACLEntry) (*ACLEntry, error) {
+	if a.redisClient == nil {
+		return nil, fmt.Errorf("redis 未初始化，无法保存 ACL 条目")
+	}
+
+	entry.ID = newACLID()
+	entry.Source = aclSourceRuntime
+	entry.CreatedAt = time.Now()
+	compiled, err := compileACLEntry(entry)
+	if err != nil {
+		return nil, err
+	}
+	if compiled.expired(entry.CreatedAt) {
+		return nil, fmt.Errorf("过期时间必须晚于当前时间")
+	}
+
+	data, err := json.Marshal(compiled.entry)
+	if err != nil {
+		return nil, fmt.Errorf("序列化 ACL 条目失败: %v", err)
+	}
+	if err := a.redisClient.Client.HSet(ctx, aclEntriesKey, entry.ID, data).Err(); err != nil {
+		return nil, fmt.Errorf("保存 ACL 条目失败: %v", err)
+	}
+
+	a.mu.Lock()
+	a.runtime = append(a.runtime, compiled)
+	a.mu.Unlock()
+	a.publish(ctx)
+	return &compiled.entry, nil
+}
+
+// Remove 删除运行时条目（配置文件中的条目不能通过接口删除）
+func (a *ACL) Remove(ctx context.Context, id string) error {
+	if a.redisClient == nil {
+		return fmt.Errorf("redis 未初始化，无法删除 ACL 条目")
+	}
+
+	deleted, err := a.redisClient.Client.HDel(ctx, aclEntriesKey, id).Result()
+	if err != nil {
+		return fmt.Errorf("删除 ACL 条目失败: %v", err)
+	}
+	if deleted == 0 {
+		return fmt.Errorf("ACL 条目不存在")
+	}
+
+	a.mu.Lock()
+	runtime := make([]*compiledACLEntry, 0, len(a.runtime))
+	for _, compiled := range a.runtime {
+		if compiled.entry.ID != id {
+			runtime = append(runtime, compiled)
+		}
+	}
+	a.runtime = runtime
+	a.mu.Unlock()
+	a.publish(ctx)
+	return nil
+}
+
+// List 返回所有未过期的条目，按类型和创建时间排序
+func (a *ACL) List() []ACLEntry {
+	now := time.Now()
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	entries := make([]ACLEntry, 0, len(a.static)+len(a.runtime))
+	for _, compiled := range a.all() {
+		if !compiled.expired(now) {
+			entries = append(entries, compiled.entry)
+		}
+	}
+	sort.SliceStable(entries, func(i, j int) bool {
+		if entries[i].Type != entries[j].Type {
+			return entries[i].Type < entries[j].Type
+		}
+		return entries[i].CreatedAt.Before(entries[j].CreatedAt)
+	})
+	return entries
+}
+
+// IsBlacklisted 判断 IP 是否在黑名单中，返回命中的条目
+func (a *ACL) IsBlacklisted(addr netip.Addr) (*ACLEntry, bool) {
+	if !addr.IsValid() {
+		return nil, false
+	}
+	now := time.Now()
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	for _, compiled := range a.all() {
+		if compiled.entry.Type == ACLBlacklist && !compiled.expired(now) && compiled.prefix.Contains(addr) {
+			entry := compiled.entry
+			return &entry, true
+		}
+	}
+	return nil, false
+}
+
+// IsWhitelisted 判断 IP 是否允许通过白名单：白名单为空或本机回环地址直接通过
+func (a *ACL) IsWhitelisted(addr netip.Addr) bool {
+	if addr.IsValid() && addr.IsLoopback() {
+		return true
+	}
+	now := time.Now()
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	hasWhitelist := false
+	for _, compiled := range a.all() {
+		if compiled.entry.Type != ACLWhitelist || compiled.expired(now) {
+			continue
+		}
+		hasWhitelist = true
+		if addr.IsValid() && compiled.prefix.Contains(addr) {
+			return true
+		}
+	}
+	return !hasWhitelist
+}
+
+// IsRouteAllowed 检查受限路径：请求路径命中受限规则时，IP 必须在该路径允许的网段内
+func (a *ACL) IsRouteAllowed(requestPath string, addr netip.Addr) bool {
+	now := time.Now()
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	restricted := false
+	for _, compiled := range a.all() {
+		if compiled.entry.Type != ACLRoute || compiled.expired(now) || !matchACLPath(compiled.entry.Path, requestPath) {
+			continue
+		}
+		restricted = true
+		if addr.IsValid() && compiled.prefix.Contains(addr) {
+			return true
+		}
+	}
+	return !restricted
+}
+
+// 所有条目（调用方需持有读锁）
+func (a *ACL) all() []*compiledACLEntry {
+	entries := make([]*compiledACLEntry, 0, len(a.static)+len(a.runtime))
+	entries = append(entries, a.static...)
+	return append(entries, a.runtime...)
+}
+
+// 订阅变更通知并定期全量加载
+func (a *ACL) watch(ctx context.Context) {
+	pubsub := a.redisClient.Client.Subscribe(ctx, aclChannel)
+	defer pubsub.Close()
+	messages := pubsub.Channel()
+
+	ticker := time.NewTicker(aclReloadPeriod)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-messages:
+		case <-ticker.C:
+		}
+		if err := a.Reload(ctx); err != nil && ctx.Err() == nil {
+			log.Printf("同步 ACL 失败: %v", err)
+		}
+	}
+}
+
+// 通知其他网关实例重新加载
+func (a *ACL) publish(ctx context.Context) {
+	if err := a.redisClient.Client.Publish(ctx, aclChannel, time.Now().UnixNano()).Err(); err != nil {
+		log.Printf("发布 ACL 变更通知失败: %v", err)
+	}
+}
+
+// 校验并预解析条目
+func compileACLEntry(entry ACLEntry) (*compiledACLEntry, error) {
+	switch entry.Type {
+	case ACLBlacklist, ACLWhitelist:
+		entry.Path = ""
+	case ACLRoute:
+		if !strings.HasPrefix(entry.Path, "/") {
+			return nil, fmt.Errorf("受限路径必须以 / 开头: %s", entry.Path)
+		}
+		if _, err := path.Match(entry.Path, "/"); err != nil {
+			return nil, fmt.Errorf("无效的路径规则 %s: %v", entry.Path, err)
+		}
+	default:
+		return nil, fmt.Errorf("未知的 ACL 类型: %s", entry.Type)
+	}
+
+	prefix, err := utils.ParseIPOrCIDR(entry.CIDR)
+	if err != nil {
+		return nil, err
+	}
+	entry.CIDR = prefix.String()
+	return &compiledACLEntry{entry: entry, prefix: prefix}, nil
+}
+
+// 路径匹配：以 /** 结尾或不含通配符时按路径段前缀匹配，否则按 glob 匹配（* 不跨越 /）
+func matchACLPath(pattern, requestPath string) bool {
+	if strings.HasSuffix(pattern, "/**") {
+		return matchPathPrefix(strings.TrimSuffix(pattern, "/**"), requestPath)
+	}
+	if !strings.ContainsAny(pattern, "*?[") {
+		return matchPathPrefix(pattern, requestPath)
+	}
+	matched, _ := path.Match(pattern, requestPath)
+	return matched
+}
+
+// 按路径段匹配前缀：/admin 匹配 /admin 和 /admin/users，不匹配 /administrator
+func matchPathPrefix(prefix, requestPath string) bool {
+	prefix = strings.TrimSuffix(prefix, "/")
+	if prefix == "" {
+		return true
+	}
+	return requestPath == prefix || strings.HasPrefix(requestPath, prefix+"/")
+}
+
+// 生成条目 ID
+func newACLID() string {
+	buf := make([]byte, 8)
+	if _, err := rand.Read(buf); err != nil {
+		return fmt.Sprintf("%x", time.Now().UnixNano())
+	}
+	return hex.EncodeToString(buf)
+}
@@ -0,0 +1,165 @@
+package proxy
+
+import (
+	"net/netip"
+	"sky_ISService/config"
+	"testing"
+	"time"
+)
+
+func TestMatchACLPath(t *testing.T) {
+	tests := []struct {
+		pattern string
+		path    string
+		want    bool
+	}{
+		// 不含通配符：按路径段前缀匹配
+		{pattern: "/admin", path: "/admin", want: true},
+		{pattern: "/admin", path: "/admin/users", want: true},
+		{pattern: "/admin", path: "/administrator", want: false},
+		{pattern: "/admin/", path: "/admin/users", want: true},
+		{pattern: "/admin/", path: "/admin", want: true},
+		{pattern: "/", path: "/anything", want: true},
+		// /** 结尾：按路径段前缀匹配
+		{pattern: "/admin/**", path: "/admin", want: true},
+		{pattern: "/admin/**", path: "/admin/a/b/c", want: true},
+		{pattern: "/admin/**", path: "/admins", want: false},
+		// glob：* 不跨越 /
+		{pattern: "/admin/*/users", path: "/admin/1/users", want: true},
+		{pattern: "/admin/*/users", path: "/admin/1/2/users", want: false},
+		{pattern: "/admin/*/users", path: "/admin/1/users/2", want: false},
+		{pattern: "/api/v?/health", path: "/api/v1/health", want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.pattern+" "+tt.path, func(t *testing.T) {
+			if got := matchACLPath(tt.pattern, tt.path); got != tt.want {
+				t.Fatalf("得到 %v，期望 %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCleanPath(t *testing.T) {
+	tests := []struct {
+		path string
+		want string
+	}{
+		{path: "", want: "/"},
+		{path: "/", want: "/"},
+		{path: "admin", want: "/admin"},
+		{path: "/admin/../system/users", want: "/system/users"},
+		{path: "//admin//users", want: "/admin/users"},
+		{path: "/admin/./users/", want: "/admin/users/"},
+		{path: "/../../admin", want: "/admin"},
+		{path: "/system/..", want: "/"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			if got := cleanPath(tt.path); got != tt.want {
+				t.Fatalf("得到 %s，期望 %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestACL(t *testing.T) {
+	acl := NewACL(nil, config.ACLConfig{
+		Blacklist:        []string{"203.0.113.0/24", "::ffff:198.51.100.7"},
+		RestrictedRoutes: map[string][]string{"/admin": {"10.0.0.0/8"}},
+	})
+	expired := time.Now().Add(-time.Minute)
+	acl.runtime = append(acl.runtime, mustCompileACLEntry(t, ACLEntry{Type: ACLBlacklist, CIDR: "192.0.2.1", ExpiresAt: &expired}))
+
+	tests := []struct {
+		name          string
+		ip            string
+		path          string
+		wantBlacklist bool
+		wantRoute     bool
+	}{
+		{name: "黑名单网段", ip: "203.0.113.9", path: "/system", wantBlacklist: true, wantRoute: true},
+		{name: "IPv4 映射的黑名单条目", ip: "198.51.100.7", path: "/system", wantBlacklist: true, wantRoute: true},
+		{name: "已过期的条目", ip: "192.0.2.1", path: "/system", wantRoute: true},
+		{name: "受限路径允许的网段", ip: "10.1.2.3", path: "/admin/users", wantRoute: true},
+		{name: "受限路径拒绝其他网段", ip: "192.0.2.5", path: "/admin/users"},
+		{name: "相似前缀不受限", ip: "192.0.2.5", path: "/administrator", wantRoute: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			addr := netip.MustParseAddr(tt.ip)
+			if _, got := acl.IsBlacklisted(addr); got != tt.wantBlacklist {
+				t.Fatalf("黑名单判断为 %v，期望 %v", got, tt.wantBlacklist)
+			}
+			if got := acl.IsRouteAllowed(tt.path, addr); got != tt.wantRoute {
+				t.Fatalf("受限路径判断为 %v，期望 %v", got, tt.wantRoute)
+			}
+		})
+	}
+}
+
+func TestACLWhitelist(t *testing.T) {
+	tests := []struct {
+		name      string
+		whitelist []string
+		ip        string
+		want      bool
+	}{
+		{name: "未配置白名单", ip: "192.0.2.1", want: true},
+		{name: "命中白名单", whitelist: []string{"192.0.2.0/24"}, ip: "192.0.2.1", want: true},
+		{name: "不在白名单", whitelist: []string{"192.0.2.0/24"}, ip: "198.51.100.1", want: false},
+		{name: "本机地址始终通过", whitelist: []string{"192.0.2.0/24"}, ip: "127.0.0.1", want: true},
+		{name: "IPv6 白名单", whitelist: []string{"2001:db8::/32"}, ip: "2001:db8::5", want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			acl := NewACL(nil, config.ACLConfig{Whitelist: tt.whitelist})
+			if got := acl.IsWhitelisted(netip.MustParseAddr(tt.ip)); got != tt.want {
+				t.Fatalf("得到 %v，期望 %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCompileACLEntry(t *testing.T) {
+	tests := []struct {
+		name     string
+		entry    ACLEntry
+		wantCIDR string
+		wantErr  bool
+	}{
+		{name: "单个 IP", entry: ACLEntry{Type: ACLBlacklist, CIDR: "192.0.2.1"}, wantCIDR: "192.0.2.1/32"},
+		{name: "网段按掩码归一", entry: ACLEntry{Type: ACLWhitelist, CIDR: "10.1.2.3/8"}, wantCIDR: "10.0.0.0/8"},
+		{name: "受限路径", entry: ACLEntry{Type: ACLRoute, CIDR: "10.0.0.0/8", Path: "/admin/*"}, wantCIDR: "10.0.0.0/8"},
+		{name: "受限路径不以 / 开头", entry: ACLEntry{Type: ACLRoute, CIDR: "10.0.0.0/8", Path: "admin"}, wantErr: true},
+		{name: "无效的路径规则", entry: ACLEntry{Type: ACLRoute, CIDR: "10.0.0.0/8", Path: "/admin/["}, wantErr: true},
+		{name: "未知类型", entry: ACLEntry{Type: "deny", CIDR: "10.0.0.0/8"}, wantErr: true},
+		{name: "无效的 CIDR", entry: ACLEntry{Type: ACLBlacklist, CIDR: "10.0.0.0/40"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			compiled, err := compileACLEntry(tt.entry)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("期望校验失败")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("校验失败: %v", err)
+			}
+			if compiled.entry.CIDR != tt.wantCIDR {
+				t.Fatalf("CIDR 为 %s，期望 %s", compiled.entry.CIDR, tt.wantCIDR)
+			}
+		})
+	}
+}
+
+// 测试用的已校验条目
+func mustCompileACLEntry(t *testing.T, entry ACLEntry) *compiledACLEntry {
+	t.Helper()
+	compiled, err := compileACLEntry(entry)
+	if err != nil {
+		t.Fatalf("校验条目失败: %v", err)
+	}
+	return compiled
+}
@@ -0,0 +1,629 @@
+package proxy
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"sky_ISService/pkg/identity"
+	"sky_ISService/shared/cache"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// APIKeyHeader 机器客户端携带 API Key 的请求头
+const APIKeyHeader = "X-API-Key"
+
+const (
+	apiKeyPrefix          = "sk_"                       // API Key 格式：sk_<ID>_<密钥>
+	apiKeysKey            = "gateway:apikeys"           // Redis Hash：Key ID -> Key JSON（只保存哈希）
+	apiKeysLastUsedKey    = "gateway:apikeys:last_used" // Redis Hash：Key ID -> 最后使用时间（Unix 秒）
+	apiKeysChannel        = "gateway:apikeys:changed"   // 变更通知频道，用于多实例同步
+	apiKeyQuotaKeyPrefix  = "gateway:apikeys:quota:"    // 配额计数 key 前缀
+	apiKeysReloadPeriod   = 30 * time.Second            // 定期全量加载，兜底丢失的变更通知
+	apiKeyLastUsedPeriod  = time.Minute                 // 最后使用时间最多每分钟写一次 Redis
+	apiKeyQuotaTimeout    = 200 * time.Millisecond      // Redis 配额操作超时，超时放行
+	apiKeySecretBytes     = 32
+	apiKeyDailyQuotaTTL   = 48 * time.Hour
+	apiKeyMonthlyQuotaTTL = 32 * 24 * time.Hour
+)
+
+var (
+	ErrAPIKeyInvalid  = errors.New("无效的 API Key")
+	ErrAPIKeyDisabled = errors.New("API Key 已停用")
+	ErrAPIKeyExpired  = errors.New("API Key 已过期")
+	ErrAPIKeyScope    = errors.New("API Key 无权访问该接口")
+	ErrAPIKeyQuota    = errors.New("API Key 配额已用完")
+)
+
+// 配额脚本：任一周期的计数已达上限时拒绝（不计数），否则两个周期同时加一
+// 返回 {是否放行, 当日计数, 当月计数}
+var apiKeyQuotaScript = redis.NewScript(`
+local daily = tonumber(redis.call('GET', KEYS[1]) or '0')
+local monthly = tonumber(redis.call('GET', KEYS[2]) or '0')
+local dailyLimit = tonumber(ARGV[1])
+local monthlyLimit = tonumber(ARGV[2])
+if (dailyLimit > 0 and daily >= dailyLimit) or (monthlyLimit > 0 and monthly >= monthlyLimit) then
+  return {0, daily, monthly}
+end
+daily = redis.call('INCR', KEYS[1])
+if daily == 1 then
+  redis.call('EXPIRE', KEYS[1], ARGV[3])
+end
+monthly = redis.call('INCR', KEYS[2])
+if monthly == 1 then
+  redis.call('EXPIRE', KEYS[2], ARGV[4])
+end
+return {1, daily, monthly}
+`)
+
+// APIKeyScope 授权范围：路由前缀（按路径段匹配）与允许的 HTTP 方法
+type APIKeyScope struct {
+	Prefix  string   `json:"prefix"`            // 如 /system/api/v1/orders
+	Methods []string `json:"methods,omitempty"` // 为空表示所有方法
+}
+
+// APIKey 机器客户端（ERP、仓储等集成）使用的长期凭证，Redis 中只保存密钥的哈希
+type APIKey struct {
+	ID           string        `json:"id"`
+	Name         string        `json:"name"`
+	Prefix       string        `json:"prefix"`            // Key 的前几位，便于识别
+	UserID       string        `json:"user_id,omitempty"` // 转发给上游服务的用户 ID，为空时为 apikey:<ID>
+	Roles        []string      `json:"roles,omitempty"`   // 转发给上游服务的角色
+	Scopes       []APIKeyScope `json:"scopes"`
+	DailyQuota   int64         `json:"daily_quota"`   // 每日请求上限，0 表示不限
+	MonthlyQuota int64         `json:"monthly_quota"` // 每月请求上限，0 表示不限
+	Disabled     bool          `json:"disabled"`
+	ExpiresAt    *time.Time    `json:"expires_at,omitempty"` // 过期时间，为空表示永久有效
+	CreatedAt    time.Time     `json:"created_at"`
+	CreatedBy    string        `json:"created_by,omitempty"`
+	LastUsedAt   *time.Time    `json:"last_used_at,omitempty"`
+}
+
+// Redis 中保存的记录
+type apiKeyRecord struct {
+	APIKey
+	Hash string `json:"hash"` // SHA-256(完整 Key)
+}
+
+// APIKeyUsage 配额使用情况
+type APIKeyUsage struct {
+	Daily        int64         `json:"daily"`
+	DailyQuota   int64         `json:"daily_quota"`
+	Monthly      int64         `json:"monthly"`
+	MonthlyQuota int64         `json:"monthly_quota"`
+	RetryAfter   time.Duration `json:"-"` // 配额用完时距离额度恢复（次日或次月）的时间
+}
+
+// WriteHeaders 写入配额响应头（只写出配置了上限的周期），配额用完时附带 Retry-After
+func (u *APIKeyUsage) WriteHeaders(w http.ResponseWriter) {
+	if u.RetryAfter > 0 {
+		w.Header().Set("Retry-After", strconv.Itoa(ceilSeconds(u.RetryAfter)))
+	}
+	if u.DailyQuota > 0 {
+		w.Header().Set("X-Quota-Daily-Limit", strconv.FormatInt(u.DailyQuota, 10))
+		w.Header().Set("X-Quota-Daily-Remaining", strconv.FormatInt(max(u.DailyQuota-u.Daily, 0), 10))
+	}
+	if u.MonthlyQuota > 0 {
+		w.Header().Set("X-Quota-Monthly-Limit", strconv.FormatInt(u.MonthlyQuota, 10))
+		w.Header().Set("X-Quota-Monthly-Remaining", strconv.FormatInt(max(u.MonthlyQuota-u.Monthly, 0), 10))
+	}
+}
+
+// 上下文中保存已认证 API Key 的 key
+type apiKeyContextKey struct{}
+
+// WithAPIKey 将认证通过的 API Key 保存到上下文，限流按 Key 计数时使用
+func WithAPIKey(ctx context.Context, key *APIKey) context.Context {
+	return context.WithValue(ctx, apiKeyContextKey{}, key)
+}
+
+// APIKeyFromContext 读取上下文中认证通过的 API Key，未使用 API Key 认证时返回 false
+func APIKeyFromContext(ctx context.Context) (*APIKey, bool) {
+	key, ok := ctx.Value(apiKeyContextKey{}).(*APIKey)
+	return key, ok
+}
+
+// Identity 转发给上游服务的调用方身份
+func (k *APIKey) Identity() *identity.Identity {
+	userID := k.UserID
+	if userID == "" {
+		userID = "apikey:" + k.ID
+	}
+	return &identity.Identity{UserID: userID, Username: k.Name, Roles: k.Roles, TokenID: k.ID}
+}
+
+// 判断 Key 是否已过期
+func (k *APIKey) expired(now time.Time) bool {
+	return k.ExpiresAt != nil && !now.Before(*k.ExpiresAt)
+}
+
+// 判断请求是否在授权范围内
+func (k *APIKey) allows(method, requestPath string) bool {
+	for _, scope := range k.Scopes {
+		if !matchPathPrefix(scope.Prefix, requestPath) {
+			continue
+		}
+		if len(scope.Methods) == 0 {
+			return true
+		}
+		for _, m := range scope.Methods {
+			if strings.EqualFold(m, method) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// APIKeys API Key 管理：Key 保存在 Redis 中，多个网关实例通过 Redis 发布订阅同步，校验在内存中完成
+type APIKeys struct {
+	redisClient *cache.RedisClient
+
+	mu       sync.RWMutex
+	keys     map[string]*apiKeyRecord // Key ID -> 记录
+	lastUsed map[string]time.Time     // Key ID -> 最后使用时间
+	recorded map[string]time.Time     // Key ID -> 最后一次写入 Redis 的使用时间
+
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewAPIKeys 创建 API Key 管理
+func NewAPIKeys(redisClient *cache.RedisClient) *APIKeys {
+	return &APIKeys{
+		redisClient: redisClient,
+		keys:        make(map[string]*apiKeyRecord),
+		lastUsed:    make(map[string]time.Time),
+		recorded:    make(map[string]time.Time),
+	}
+}
+
+// Start 加载 Redis 中的 Key，并订阅变更通知
+func (a *APIKeys) Start() {
+	if a.redisClient == nil {
+		return
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	a.cancel = cancel
+
+	if err := a.Reload(ctx); err != nil {
+		log.Printf("加载 API Key 失败: %v", err)
+	}
+
+	a.wg.Add(1)
+	go func() {
+		defer a.wg.Done()
+		a.watch(ctx)
+	}()
+}
+
+// Stop 停止同步
+func (a *APIKeys) Stop() {
+	if a.cancel != nil {
+		a.cancel()
+	}
+	a.wg.Wait()
+}
+
+// Reload 从 Redis 全量加载 Key 及最后使用时间
+func (a *APIKeys) Reload(ctx context.Context) error {
+	values, err := a.redisClient.Client.HGetAll(ctx, apiKeysKey).Result()
+	if err != nil {
+		return fmt.Errorf("读取 API Key 失败: %v", err)
+	}
+	lastUsedValues, err := a.redisClient.Client.HGetAll(ctx, apiKeysLastUsedKey).Result()
+	if err != nil {
+		return fmt.Errorf("读取 API Key 使用时间失败: %v", err)
+	}
+
+	keys := make(map[string]*apiKeyRecord, len(values))
+	for id, value := range values {
+		var record apiKeyRecord
+		if err := json.Unmarshal([]byte(value), &record); err != nil {
+			log.Printf("忽略无法解析的 API Key %s: %v", id, err)
+			continue
+		}
+		keys[id] = &record
+	}
+
+	a.mu.Lock()
+	a.keys = keys
+	for id, value := range lastUsedValues {
+		seconds, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			continue
+		}
+		if usedAt := time.Unix(seconds, 0); usedAt.After(a.lastUsed[id]) {
+			a.lastUsed[id] = usedAt
+		}
+	}
+	a.mu.Unlock()
+	return nil
+}
+
+// Create 创建 Key，返回的明文 Key 只在创建时出现一次
+func (a *APIKeys) Create(ctx context.Context, key APIKey) (*APIKey, string, error) {
+	if a.redisClient == nil {
+		return nil, "", fmt.Errorf("redis 未初始化，无法保存 API Key")
+	}
+	if err := validateAPIKey(&key); err != nil {
+		return nil, "", err
+	}
+
+	id, secret, err := newAPIKeySecret()
+	if err != nil {
+		return nil, "", err
+	}
+	plaintext := apiKeyPrefix + id + "_" + secret
+	key.ID = id
+	key.Prefix = plaintext[:len(apiKeyPrefix)+len(id)+5]
+	key.CreatedAt = time.Now()
+	key.LastUsedAt = nil
+	if key.expired(key.CreatedAt) {
+		return nil, "", fmt.Errorf("过期时间必须晚于当前时间")
+	}
+
+	record := &apiKeyRecord{APIKey: key, Hash: hashAPIKey(plaintext)}
+	if err := a.save(ctx, record); err != nil {
+		return nil, "", err
+	}
+	return &record.APIKey, plaintext, nil
+}
+
+// Update 修改 Key 的名称、身份、授权范围、配额、状态与过期时间，ID 与密钥不变
+func (a *APIKeys) Update(ctx context.Context, key APIKey) (*APIKey, error) {
+	if a.redisClient == nil {
+		return nil, fmt.Errorf("redis 未初始化，无法保存 API Key")
+	}
+	if err := validateAPIKey(&key); err != nil {
+		return nil, err
+	}
+
+	a.mu.RLock()
+	current, ok := a.keys[key.ID]
+	a.mu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("API Key 不存在")
+	}
+
+	record := &apiKeyRecord{APIKey: current.APIKey, Hash: current.Hash}
+	record.Name = key.Name
+	record.UserID = key.UserID
+	record.Roles = key.Roles
+	record.Scopes = key.Scopes
+	record.DailyQuota = key.DailyQuota
+	record.MonthlyQuota = key.MonthlyQuota
+	record.Disabled = key.Disabled
+	record.ExpiresAt = key.ExpiresAt
+	if err := a.save(ctx, record); err != nil {
+		return nil, err
+	}
+	a.mu.RLock()
+	updated := a.withLastUsed(record)
+	a.mu.RUnlock()
+	return &updated, nil
+}
+
+// Remove 删除（吊销）Key
+func (a *APIKeys) Remove(ctx context.Context, id string) error {
+	if a.redisClient == nil {
+		return fmt.Errorf("redis 未初始化，无法删除 API Key")
+	}
+
+	deleted, err := a.redisClient.Client.HDel(ctx, apiKeysKey, id).Result()
+	if err != nil {
+		return fmt.Errorf("删除 API Key 失败: %v", err)
+	}
+	if deleted == 0 {
+		return fmt.Errorf("API Key 不存在")
+	}
+	a.redisClient.Client.HDel(ctx, apiKeysLastUsedKey, id)
+
+	a.mu.Lock()
+	delete(a.keys, id)
+	delete(a.lastUsed, id)
+	delete(a.recorded, id)
+	a.mu.Unlock()
+	a.publish(ctx)
+	return nil
+}
+
+// List 返回所有 Key，按创建时间排序
+func (a *APIKeys) List() []APIKey {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	keys := make([]APIKey, 0, len(a.keys))
+	for _, record := range a.keys {
+		keys = append(keys, a.withLastUsed(record))
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i].CreatedAt.Before(keys[j].CreatedAt) })
+	return keys
+}
+
+// Get 返回指定的 Key
+func (a *APIKeys) Get(id string) (*APIKey, bool) {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	record, ok := a.keys[id]
+	if !ok {
+		return nil, false
+	}
+	key := a.withLastUsed(record)
+	return &key, true
+}
+
+// Authenticate 校验 Key 与授权范围，不计入配额；通过校验的请求在访问控制与限流之后调用 Consume 计入配额
+func (a *APIKeys) Authenticate(plaintext, method, requestPath string) (*APIKey, error) {
+	key, err := a.authenticate(plaintext, method, requestPath, time.Now())
+	if err != nil {
+		apiKeyRequests.WithLabelValues(apiKeyResult(err)).Inc()
+	}
+	return key, err
+}
+
+func (a *APIKeys) authenticate(plaintext, method, requestPath string, now time.Time) (*APIKey, error) {
+	id, ok := parseAPIKeyID(plaintext)
+	if !ok {
+		return nil, ErrAPIKeyInvalid
+	}
+	a.mu.RLock()
+	record, ok := a.keys[id]
+	a.mu.RUnlock()
+	if !ok || subtle.ConstantTimeCompare([]byte(hashAPIKey(plaintext)), []byte(record.Hash)) != 1 {
+		return nil, ErrAPIKeyInvalid
+	}
+
+	key := &record.APIKey
+	switch {
+	case key.Disabled:
+		return nil, ErrAPIKeyDisabled
+	case key.expired(now):
+		return nil, ErrAPIKeyExpired
+	case !key.allows(method, requestPath):
+		return nil, ErrAPIKeyScope
+	}
+	return key, nil
+}
+
+// Consume 计入配额并记录最后使用时间，返回配额使用情况
+// 配额超限时同时返回使用情况（用于响应头）与 ErrAPIKeyQuota；Redis 不可用时不限制配额（fail open）
+func (a *APIKeys) Consume(ctx context.Context, key *APIKey) (*APIKeyUsage, error) {
+	now := time.Now()
+	usage, err := a.consume(ctx, key, now)
+	if err != nil {
+		log.Printf("API Key %s 配额检查失败，放行请求: %v", key.ID, err)
+		usage, err = nil, nil
+	} else if usage.RetryAfter > 0 {
+		err = ErrAPIKeyQuota
+	}
+	apiKeyRequests.WithLabelValues(apiKeyResult(err)).Inc()
+	if err != nil {
+		return usage, err
+	}
+	a.touch(ctx, key.ID, now)
+	return usage, nil
+}
+
+// 指标中的校验结果
+func apiKeyResult(err error) string {
+	switch {
+	case err == nil:
+		return "ok"
+	case errors.Is(err, ErrAPIKeyDisabled):
+		return "disabled"
+	case errors.Is(err, ErrAPIKeyExpired):
+		return "expired"
+	case errors.Is(err, ErrAPIKeyScope):
+		return "scope"
+	case errors.Is(err, ErrAPIKeyQuota):
+		return "quota"
+	default:
+		return "invalid"
+	}
+}
+
+// Usage 查询 Key 当日、当月已使用的请求数
+func (a *APIKeys) Usage(ctx context.Context, id string) (*APIKeyUsage, error) {
+	if a.redisClient == nil {
+		return nil, fmt.Errorf("redis 未初始化，无法查询配额")
+	}
+	key, ok := a.Get(id)
+	if !ok {
+		return nil, fmt.Errorf("API Key 不存在")
+	}
+
+	dailyKey, monthlyKey := apiKeyQuotaKeys(id, time.Now())
+	values, err := a.redisClient.Client.MGet(ctx, dailyKey, monthlyKey).Result()
+	if err != nil {
+		return nil, fmt.Errorf("查询配额失败: %v", err)
+	}
+	usage := &APIKeyUsage{DailyQuota: key.DailyQuota, MonthlyQuota: key.MonthlyQuota}
+	if value, ok := values[0].(string); ok {
+		usage.Daily, _ = strconv.ParseInt(value, 10, 64)
+	}
+	if value, ok := values[1].(string); ok {
+		usage.Monthly, _ = strconv.ParseInt(value, 10, 64)
+	}
+	return usage, nil
+}
+
+// 计入配额，超限时返回的 RetryAfter 大于 0；未配置配额时不访问 Redis
+func (a *APIKeys) consume(ctx context.Context, key *APIKey, now time.Time) (*APIKeyUsage, error) {
+	usage := &APIKeyUsage{DailyQuota: key.DailyQuota, MonthlyQuota: key.MonthlyQuota}
+	if key.DailyQuota <= 0 && key.MonthlyQuota <= 0 {
+		return usage, nil
+	}
+	if a.redisClient == nil {
+		return nil, fmt.Errorf("redis 未初始化")
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, apiKeyQuotaTimeout)
+	defer cancel()
+	dailyKey, monthlyKey := apiKeyQuotaKeys(key.ID, now)
+	values, err := apiKeyQuotaScript.Run(ctx, a.redisClient.Client, []string{dailyKey, monthlyKey},
+		key.DailyQuota, key.MonthlyQuota, int(apiKeyDailyQuotaTTL.Seconds()), int(apiKeyMonthlyQuotaTTL.Seconds())).Slice()
+	if err != nil {
+		return nil, err
+	}
+	if len(values) != 3 {
+		return nil, fmt.Errorf("配额脚本返回值异常: %v", values)
+	}
+	usage.Daily = toInt64(values[1])
+	usage.Monthly = toInt64(values[2])
+	if toInt64(values[0]) != 1 {
+		year, month, day := now.Date()
+		if key.MonthlyQuota > 0 && usage.Monthly >= key.MonthlyQuota {
+			usage.RetryAfter = time.Date(year, month+1, 1, 0, 0, 0, 0, now.Location()).Sub(now)
+		} else {
+			usage.RetryAfter = time.Date(year, month, day+1, 0, 0, 0, 0, now.Location()).Sub(now)
+		}
+	}
+	return usage, nil
+}
+
+// 记录最后使用时间，每个 Key 最多每分钟写一次 Redis
+func (a *APIKeys) touch(ctx context.Context, id string, now time.Time) {
+	a.mu.Lock()
+	a.lastUsed[id] = now
+	persist := a.redisClient != nil && now.Sub(a.recorded[id]) >= apiKeyLastUsedPeriod
+	if persist {
+		a.recorded[id] = now
+	}
+	a.mu.Unlock()
+
+	if persist {
+		go func() {
+			ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), apiKeyQuotaTimeout)
+			defer cancel()
+			if err := a.redisClient.Client.HSet(ctx, apiKeysLastUsedKey, id, now.Unix()).Err(); err != nil {
+				log.Printf("记录 API Key %s 使用时间失败: %v", id, err)
+			}
+		}()
+	}
+}
+
+// 保存记录到 Redis 并通知其他网关实例
+func (a *APIKeys) save(ctx context.Context, record *apiKeyRecord) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("序列化 API Key 失败: %v", err)
+	}
+	if err := a.redisClient.Client.HSet(ctx, apiKeysKey, record.ID, data).Err(); err != nil {
+		return fmt.Errorf("保存 API Key 失败: %v", err)
+	}
+
+	a.mu.Lock()
+	a.keys[record.ID] = record
+	a.mu.Unlock()
+	a.publish(ctx)
+	return nil
+}
+
+// 附带最后使用时间的 Key（调用方需持有读锁）
+func (a *APIKeys) withLastUsed(record *apiKeyRecord) APIKey {
+	key := record.APIKey
+	if usedAt, ok := a.lastUsed[key.ID]; ok {
+		key.LastUsedAt = &usedAt
+	}
+	return key
+}
+
+// 订阅变更通知并定期全量加载
+func (a *APIKeys) watch(ctx context.Context) {
+	pubsub := a.redisClient.Client.Subscribe(ctx, apiKeysChannel)
+	defer pubsub.Close()
+	messages := pubsub.Channel()
+
+	ticker := time.NewTicker(apiKeysReloadPeriod)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-messages:
+		case <-ticker.C:
+		}
+		if err := a.Reload(ctx); err != nil && ctx.Err() == nil {
+			log.Printf("同步 API Key 失败: %v", err)
+		}
+	}
+}
+
+// 通知其他网关实例重新加载
+func (a *APIKeys) publish(ctx context.Context) {
+	if err := a.redisClient.Client.Publish(ctx, apiKeysChannel, time.Now().UnixNano()).Err(); err != nil {
+		log.Printf("发布 API Key 变更通知失败: %v", err)
+	}
+}
+
+// 校验名称、授权范围与配额，并规范化 HTTP 方法
+func validateAPIKey(key *APIKey) error {
+	if strings.TrimSpace(key.Name) == "" {
+		return fmt.Errorf("名称不能为空")
+	}
+	if len(key.Scopes) == 0 {
+		return fmt.Errorf("至少配置一个授权范围")
+	}
+	for i, scope := range key.Scopes {
+		if !strings.HasPrefix(scope.Prefix, "/") {
+			return fmt.Errorf("授权范围的前缀必须以 / 开头: %s", scope.Prefix)
+		}
+		for j, method := range scope.Methods {
+			key.Scopes[i].Methods[j] = strings.ToUpper(method)
+		}
+	}
+	if key.DailyQuota < 0 || key.MonthlyQuota < 0 {
+		return fmt.Errorf("配额不能为负数")
+	}
+	return nil
+}
+
+// 生成 Key ID 与密钥
+func newAPIKeySecret() (string, string, error) {
+	buf := make([]byte, 8+apiKeySecretBytes)
+	if _, err := rand.Read(buf); err != nil {
+		return "", "", fmt.Errorf("生成 API Key 失败: %v", err)
+	}
+	return hex.EncodeToString(buf[:8]), base64.RawURLEncoding.EncodeToString(buf[8:]), nil
+}
+
+// 从明文 Key 中取出 ID
+func parseAPIKeyID(plaintext string) (string, bool) {
+	rest, ok := strings.CutPrefix(plaintext, apiKeyPrefix)
+	if !ok {
+		return "", false
+	}
+	id, secret, ok := strings.Cut(rest, "_")
+	return id, ok && id != "" && secret != ""
+}
+
+// Key 的哈希：Key 本身是高熵随机数，使用 SHA-256 即可，无需慢哈希
+func hashAPIKey(plaintext string) string {
+	sum := sha256.Sum256([]byte(plaintext))
+	return hex.EncodeToString(sum[:])
+}
+
+// 配额计数 key：按本地时间的自然日、自然月
+func apiKeyQuotaKeys(id string, now time.Time) (string, string) {
+	return apiKeyQuotaKeyPrefix + id + ":day:" + now.Format("20060102"),
+		apiKeyQuotaKeyPrefix + id + ":month:" + now.Format("200601")
+}
+
+// APIKeys 返回 API Key 管理
+func (p *Proxy) APIKeys() *APIKeys {
+	return p.apiKeys
+}
@@ -0,0 +1,129 @@
+package proxy
+
+import (
+	"context"
+	"errors"
+	"sky_ISService/shared/cache"
+	"testing"
+	"time"
+)
+
+// 使用进程内 Redis 的 API Key 管理
+func newTestAPIKeys(t *testing.T) *APIKeys {
+	t.Helper()
+	redisClient, err := cache.NewMemoryRedis()
+	if err != nil {
+		t.Fatalf("启动进程内 Redis 失败: %v", err)
+	}
+	t.Cleanup(func() { _ = redisClient.Close() })
+	return NewAPIKeys(redisClient)
+}
+
+// 创建 Key，返回明文
+func createTestAPIKey(t *testing.T, keys *APIKeys, key APIKey) (*APIKey, string) {
+	t.Helper()
+	if key.Name == "" {
+		key.Name = "erp"
+	}
+	if key.Scopes == nil {
+		key.Scopes = []APIKeyScope{{Prefix: "/system/api/v1/orders", Methods: []string{"get"}}}
+	}
+	created, plaintext, err := keys.Create(context.Background(), key)
+	if err != nil {
+		t.Fatalf("创建 API Key 失败: %v", err)
+	}
+	return created, plaintext
+}
+
+func TestAPIKeyAuthenticate(t *testing.T) {
+	keys := newTestAPIKeys(t)
+	now := time.Now()
+	expiresAt := now.Add(time.Hour)
+	_, valid := createTestAPIKey(t, keys, APIKey{ExpiresAt: &expiresAt})
+	_, disabled := createTestAPIKey(t, keys, APIKey{Disabled: true})
+
+	tests := []struct {
+		name      string
+		plaintext string
+		method    string
+		path      string
+		at        time.Time
+		wantErr   error
+	}{
+		{name: "有效", plaintext: valid, method: "GET", path: "/system/api/v1/orders/1", at: now},
+		{name: "前缀本身", plaintext: valid, method: "GET", path: "/system/api/v1/orders", at: now},
+		{name: "方法不区分大小写", plaintext: valid, method: "get", path: "/system/api/v1/orders", at: now},
+		{name: "格式错误", plaintext: "not-a-key", method: "GET", path: "/system/api/v1/orders", at: now, wantErr: ErrAPIKeyInvalid},
+		{name: "密钥错误", plaintext: valid + "x", method: "GET", path: "/system/api/v1/orders", at: now, wantErr: ErrAPIKeyInvalid},
+		{name: "未知 ID", plaintext: apiKeyPrefix + "0000000000000000_secret", method: "GET", path: "/system/api/v1/orders", at: now, wantErr: ErrAPIKeyInvalid},
+		{name: "已停用", plaintext: disabled, method: "GET", path: "/system/api/v1/orders", at: now, wantErr: ErrAPIKeyDisabled},
+		{name: "已过期", plaintext: valid, method: "GET", path: "/system/api/v1/orders", at: expiresAt, wantErr: ErrAPIKeyExpired},
+		{name: "方法不在授权范围内", plaintext: valid, method: "POST", path: "/system/api/v1/orders", at: now, wantErr: ErrAPIKeyScope},
+		{name: "前缀按路径段匹配", plaintext: valid, method: "GET", path: "/system/api/v1/orders-admin", at: now, wantErr: ErrAPIKeyScope},
+		{name: "其他路径", plaintext: valid, method: "GET", path: "/system/menu", at: now, wantErr: ErrAPIKeyScope},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			key, err := keys.authenticate(tt.plaintext, tt.method, tt.path, tt.at)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("authenticate 返回 %v，期望 %v", err, tt.wantErr)
+			}
+			if (key != nil) != (tt.wantErr == nil) {
+				t.Fatalf("authenticate 返回 Key %+v", key)
+			}
+		})
+	}
+}
+
+func TestAPIKeyStoresOnlyHash(t *testing.T) {
+	keys := newTestAPIKeys(t)
+	created, plaintext := createTestAPIKey(t, keys, APIKey{})
+	record := keys.keys[created.ID]
+	if record.Hash != hashAPIKey(plaintext) || record.Hash == plaintext {
+		t.Fatalf("保存的哈希为 %q", record.Hash)
+	}
+	if got := created.Identity(); got.UserID != "apikey:"+created.ID || got.TokenID != created.ID {
+		t.Fatalf("Key 的身份为 %+v", got)
+	}
+}
+
+// 校验不计入配额，Consume 计数到上限后拒绝且不再计数
+func TestAPIKeyConsumeQuota(t *testing.T) {
+	keys := newTestAPIKeys(t)
+	ctx := context.Background()
+	key, plaintext := createTestAPIKey(t, keys, APIKey{DailyQuota: 2, MonthlyQuota: 10})
+
+	for i := 0; i < 3; i++ {
+		if _, err := keys.Authenticate(plaintext, "GET", "/system/api/v1/orders"); err != nil {
+			t.Fatalf("Authenticate 返回 %v", err)
+		}
+	}
+	if usage, err := keys.Usage(ctx, key.ID); err != nil || usage.Daily != 0 {
+		t.Fatalf("校验后配额为 %+v（%v），期望未计数", usage, err)
+	}
+
+	for i := 1; i <= 2; i++ {
+		usage, err := keys.Consume(ctx, key)
+		if err != nil || usage.Daily != int64(i) || usage.Monthly != int64(i) {
+			t.Fatalf("第 %d 次 Consume 返回 %+v（%v）", i, usage, err)
+		}
+	}
+	usage, err := keys.Consume(ctx, key)
+	if !errors.Is(err, ErrAPIKeyQuota) || usage.RetryAfter <= 0 || usage.Daily != 2 {
+		t.Fatalf("超过每日配额时返回 %+v（%v）", usage, err)
+	}
+	if usage, _ := keys.Usage(ctx, key.ID); usage.Daily != 2 || usage.Monthly != 2 {
+		t.Fatalf("拒绝的请求不应计数，配额为 %+v", usage)
+	}
+}
+
+// 未配置配额时不访问 Redis，Redis 不可用时放行
+func TestAPIKeyConsumeWithoutQuota(t *testing.T) {
+	keys := NewAPIKeys(nil)
+	if usage, err := keys.Consume(context.Background(), &APIKey{ID: "k1"}); err != nil || usage.Daily != 0 {
+		t.Fatalf("未配置配额时返回 %+v（%v）", usage, err)
+	}
+	if usage, err := keys.Consume(context.Background(), &APIKey{ID: "k2", DailyQuota: 1}); err != nil || usage != nil {
+		t.Fatalf("Redis 不可用时返回 %+v（%v），期望放行", usage, err)
+	}
+}
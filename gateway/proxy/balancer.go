@@ -0,0 +1,266 @@
+package proxy
+
+import (
+	"fmt"
+	"hash/crc32"
+	"log"
+	"math/rand"
+	"net/http"
+	"sky_ISService/config"
+	"sky_ISService/pkg/identity"
+	"sort"
+	"sync"
+)
+
+// 负载均衡策略
+const (
+	BalancerRandom         = "random"          // 加权随机（默认）
+	BalancerRoundRobin     = "round_robin"     // 平滑加权轮询
+	BalancerLeastRequest   = "least_request"   // 最少未完成请求
+	BalancerConsistentHash = "consistent_hash" // 一致性哈希（会话保持）
+)
+
+// 一致性哈希的取值来源
+const (
+	HashOnIP     = "ip"     // 客户端 IP
+	HashOnHeader = "header" // 指定请求头
+	HashOnJWT    = "jwt"    // 已认证用户的 ID（JWT 中的 sub_id）
+)
+
+// 每单位权重对应的虚拟节点数
+const virtualNodesPerWeight = 10
+
+// Balancer 负载均衡器
+type Balancer interface {
+	// Pick 从候选节点中选择一个，weights 与 nodes 一一对应，为按健康状态调整后的权重（均大于 0）
+	Pick(nodes []*WeightedNode, weights []int, r *http.Request) *WeightedNode
+	// Done 请求结束时回调
+	Done(node *WeightedNode)
+}
+
+// NewBalancer 根据上游配置创建负载均衡器，未知策略回退到加权随机
+func NewBalancer(upstream config.UpstreamConfig) Balancer {
+	switch upstream.Balancer {
+	case "", BalancerRandom:
+		return &randomBalancer{}
+	case BalancerRoundRobin:
+		return newRoundRobinBalancer()
+	case BalancerLeastRequest:
+		return newLeastRequestBalancer()
+	case BalancerConsistentHash:
+		return newConsistentHashBalancer(upstream.HashOn, upstream.HashHeader)
+	default:
+		log.Printf("未知的负载均衡策略 %s，使用加权随机", upstream.Balancer)
+		return &randomBalancer{}
+	}
+}
+
+// randomBalancer 加权随机
+type randomBalancer struct{}
+
+func (b *randomBalancer) Pick(nodes []*WeightedNode, weights []int, r *http.Request) *WeightedNode {
+	totalWeight := 0
+	for _, weight := range weights {
+		totalWeight += weight
+	}
+
+	randWeight := rand.Intn(totalWeight)
+	for i, node := range nodes {
+		if randWeight < weights[i] {
+			return node
+		}
+		randWeight -= weights[i]
+	}
+	return nodes[len(nodes)-1] // 兜底，防止异常
+}
+
+func (b *randomBalancer) Done(node *WeightedNode) {}
+
+// roundRobinBalancer 平滑加权轮询（与 nginx 的 smooth weighted round-robin 一致）
+type roundRobinBalancer struct {
+	mu      sync.Mutex
+	current map[string]int // 节点地址 -> 当前权重
+}
+
+func newRoundRobinBalancer() *roundRobinBalancer {
+	return &roundRobinBalancer{current: make(map[string]int)}
+}
+
+func (b *roundRobinBalancer) Pick(nodes []*WeightedNode, weights []int, r *http.Request) *WeightedNode {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	// 只保留当前候选节点的状态，节点下线后自动清理
+	current := make(map[string]int, len(nodes))
+	totalWeight := 0
+	var best *WeightedNode
+	for i, node := range nodes {
+		current[node.addr] = b.current[node.addr] + weights[i]
+		totalWeight += weights[i]
+		if best == nil || current[node.addr] > current[best.addr] {
+			best = node
+		}
+	}
+	current[best.addr] -= totalWeight
+	b.current = current
+	return best
+}
+
+func (b *roundRobinBalancer) Done(node *WeightedNode) {}
+
+// leastRequestBalancer 最少未完成请求（按权重归一化）
+type leastRequestBalancer struct {
+	mu       sync.Mutex
+	inflight map[string]int // 节点地址 -> 未完成请求数
+}
+
+func newLeastRequestBalancer() *leastRequestBalancer {
+	return &leastRequestBalancer{inflight: make(map[string]int)}
+}
+
+func (b *leastRequestBalancer) Pick(nodes []*WeightedNode, weights []int, r *http.Request) *WeightedNode {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	// 比较 (inflight+1)/weight，交叉相乘避免浮点运算；负载相同时随机打散
+	var best *WeightedNode
+	bestIndex := 0
+	ties := 0
+	for i, node := range nodes {
+		if best == nil {
+			best, bestIndex, ties = node, i, 1
+			continue
+		}
+		left := (b.inflight[node.addr] + 1) * weights[bestIndex]
+		right := (b.inflight[best.addr] + 1) * weights[i]
+		switch {
+		case left < right:
+			best, bestIndex, ties = node, i, 1
+		case left == right:
+			ties++
+			if rand.Intn(ties) == 0 {
+				best, bestIndex = node, i
+			}
+		}
+	}
+	b.inflight[best.addr]++
+	return best
+}
+
+func (b *leastRequestBalancer) Done(node *WeightedNode) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.inflight[node.addr] <= 1 {
+		delete(b.inflight, node.addr)
+		return
+	}
+	b.inflight[node.addr]--
+}
+
+// consistentHashBalancer 一致性哈希，相同的 key 始终落到同一节点（节点变化时只影响少量 key）。
+// 哈希环按服务的全部节点与配置的权重构建，不受慢启动、健康状态影响；被摘除（熔断、重试已尝试）的节点在查找时跳过，
+// 原本落在该节点上的 key 顺延到环上的下一个节点，其余 key 不受影响
+type consistentHashBalancer struct {
+	hashOn     string
+	hashHeader string
+
+	mu      sync.Mutex
+	version uint64      // 当前哈希环对应的节点列表版本，节点（含配置的权重）变化时版本递增，哈希环随之重建
+	ring    []ringPoint // 按 hash 排序的虚拟节点
+}
+
+// 哈希环上的虚拟节点
+type ringPoint struct {
+	hash uint32
+	node *WeightedNode
+}
+
+func newConsistentHashBalancer(hashOn, hashHeader string) *consistentHashBalancer {
+	if hashOn == "" {
+		hashOn = HashOnIP
+	}
+	return &consistentHashBalancer{hashOn: hashOn, hashHeader: hashHeader}
+}
+
+// Pick 在候选节点构建的哈希环上选择（每次重新构建），调整后的权重不参与哈希
+func (b *consistentHashBalancer) Pick(nodes []*WeightedNode, weights []int, r *http.Request) *WeightedNode {
+	return b.lookup(buildRing(nodes), nodes, r)
+}
+
+// PickFrom 在服务全部节点构建的哈希环上查找，跳过不在候选节点中的节点；
+// version 为节点列表的版本，版本不变时复用已构建的哈希环
+func (b *consistentHashBalancer) PickFrom(all []*WeightedNode, version uint64, candidates []*WeightedNode, r *http.Request) *WeightedNode {
+	return b.lookup(b.ringFor(all, version), candidates, r)
+}
+
+func (b *consistentHashBalancer) Done(node *WeightedNode) {}
+
+// 从 key 的 hash 位置顺时针查找第一个候选节点
+func (b *consistentHashBalancer) lookup(ring []ringPoint, candidates []*WeightedNode, r *http.Request) *WeightedNode {
+	hash := crc32.ChecksumIEEE([]byte(b.hashKey(r)))
+	allowed := make(map[*WeightedNode]bool, len(candidates))
+	for _, node := range candidates {
+		allowed[node] = true
+	}
+	start := sort.Search(len(ring), func(i int) bool { return ring[i].hash >= hash })
+	for i := 0; i < len(ring); i++ {
+		if point := ring[(start+i)%len(ring)]; allowed[point.node] {
+			return point.node
+		}
+	}
+	// 候选节点不在哈希环上（不应出现），按 hash 取一个候选节点兜底
+	return candidates[hash%uint32(len(candidates))]
+}
+
+// 获取（节点列表版本变化时重建）哈希环
+func (b *consistentHashBalancer) ringFor(nodes []*WeightedNode, version uint64) []ringPoint {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.ring != nil && version == b.version {
+		return b.ring
+	}
+	b.version = version
+	b.ring = buildRing(nodes)
+	return b.ring
+}
+
+// 按节点配置的权重构建哈希环
+func buildRing(nodes []*WeightedNode) []ringPoint {
+	var ring []ringPoint
+	for _, node := range nodes {
+		weight := node.weight
+		if weight <= 0 {
+			weight = 1
+		}
+		for v := 0; v < weight*virtualNodesPerWeight; v++ {
+			hash := crc32.ChecksumIEEE([]byte(fmt.Sprintf("%s#%d", node.addr, v)))
+			ring = append(ring, ringPoint{hash: hash, node: node})
+		}
+	}
+	sort.Slice(ring, func(i, j int) bool { return ring[i].hash < ring[j].hash })
+	return ring
+}
+
+// 根据配置提取哈希 key，取不到时回退到客户端 IP
+func (b *consistentHashBalancer) hashKey(r *http.Request) string {
+	switch b.hashOn {
+	case HashOnHeader:
+		if value := r.Header.Get(b.hashHeader); value != "" {
+			return value
+		}
+	case HashOnJWT:
+		if userID := authenticatedUser(r); userID != "" {
+			return userID
+		}
+	}
+	return getClientIP(r)
+}
+
+// 请求的已认证用户 ID（由 JWT、API Key 或验签插件校验后写入上下文），未认证时返回空字符串。
+// 不能直接解析 Authorization 头，未校验的 JWT 可以任意伪造 sub_id
+func authenticatedUser(r *http.Request) string {
+	if id, ok := identity.FromContext(r.Context()); ok {
+		return id.UserID
+	}
+	return ""
+}
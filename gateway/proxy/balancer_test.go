@@ -0,0 +1,215 @@
+package proxy
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sky_ISService/config"
+	"sky_ISService/pkg/identity"
+	"strings"
+	"testing"
+)
+
+// 测试用的节点，weights 为配置的权重
+func testNodes(weights ...int) []*WeightedNode {
+	nodes := make([]*WeightedNode, len(weights))
+	for i, weight := range weights {
+		nodes[i] = &WeightedNode{addr: fmt.Sprintf("10.0.0.%d:8080", i+1), weight: weight}
+	}
+	return nodes
+}
+
+func TestNewBalancer(t *testing.T) {
+	tests := []struct {
+		balancer string
+		want     string
+	}{
+		{balancer: "", want: "*proxy.randomBalancer"},
+		{balancer: BalancerRandom, want: "*proxy.randomBalancer"},
+		{balancer: BalancerRoundRobin, want: "*proxy.roundRobinBalancer"},
+		{balancer: BalancerLeastRequest, want: "*proxy.leastRequestBalancer"},
+		{balancer: BalancerConsistentHash, want: "*proxy.consistentHashBalancer"},
+		{balancer: "unknown", want: "*proxy.randomBalancer"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.balancer, func(t *testing.T) {
+			if got := fmt.Sprintf("%T", NewBalancer(config.UpstreamConfig{Balancer: tt.balancer})); got != tt.want {
+				t.Fatalf("得到 %s，期望 %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRoundRobinBalancer(t *testing.T) {
+	tests := []struct {
+		name    string
+		weights []int
+		want    string // 一轮内依次选中的节点序号
+	}{
+		{name: "等权重", weights: []int{1, 1, 1}, want: "012"},
+		{name: "平滑加权", weights: []int{5, 1, 1}, want: "0010200"},
+		{name: "两个节点", weights: []int{2, 1}, want: "010"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			nodes := testNodes(tt.weights...)
+			index := make(map[*WeightedNode]int, len(nodes))
+			for i, node := range nodes {
+				index[node] = i
+			}
+			balancer := newRoundRobinBalancer()
+			r := httptest.NewRequest("GET", "/", nil)
+			// 连续两轮结果相同
+			for round := 0; round < 2; round++ {
+				var got strings.Builder
+				for range tt.want {
+					fmt.Fprint(&got, index[balancer.Pick(nodes, tt.weights, r)])
+				}
+				if got.String() != tt.want {
+					t.Fatalf("第 %d 轮选中 %s，期望 %s", round+1, got.String(), tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestLeastRequestBalancer(t *testing.T) {
+	nodes := testNodes(1, 2)
+	weights := []int{1, 2}
+	balancer := newLeastRequestBalancer()
+	r := httptest.NewRequest("GET", "/", nil)
+
+	// 按 (未完成请求数+1)/权重 选择：权重 2 的节点承担两倍的请求
+	counts := map[*WeightedNode]int{}
+	for i := 0; i < 6; i++ {
+		counts[balancer.Pick(nodes, weights, r)]++
+	}
+	if counts[nodes[0]] != 2 || counts[nodes[1]] != 4 {
+		t.Fatalf("未完成请求分布为 %d:%d，期望 2:4", counts[nodes[0]], counts[nodes[1]])
+	}
+
+	// 节点 1 的请求全部结束后优先选择它
+	for i := 0; i < 4; i++ {
+		balancer.Done(nodes[1])
+	}
+	if node := balancer.Pick(nodes, weights, r); node != nodes[1] {
+		t.Fatalf("选中 %s，期望 %s", node.addr, nodes[1].addr)
+	}
+	balancer.Done(nodes[1])
+	balancer.Done(nodes[0])
+	balancer.Done(nodes[0])
+	balancer.Done(nodes[0]) // 多余的回调不会产生负数
+	if len(balancer.inflight) != 0 {
+		t.Fatalf("请求全部结束后仍有记录: %v", balancer.inflight)
+	}
+}
+
+func TestRandomBalancer(t *testing.T) {
+	nodes := testNodes(3, 1)
+	weights := []int{3, 1}
+	balancer := &randomBalancer{}
+	r := httptest.NewRequest("GET", "/", nil)
+
+	counts := map[*WeightedNode]int{}
+	for i := 0; i < 4000; i++ {
+		counts[balancer.Pick(nodes, weights, r)]++
+	}
+	// 期望 3000:1000，允许一定的随机误差
+	if counts[nodes[0]] < 2700 || counts[nodes[0]] > 3300 {
+		t.Fatalf("加权随机分布为 %d:%d，期望约 3000:1000", counts[nodes[0]], counts[nodes[1]])
+	}
+}
+
+func TestConsistentHashKey(t *testing.T) {
+	tests := []struct {
+		name   string
+		hashOn string
+		header string
+		userID string
+		want   string
+	}{
+		{name: "默认按 IP", want: "192.0.2.1"},
+		{name: "按请求头", hashOn: HashOnHeader, header: "tenant-a", want: "tenant-a"},
+		{name: "请求头为空时回退到 IP", hashOn: HashOnHeader, want: "192.0.2.1"},
+		{name: "按已认证用户", hashOn: HashOnJWT, userID: "42", want: "42"},
+		{name: "未认证时回退到 IP", hashOn: HashOnJWT, want: "192.0.2.1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			balancer := newConsistentHashBalancer(tt.hashOn, "X-Tenant")
+			r := httptest.NewRequest("GET", "/", nil)
+			r.RemoteAddr = "192.0.2.1:4000"
+			// 未经校验的 JWT 不参与哈希
+			r.Header.Set("Authorization", "Bearer forged")
+			if tt.header != "" {
+				r.Header.Set("X-Tenant", tt.header)
+			}
+			if tt.userID != "" {
+				r = r.WithContext(identity.WithIdentity(r.Context(), &identity.Identity{UserID: tt.userID}))
+			}
+			if got := balancer.hashKey(r); got != tt.want {
+				t.Fatalf("得到 %s，期望 %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConsistentHashBalancer(t *testing.T) {
+	nodes := testNodes(1, 1, 2)
+	balancer := newConsistentHashBalancer(HashOnHeader, "X-Tenant")
+	request := func(key string) *http.Request {
+		r := httptest.NewRequest("GET", "/", nil)
+		r.Header.Set("X-Tenant", key)
+		return r
+	}
+
+	// 相同的 key 始终落到同一节点
+	assigned := make(map[string]*WeightedNode)
+	counts := map[*WeightedNode]int{}
+	for i := 0; i < 600; i++ {
+		key := fmt.Sprintf("tenant-%d", i)
+		node := balancer.PickFrom(nodes, 1, nodes, request(key))
+		if again := balancer.PickFrom(nodes, 1, nodes, request(key)); again != node {
+			t.Fatalf("key %s 两次落到不同节点", key)
+		}
+		assigned[key] = node
+		counts[node]++
+	}
+	for _, node := range nodes {
+		if counts[node] == 0 {
+			t.Fatalf("节点 %s 没有分到任何 key", node.addr)
+		}
+	}
+
+	// 摘除一个节点：只有原本落在该节点上的 key 迁移
+	removed := nodes[2]
+	candidates := nodes[:2]
+	for key, node := range assigned {
+		got := balancer.PickFrom(nodes, 1, candidates, request(key))
+		if got == removed {
+			t.Fatalf("key %s 落到了已摘除的节点", key)
+		}
+		if node != removed && got != node {
+			t.Fatalf("key %s 从未摘除的节点 %s 迁移到 %s", key, node.addr, got.addr)
+		}
+	}
+}
+
+func TestConsistentHashRingCache(t *testing.T) {
+	nodes := testNodes(1, 1)
+	balancer := newConsistentHashBalancer(HashOnIP, "")
+
+	ring := balancer.ringFor(nodes, 1)
+	if len(ring) != 2*virtualNodesPerWeight {
+		t.Fatalf("虚拟节点数为 %d，期望 %d", len(ring), 2*virtualNodesPerWeight)
+	}
+	// 版本不变时复用哈希环
+	if again := balancer.ringFor(testNodes(5), 1); &again[0] != &ring[0] {
+		t.Fatal("节点列表版本未变化时重建了哈希环")
+	}
+	// 版本变化（含权重变化）时重建
+	reweighted := testNodes(1, 3)
+	if rebuilt := balancer.ringFor(reweighted, 2); len(rebuilt) != 4*virtualNodesPerWeight {
+		t.Fatalf("版本变化后虚拟节点数为 %d，期望 %d", len(rebuilt), 4*virtualNodesPerWeight)
+	}
+}
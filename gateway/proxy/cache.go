@@ -0,0 +1,433 @@
+package proxy
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"sky_ISService/pkg/identity"
+	"sky_ISService/pkg/tracing"
+	"sky_ISService/shared/cache"
+	"sky_ISService/shared/mq"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	cacheEntryPrefix       = "gateway:cache:entry:"
+	cachePathIndexPrefix   = "gateway:cache:path:" // 路径前缀 -> 缓存 key 集合
+	cacheTagIndexPrefix    = "gateway:cache:tag:"  // 标签 -> 缓存 key 集合
+	cacheTimeout           = 200 * time.Millisecond
+	defaultCacheMaxBody    = 1 << 20
+	cacheConsumerRetryWait = 5 * time.Second
+	cacheIndexMinTTL       = 24 * time.Hour // 索引的最短保留时间，避免短 TTL 的条目缩短共享索引的寿命
+)
+
+// 不随缓存保存的响应头（X-Request-ID 使用当前请求的值）
+var uncachedHeaders = []string{"Set-Cookie", "Connection", "Keep-Alive", "Transfer-Encoding", "Date", "Age", "X-Cache", tracing.RequestIDHeader}
+
+// 缓存的响应
+type cachedResponse struct {
+	Status   int         `json:"status"`
+	Header   http.Header `json:"header"`
+	Body     []byte      `json:"body"`
+	ETag     string      `json:"etag"`
+	StoredAt time.Time   `json:"stored_at"`
+}
+
+// 一次可缓存请求的上下文，在请求转发前确定，响应返回后用于写入缓存
+type cacheRequest struct {
+	route       *Route
+	key         string
+	path        string
+	ifNoneMatch string
+}
+
+// ResponseCache 基于 Redis 的网关响应缓存，按路由开启，多个网关实例共享
+type ResponseCache struct {
+	redisClient *cache.RedisClient
+
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewResponseCache 创建响应缓存，redisClient 为空时缓存不生效
+func NewResponseCache(redisClient *cache.RedisClient) *ResponseCache {
+	return &ResponseCache{redisClient: redisClient}
+}
+
+// Lookup 查找缓存，命中时直接写出响应并返回 served=true；
+// 未命中且请求可缓存时返回 cacheRequest，用于转发后写入缓存
+func (c *ResponseCache) Lookup(w http.ResponseWriter, r *http.Request, route *Route) (req *cacheRequest, served bool) {
+	if c.redisClient == nil || !route.Cache.Enabled || r.Method != http.MethodGet {
+		return nil, false
+	}
+	requestControl := parseCacheControl(r.Header.Get("Cache-Control"))
+	if _, ok := requestControl["no-store"]; ok {
+		w.Header().Set("X-Cache", "BYPASS")
+		return nil, false
+	}
+
+	req = &cacheRequest{
+		route:       route,
+		key:         cacheKey(route, r),
+		path:        r.URL.Path,
+		ifNoneMatch: r.Header.Get("If-None-Match"),
+	}
+
+	// no-cache 要求回源，但新的响应仍可写入缓存
+	if _, ok := requestControl["no-cache"]; !ok {
+		if entry := c.get(r.Context(), req.key); entry != nil {
+			c.serve(w, req, entry)
+			return nil, true
+		}
+	}
+	w.Header().Set("X-Cache", "MISS")
+	return req, false
+}
+
+// Store 根据上游响应的 Cache-Control 写入缓存，并在 If-None-Match 命中时改写为 304
+func (c *ResponseCache) Store(resp *http.Response, req *cacheRequest) error {
+	if resp.StatusCode != http.StatusOK {
+		return nil
+	}
+	ttl, ok := cacheTTL(resp, req.route)
+	if !ok {
+		return nil
+	}
+
+	maxBody := req.route.Cache.MaxBodySize
+	if maxBody <= 0 {
+		maxBody = defaultCacheMaxBody
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxBody+1))
+	if err != nil {
+		return err
+	}
+	if int64(len(body)) > maxBody {
+		// 响应体过大，不缓存，拼接回已读取的部分继续转发
+		resp.Body = readCloser{Reader: io.MultiReader(bytes.NewReader(body), resp.Body), Closer: resp.Body}
+		return nil
+	}
+	resp.Body.Close()
+
+	etag := resp.Header.Get("ETag")
+	if etag == "" {
+		sum := sha256.Sum256(body)
+		etag = `"` + hex.EncodeToString(sum[:16]) + `"`
+		resp.Header.Set("ETag", etag)
+	}
+
+	entry := &cachedResponse{Status: resp.StatusCode, Header: resp.Header.Clone(), Body: body, ETag: etag, StoredAt: time.Now()}
+	for _, name := range uncachedHeaders {
+		entry.Header.Del(name)
+	}
+	if err := c.set(req, entry, ttl); err != nil {
+		log.Printf("写入响应缓存失败: %v", err)
+	}
+
+	if etagMatches(req.ifNoneMatch, etag) {
+		resp.StatusCode = http.StatusNotModified
+		resp.Status = "304 " + http.StatusText(http.StatusNotModified)
+		resp.Header.Del("Content-Length")
+		resp.ContentLength = 0
+		resp.Body = io.NopCloser(bytes.NewReader(nil))
+		return nil
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	resp.ContentLength = int64(len(body))
+	resp.Header.Set("Content-Length", strconv.Itoa(len(body)))
+	return nil
+}
+
+// Invalidate 按路径前缀和标签失效缓存，返回删除的缓存条数
+func (c *ResponseCache) Invalidate(ctx context.Context, invalidation cache.Invalidation) (int, error) {
+	if c.redisClient == nil {
+		return 0, nil
+	}
+
+	var indexes []string
+	for _, path := range invalidation.Paths {
+		indexes = append(indexes, cachePathIndexPrefix+normalizeCachePath(path))
+	}
+	for _, tag := range invalidation.Tags {
+		indexes = append(indexes, cacheTagIndexPrefix+tag)
+	}
+
+	removed := 0
+	for _, index := range indexes {
+		keys, err := c.redisClient.Client.SMembers(ctx, index).Result()
+		if err != nil {
+			return removed, fmt.Errorf("读取缓存索引失败: %v", err)
+		}
+		if len(keys) > 0 {
+			count, err := c.redisClient.Client.Del(ctx, keys...).Result()
+			if err != nil {
+				return removed, fmt.Errorf("删除缓存失败: %v", err)
+			}
+			removed += int(count)
+		}
+		if err := c.redisClient.Client.Del(ctx, index).Err(); err != nil {
+			return removed, fmt.Errorf("删除缓存索引失败: %v", err)
+		}
+	}
+	return removed, nil
+}
+
+// Start 消费 RabbitMQ 中的缓存失效消息，连接断开后自动重试
+func (c *ResponseCache) Start(rmqClient *mq.RabbitMQClient) {
+	if c.redisClient == nil || rmqClient == nil {
+		return
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	c.cancel = cancel
+
+	c.wg.Add(1)
+	go func() {
+		defer c.wg.Done()
+		for ctx.Err() == nil {
+			err := rmqClient.Consume(ctx, cache.InvalidationQueue, func(msgCtx context.Context, body []byte) error {
+				var invalidation cache.Invalidation
+				if err := json.Unmarshal(body, &invalidation); err != nil {
+					return fmt.Errorf("无效的缓存失效消息: %v", err)
+				}
+				removed, err := c.Invalidate(msgCtx, invalidation)
+				if err != nil {
+					return err
+				}
+				log.Printf("缓存失效 paths=%v tags=%v，删除 %d 条 [%s]", invalidation.Paths, invalidation.Tags, removed, tracing.RequestID(msgCtx))
+				return nil
+			})
+			if err != nil {
+				log.Printf("缓存失效消息消费中断，%s 后重试: %v", cacheConsumerRetryWait, err)
+			}
+			sleepContext(ctx, cacheConsumerRetryWait)
+		}
+	}()
+}
+
+// Stop 停止消费缓存失效消息
+func (c *ResponseCache) Stop() {
+	if c.cancel != nil {
+		c.cancel()
+	}
+	c.wg.Wait()
+}
+
+// 读取缓存，出错时视为未命中
+func (c *ResponseCache) get(ctx context.Context, key string) *cachedResponse {
+	ctx, cancel := context.WithTimeout(ctx, cacheTimeout)
+	defer cancel()
+
+	value, err := c.redisClient.Get(ctx, key)
+	if err != nil {
+		log.Printf("读取响应缓存失败: %v", err)
+		return nil
+	}
+	if value == "" {
+		return nil
+	}
+	var entry cachedResponse
+	if err := json.Unmarshal([]byte(value), &entry); err != nil {
+		return nil
+	}
+	return &entry
+}
+
+// 写入缓存，同时登记到路径前缀和标签索引
+func (c *ResponseCache) set(req *cacheRequest, entry *cachedResponse, ttl time.Duration) error {
+	value, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), cacheTimeout)
+	defer cancel()
+
+	indexes := []string{cacheTagIndexPrefix + "route:" + req.route.Name}
+	for _, tag := range req.route.Cache.Tags {
+		indexes = append(indexes, cacheTagIndexPrefix+tag)
+	}
+	for _, prefix := range pathPrefixes(req.path) {
+		indexes = append(indexes, cachePathIndexPrefix+prefix)
+	}
+
+	// 索引只需比条目活得久，过期条目残留在索引中不影响正确性
+	indexTTL := ttl + time.Minute
+	if indexTTL < cacheIndexMinTTL {
+		indexTTL = cacheIndexMinTTL
+	}
+
+	pipe := c.redisClient.Client.TxPipeline()
+	pipe.Set(ctx, req.key, value, ttl)
+	for _, index := range indexes {
+		pipe.SAdd(ctx, index, req.key)
+		pipe.Expire(ctx, index, indexTTL)
+	}
+	_, err = pipe.Exec(ctx)
+	return err
+}
+
+// 写出缓存的响应
+func (c *ResponseCache) serve(w http.ResponseWriter, req *cacheRequest, entry *cachedResponse) {
+	for name, values := range entry.Header {
+		// 跳过不缓存的响应头（兼容写入时尚未排除的旧条目），保留网关已写入的当前请求的值
+		if isUncachedHeader(name) {
+			continue
+		}
+		// Vary 需要与网关已写入的值（如跨域的 Origin）合并
+		if name == "Vary" {
+			w.Header()[name] = append(w.Header()[name], values...)
+			continue
+		}
+		w.Header()[name] = values
+	}
+	w.Header().Set("X-Cache", "HIT")
+	w.Header().Set("Age", strconv.Itoa(int(time.Since(entry.StoredAt).Seconds())))
+	if etagMatches(req.ifNoneMatch, entry.ETag) {
+		w.Header().Del("Content-Length")
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	w.WriteHeader(entry.Status)
+	_, _ = w.Write(entry.Body)
+}
+
+// 是否为不随缓存保存的响应头
+func isUncachedHeader(name string) bool {
+	for _, uncached := range uncachedHeaders {
+		if http.CanonicalHeaderKey(name) == uncached {
+			return true
+		}
+	}
+	return false
+}
+
+// 缓存是否按调用方区分：需要认证的路由始终区分，公开路由按 vary_user 配置
+func cacheVaryUser(route *Route) bool {
+	return route.Cache.VaryUser || route.RequiresAuth()
+}
+
+// 缓存 key：路由、路径、排序后的查询参数、vary 请求头以及（按需）调用方。
+// 调用方取网关认证后的身份（JWT、API Key 或签名应用），未认证时为空
+func cacheKey(route *Route, r *http.Request) string {
+	parts := []string{route.Name, r.URL.Path, r.URL.Query().Encode()}
+	if route.Host != "" {
+		parts = append(parts, requestHost(r))
+	}
+	for _, header := range route.Cache.VaryHeaders {
+		parts = append(parts, header+"="+r.Header.Get(header))
+	}
+	if cacheVaryUser(route) {
+		user := ""
+		if id, ok := identity.FromContext(r.Context()); ok {
+			user = id.UserID
+		}
+		parts = append(parts, "user="+user)
+	}
+	sum := sha256.Sum256([]byte(strings.Join(parts, "\n")))
+	return cacheEntryPrefix + route.Name + ":" + hex.EncodeToString(sum[:])
+}
+
+// 根据上游响应头计算缓存时间，不可缓存时返回 false
+func cacheTTL(resp *http.Response, route *Route) (time.Duration, bool) {
+	if resp.Header.Get("Set-Cookie") != "" || strings.TrimSpace(resp.Header.Get("Vary")) == "*" {
+		return 0, false
+	}
+
+	control := parseCacheControl(resp.Header.Get("Cache-Control"))
+	if _, ok := control["no-store"]; ok {
+		return 0, false
+	}
+	if _, ok := control["no-cache"]; ok {
+		return 0, false
+	}
+	// private 响应只能按用户缓存
+	if _, ok := control["private"]; ok && !cacheVaryUser(route) {
+		return 0, false
+	}
+
+	for _, directive := range []string{"s-maxage", "max-age"} {
+		if value, ok := control[directive]; ok {
+			seconds, err := strconv.Atoi(value)
+			if err != nil || seconds <= 0 {
+				return 0, false
+			}
+			return time.Duration(seconds) * time.Second, true
+		}
+	}
+	if route.Cache.TTL <= 0 {
+		return 0, false
+	}
+	return route.Cache.TTL, true
+}
+
+// 解析 Cache-Control 指令
+func parseCacheControl(value string) map[string]string {
+	directives := make(map[string]string)
+	for _, part := range strings.Split(value, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		name, arg, _ := strings.Cut(part, "=")
+		directives[strings.ToLower(strings.TrimSpace(name))] = strings.Trim(strings.TrimSpace(arg), `"`)
+	}
+	return directives
+}
+
+// If-None-Match 是否命中 ETag（弱比较）
+func etagMatches(ifNoneMatch, etag string) bool {
+	if ifNoneMatch == "" || etag == "" {
+		return false
+	}
+	if strings.TrimSpace(ifNoneMatch) == "*" {
+		return true
+	}
+	etag = strings.TrimPrefix(etag, "W/")
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		if strings.TrimPrefix(strings.TrimSpace(candidate), "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// 路径的所有前缀：/system/menu/tree -> /system, /system/menu, /system/menu/tree
+// 不登记根路径，整个路由的缓存通过 route:<路由名> 标签失效
+func pathPrefixes(requestPath string) []string {
+	requestPath = normalizeCachePath(requestPath)
+	var prefixes []string
+	for i := 1; i < len(requestPath); i++ {
+		if requestPath[i] == '/' {
+			prefixes = append(prefixes, requestPath[:i])
+		}
+	}
+	if requestPath != "/" {
+		prefixes = append(prefixes, requestPath)
+	}
+	return prefixes
+}
+
+// 统一路径格式：以 / 开头，不以 / 结尾
+func normalizeCachePath(requestPath string) string {
+	return "/" + strings.Trim(requestPath, "/")
+}
+
+// 组合 Reader 与原始 Body 的 Close
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// ResponseCache 返回网关响应缓存
+func (p *Proxy) ResponseCache() *ResponseCache {
+	return p.cache
+}
@@ -0,0 +1,283 @@
+package proxy
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sky_ISService/config"
+	"sky_ISService/pkg/identity"
+	"sky_ISService/pkg/tracing"
+	"sky_ISService/shared/cache"
+	"strings"
+	"testing"
+	"time"
+)
+
+// 开启缓存的测试路由，public 为 true 时不需要认证
+func testCacheRoute(public bool, settings config.RouteCacheConfig) *Route {
+	settings.Enabled = true
+	route := &Route{RouteConfig: config.RouteConfig{Name: "menu", Prefix: "/system", Service: "system", Cache: settings}}
+	if public {
+		authRequired := false
+		route.AuthRequired = &authRequired
+	}
+	return route
+}
+
+func TestCacheTTL(t *testing.T) {
+	tests := []struct {
+		name     string
+		header   http.Header
+		public   bool
+		routeTTL time.Duration
+		want     time.Duration
+		wantOK   bool
+	}{
+		{name: "使用路由配置的 TTL", public: true, routeTTL: time.Minute, want: time.Minute, wantOK: true},
+		{name: "未配置 TTL 不缓存", public: true},
+		{name: "max-age 优先", header: http.Header{"Cache-Control": {"max-age=30"}}, public: true, routeTTL: time.Minute, want: 30 * time.Second, wantOK: true},
+		{name: "s-maxage 优先于 max-age", header: http.Header{"Cache-Control": {"max-age=30, s-maxage=10"}}, public: true, want: 10 * time.Second, wantOK: true},
+		{name: "max-age=0", header: http.Header{"Cache-Control": {"max-age=0"}}, public: true, routeTTL: time.Minute},
+		{name: "无效的 max-age", header: http.Header{"Cache-Control": {"max-age=abc"}}, public: true, routeTTL: time.Minute},
+		{name: "no-store", header: http.Header{"Cache-Control": {"no-store"}}, public: true, routeTTL: time.Minute},
+		{name: "no-cache", header: http.Header{"Cache-Control": {"No-Cache"}}, public: true, routeTTL: time.Minute},
+		{name: "Set-Cookie", header: http.Header{"Set-Cookie": {"session=1"}}, public: true, routeTTL: time.Minute},
+		{name: "Vary: *", header: http.Header{"Vary": {"*"}}, public: true, routeTTL: time.Minute},
+		{name: "公开路由的 private 响应", header: http.Header{"Cache-Control": {"private, max-age=30"}}, public: true},
+		{name: "需要认证的路由按用户缓存 private 响应", header: http.Header{"Cache-Control": {"private, max-age=30"}}, want: 30 * time.Second, wantOK: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := &http.Response{Header: tt.header}
+			if resp.Header == nil {
+				resp.Header = http.Header{}
+			}
+			got, ok := cacheTTL(resp, testCacheRoute(tt.public, config.RouteCacheConfig{TTL: tt.routeTTL}))
+			if got != tt.want || ok != tt.wantOK {
+				t.Fatalf("得到 %s（%v），期望 %s（%v）", got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestETagMatches(t *testing.T) {
+	tests := []struct {
+		ifNoneMatch string
+		etag        string
+		want        bool
+	}{
+		{ifNoneMatch: `"abc"`, etag: `"abc"`, want: true},
+		{ifNoneMatch: `W/"abc"`, etag: `"abc"`, want: true},
+		{ifNoneMatch: `"abc"`, etag: `W/"abc"`, want: true},
+		{ifNoneMatch: `"x", "abc"`, etag: `"abc"`, want: true},
+		{ifNoneMatch: "*", etag: `"abc"`, want: true},
+		{ifNoneMatch: `"x"`, etag: `"abc"`, want: false},
+		{ifNoneMatch: "", etag: `"abc"`, want: false},
+		{ifNoneMatch: `"abc"`, etag: "", want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.ifNoneMatch+" "+tt.etag, func(t *testing.T) {
+			if got := etagMatches(tt.ifNoneMatch, tt.etag); got != tt.want {
+				t.Fatalf("得到 %v，期望 %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCacheKey(t *testing.T) {
+	request := func(target, lang, userID string) *http.Request {
+		r := httptest.NewRequest("GET", target, nil)
+		if lang != "" {
+			r.Header.Set("Accept-Language", lang)
+		}
+		if userID != "" {
+			r = r.WithContext(identity.WithIdentity(r.Context(), &identity.Identity{UserID: userID}))
+		}
+		return r
+	}
+	public := testCacheRoute(true, config.RouteCacheConfig{VaryHeaders: []string{"Accept-Language"}})
+	private := testCacheRoute(false, config.RouteCacheConfig{})
+	base := cacheKey(public, request("/system/menu?a=1&b=2", "zh", ""))
+
+	tests := []struct {
+		name  string
+		route *Route
+		r     *http.Request
+		same  bool
+	}{
+		{name: "查询参数顺序不影响", route: public, r: request("/system/menu?b=2&a=1", "zh", ""), same: true},
+		{name: "公开路由不按调用方区分", route: public, r: request("/system/menu?a=1&b=2", "zh", "42"), same: true},
+		{name: "查询参数不同", route: public, r: request("/system/menu?a=2&b=2", "zh", "")},
+		{name: "路径不同", route: public, r: request("/system/menus?a=1&b=2", "zh", "")},
+		{name: "vary 请求头不同", route: public, r: request("/system/menu?a=1&b=2", "en", "")},
+		{name: "需要认证的路由按调用方区分", route: private, r: request("/system/menu?a=1&b=2", "zh", "42")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := cacheKey(tt.route, tt.r); (got == base) != tt.same {
+				t.Fatalf("缓存 key 相同为 %v，期望 %v", got == base, tt.same)
+			}
+		})
+	}
+
+	// 需要认证的路由上不同用户的缓存互不共享
+	if cacheKey(private, request("/system/menu", "", "1")) == cacheKey(private, request("/system/menu", "", "2")) {
+		t.Fatal("不同用户共用了缓存 key")
+	}
+}
+
+func TestPathPrefixes(t *testing.T) {
+	tests := []struct {
+		path string
+		want string
+	}{
+		{path: "/system/menu/tree", want: "/system,/system/menu,/system/menu/tree"},
+		{path: "/system/menu/", want: "/system,/system/menu"},
+		{path: "system", want: "/system"},
+		{path: "/", want: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			if got := strings.Join(pathPrefixes(tt.path), ","); got != tt.want {
+				t.Fatalf("得到 %s，期望 %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestResponseCache(t *testing.T) {
+	redisClient, err := cache.NewMemoryRedis()
+	if err != nil {
+		t.Fatalf("启动进程内 Redis 失败: %v", err)
+	}
+	t.Cleanup(func() { _ = redisClient.Close() })
+	responseCache := NewResponseCache(redisClient)
+	route := testCacheRoute(true, config.RouteCacheConfig{TTL: time.Minute, Tags: []string{"menu"}})
+
+	// 未命中：转发上游后写入缓存
+	w := httptest.NewRecorder()
+	req, served := responseCache.Lookup(w, httptest.NewRequest("GET", "/system/menu/tree", nil), route)
+	if served || req == nil || w.Header().Get("X-Cache") != "MISS" {
+		t.Fatalf("首次请求应当未命中: served=%v X-Cache=%s", served, w.Header().Get("X-Cache"))
+	}
+	resp := &http.Response{
+		StatusCode: http.StatusOK,
+		Header: http.Header{
+			"Content-Type":          {"application/json"},
+			"Vary":                  {"Accept-Encoding"},
+			tracing.RequestIDHeader: {"upstream-request"},
+		},
+		Body: io.NopCloser(strings.NewReader(`{"menu":[]}`)),
+	}
+	if err := responseCache.Store(resp, req); err != nil {
+		t.Fatalf("写入缓存失败: %v", err)
+	}
+	etag := resp.Header.Get("ETag")
+	if etag == "" {
+		t.Fatal("未生成 ETag")
+	}
+
+	tests := []struct {
+		name        string
+		header      http.Header
+		wantServed  bool
+		wantStatus  int
+		wantBody    string
+		wantXCache  string
+		wantVary    string
+		wantRequest string
+	}{
+		{name: "命中缓存", wantServed: true, wantStatus: http.StatusOK, wantBody: `{"menu":[]}`, wantXCache: "HIT",
+			wantVary: "Origin, Accept-Encoding", wantRequest: "current-request"},
+		{name: "ETag 匹配返回 304", header: http.Header{"If-None-Match": {etag}}, wantServed: true,
+			wantStatus: http.StatusNotModified, wantXCache: "HIT", wantVary: "Origin, Accept-Encoding", wantRequest: "current-request"},
+		{name: "no-cache 回源", header: http.Header{"Cache-Control": {"no-cache"}}, wantXCache: "MISS", wantVary: "Origin", wantRequest: "current-request"},
+		{name: "no-store 绕过缓存", header: http.Header{"Cache-Control": {"no-store"}}, wantXCache: "BYPASS", wantVary: "Origin", wantRequest: "current-request"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("GET", "/system/menu/tree", nil)
+			for name, values := range tt.header {
+				r.Header[name] = values
+			}
+			w := httptest.NewRecorder()
+			// 网关在查找缓存前已写入的响应头
+			w.Header().Set("Vary", "Origin")
+			w.Header().Set(tracing.RequestIDHeader, "current-request")
+
+			_, served := responseCache.Lookup(w, r, route)
+			if served != tt.wantServed {
+				t.Fatalf("served 为 %v，期望 %v", served, tt.wantServed)
+			}
+			if got := w.Header().Get("X-Cache"); got != tt.wantXCache {
+				t.Fatalf("X-Cache 为 %s，期望 %s", got, tt.wantXCache)
+			}
+			if got := strings.Join(w.Header().Values("Vary"), ", "); got != tt.wantVary {
+				t.Fatalf("Vary 为 %q，期望 %q", got, tt.wantVary)
+			}
+			if got := w.Header().Get(tracing.RequestIDHeader); got != tt.wantRequest {
+				t.Fatalf("%s 为 %s，期望 %s", tracing.RequestIDHeader, got, tt.wantRequest)
+			}
+			if !served {
+				return
+			}
+			if w.Code != tt.wantStatus || w.Body.String() != tt.wantBody {
+				t.Fatalf("响应 %d %q，期望 %d %q", w.Code, w.Body.String(), tt.wantStatus, tt.wantBody)
+			}
+		})
+	}
+
+	// 按路径前缀失效后不再命中
+	removed, err := responseCache.Invalidate(context.Background(), cache.Invalidation{Paths: []string{"/system/menu/"}})
+	if err != nil || removed != 1 {
+		t.Fatalf("失效缓存 %d 条（%v），期望 1 条", removed, err)
+	}
+	w = httptest.NewRecorder()
+	if _, served := responseCache.Lookup(w, httptest.NewRequest("GET", "/system/menu/tree", nil), route); served {
+		t.Fatal("失效后仍然命中缓存")
+	}
+}
+
+func TestResponseCacheStoreIfNoneMatch(t *testing.T) {
+	redisClient, err := cache.NewMemoryRedis()
+	if err != nil {
+		t.Fatalf("启动进程内 Redis 失败: %v", err)
+	}
+	t.Cleanup(func() { _ = redisClient.Close() })
+	responseCache := NewResponseCache(redisClient)
+	route := testCacheRoute(true, config.RouteCacheConfig{TTL: time.Minute, MaxBodySize: 8})
+
+	tests := []struct {
+		name       string
+		body       string
+		etag       string
+		wantStatus int
+		wantBody   string
+	}{
+		{name: "上游 ETag 与 If-None-Match 匹配改写为 304", body: "data", etag: `"v1"`, wantStatus: http.StatusNotModified},
+		{name: "ETag 不匹配", body: "data", etag: `"v2"`, wantStatus: http.StatusOK, wantBody: "data"},
+		{name: "响应体过大时不缓存也不改写", body: "0123456789", etag: `"v1"`, wantStatus: http.StatusOK, wantBody: "0123456789"},
+	}
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// 每个用例使用不同的路径，避免命中前一个用例写入的缓存
+			r := httptest.NewRequest("GET", fmt.Sprintf("/system/menu/%d", i), nil)
+			r.Header.Set("If-None-Match", `"v1"`)
+			req, _ := responseCache.Lookup(httptest.NewRecorder(), r, route)
+			resp := &http.Response{
+				StatusCode: http.StatusOK,
+				Header:     http.Header{"Etag": {tt.etag}},
+				Body:       io.NopCloser(strings.NewReader(tt.body)),
+			}
+			if err := responseCache.Store(resp, req); err != nil {
+				t.Fatalf("写入缓存失败: %v", err)
+			}
+			body, _ := io.ReadAll(resp.Body)
+			if resp.StatusCode != tt.wantStatus || string(body) != tt.wantBody {
+				t.Fatalf("响应 %d %q，期望 %d %q", resp.StatusCode, body, tt.wantStatus, tt.wantBody)
+			}
+		})
+	}
+}
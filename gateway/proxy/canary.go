@@ -0,0 +1,398 @@
+package proxy
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"hash/crc32"
+	"log"
+	"net/http"
+	"sky_ISService/config"
+	"sky_ISService/shared/cache"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	canaryRulesKey      = "gateway:canary:rules"   // Redis Hash：服务名 -> 规则 JSON
+	canaryChannel       = "gateway:canary:changed" // 规则变更通知频道，用于多实例同步
+	canaryReloadPeriod  = 30 * time.Second         // 定期全量加载，兜底丢失的变更通知
+	canaryCookie        = "sky_canary"             // 未登录用户的分组标识，保证同一浏览器始终落在同一版本
+	canaryCookieMaxAge  = 30 * 24 * time.Hour
+	canaryBuckets       = 10000 // 按比例分流的桶数，精度 0.01%
+	canarySourceConfig  = "config"
+	canarySourceRuntime = "runtime"
+)
+
+// CanaryRule 灰度发布规则
+type CanaryRule struct {
+	Service   string            `json:"service"`
+	Version   string            `json:"version"`            // 灰度版本，其余节点为稳定版本
+	Percent   float64           `json:"percent"`            // 按比例转发到灰度版本（0~100）
+	Headers   map[string]string `json:"headers,omitempty"`  // 请求头 -> 值，值为 * 表示只要存在
+	Cookies   map[string]string `json:"cookies,omitempty"`  // Cookie -> 值，值为 * 表示只要存在
+	UserIDs   []string          `json:"user_ids,omitempty"` // JWT 中的 sub_id
+	Source    string            `json:"source"`             // config 或 runtime
+	UpdatedAt time.Time         `json:"updated_at"`
+	UpdatedBy string            `json:"updated_by,omitempty"`
+}
+
+// 预处理后的规则
+type compiledCanaryRule struct {
+	rule   CanaryRule
+	users  map[string]bool
+	bucket int // 桶号小于该值的用户转发到灰度版本
+}
+
+// Canary 灰度发布：配置文件中的规则 + Redis 中的运行时规则（同一服务以运行时规则为准），多个网关实例通过 Redis 发布订阅同步
+type Canary struct {
+	redisClient *cache.RedisClient
+
+	mu      sync.RWMutex
+	static  map[string]*compiledCanaryRule // 服务名 -> 配置文件规则
+	runtime map[string]*compiledCanaryRule // 服务名 -> Redis 规则
+
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewCanary 创建灰度发布管理，并加载配置文件中的规则
+func NewCanary(redisClient *cache.RedisClient, settings []config.CanaryConfig) *Canary {
+	c := &Canary{
+		redisClient: redisClient,
+		runtime:     make(map[string]*compiledCanaryRule),
+	}
+	c.SetStatic(settings)
+	return c
+}
+
+// SetStatic 替换配置文件中的规则（配置文件重新加载时调用），Redis 中的运行时规则不受影响
+func (c *Canary) SetStatic(settings []config.CanaryConfig) {
+	static := make(map[string]*compiledCanaryRule)
+	for _, setting := range settings {
+		compiled, err := compileCanaryRule(canaryRuleFromConfig(setting))
+		if err != nil {
+			log.Printf("忽略无效的灰度规则配置: %v", err)
+			continue
+		}
+		static[compiled.rule.Service] = compiled
+	}
+
+	c.mu.Lock()
+	c.static = static
+	c.mu.Unlock()
+}
+
+// 配置文件中的灰度规则
+func canaryRuleFromConfig(setting config.CanaryConfig) CanaryRule {
+	return CanaryRule{
+		Service: setting.Service,
+		Version: setting.Version,
+		Percent: setting.Percent,
+		Headers: setting.Headers,
+		Cookies: setting.Cookies,
+		UserIDs: setting.UserIDs,
+		Source:  canarySourceConfig,
+	}
+}
+
+// Start 加载 Redis 中的规则，并订阅变更通知
+func (c *Canary) Start() {
+	if c.redisClient == nil {
+		return
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	c.cancel = cancel
+
+	if err := c.Reload(ctx); err != nil {
+		log.Printf("加载灰度规则失败: %v", err)
+	}
+
+	c.wg.Add(1)
+	go func() {
+		defer c.wg.Done()
+		c.watch(ctx)
+	}()
+}
+
+// Stop 停止同步
+func (c *Canary) Stop() {
+	if c.cancel != nil {
+		c.cancel()
+	}
+	c.wg.Wait()
+}
+
+// Reload 从 Redis 全量加载运行时规则
+func (c *Canary) Reload(ctx context.Context) error {
+	values, err := c.redisClient.Client.HGetAll(ctx, canaryRulesKey).Result()
+	if err != nil {
+		return fmt.Errorf("读取灰度规则失败: %v", err)
+	}
+
+	runtime := make(map[string]*compiledCanaryRule, len(values))
+	for service, value := range values {
+		var rule CanaryRule
+		if err := json.Unmarshal([]byte(value), &rule); err != nil {
+			log.Printf("忽略无法解析的灰度规则 %s: %v", service, err)
+			continue
+		}
+		compiled, err := compileCanaryRule(rule)
+		if err != nil {
+			log.Printf("忽略无效的灰度规则 %s: %v", service, err)
+			continue
+		}
+		runtime[compiled.rule.Service] = compiled
+	}
+
+	c.mu.Lock()
+	c.runtime = runtime
+	c.mu.Unlock()
+	return nil
+}
+
+// Set 设置服务的运行时规则（覆盖配置文件中的规则），保存到 Redis 并通知其他网关实例
+func (c *Canary) Set(ctx context.Context, rule CanaryRule) (*CanaryRule, error) {
+	if c.redisClient == nil {
+		return nil, fmt.Errorf("redis 未初始化，无法保存灰度规则")
+	}
+
+	rule.Source = canarySourceRuntime
+	rule.UpdatedAt = time.Now()
+	compiled, err := compileCanaryRule(rule)
+	if err != nil {
+		return nil, err
+	}
+	data, err := json.Marshal(compiled.rule)
+	if err != nil {
+		return nil, fmt.Errorf("序列化灰度规则失败: %v", err)
+	}
+	if err := c.redisClient.Client.HSet(ctx, canaryRulesKey, compiled.rule.Service, data).Err(); err != nil {
+		return nil, fmt.Errorf("保存灰度规则失败: %v", err)
+	}
+
+	c.mu.Lock()
+	c.runtime[compiled.rule.Service] = compiled
+	c.mu.Unlock()
+	c.publish(ctx)
+	return &compiled.rule, nil
+}
+
+// Remove 删除服务的运行时规则，之后恢复使用配置文件中的规则（如有）
+func (c *Canary) Remove(ctx context.Context, service string) error {
+	if c.redisClient == nil {
+		return fmt.Errorf("redis 未初始化，无法删除灰度规则")
+	}
+
+	deleted, err := c.redisClient.Client.HDel(ctx, canaryRulesKey, service).Result()
+	if err != nil {
+		return fmt.Errorf("删除灰度规则失败: %v", err)
+	}
+	if deleted == 0 {
+		return fmt.Errorf("服务 %s 没有运行时灰度规则", service)
+	}
+
+	c.mu.Lock()
+	delete(c.runtime, service)
+	c.mu.Unlock()
+	c.publish(ctx)
+	return nil
+}
+
+// List 返回当前生效的规则，按服务名排序
+func (c *Canary) List() []CanaryRule {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	rules := make([]CanaryRule, 0, len(c.static)+len(c.runtime))
+	for service, compiled := range c.static {
+		if _, overridden := c.runtime[service]; !overridden {
+			rules = append(rules, compiled.rule)
+		}
+	}
+	for _, compiled := range c.runtime {
+		rules = append(rules, compiled.rule)
+	}
+	sort.Slice(rules, func(i, j int) bool { return rules[i].Service < rules[j].Service })
+	return rules
+}
+
+// Assign 确定请求使用的版本：命中灰度时返回灰度版本，否则返回空字符串（稳定版本）
+// 按比例分流时依次使用 JWT 用户、灰度 Cookie 作为分组标识，未登录且没有 Cookie 时下发 Cookie，保证后续请求不会切换版本
+func (c *Canary) Assign(w http.ResponseWriter, r *http.Request, service string) string {
+	compiled := c.rule(service)
+	if compiled == nil {
+		return ""
+	}
+	if compiled.matches(r) {
+		return compiled.rule.Version
+	}
+	if compiled.bucket <= 0 {
+		return ""
+	}
+
+	subject := authenticatedUser(r)
+	if subject == "" {
+		subject = canaryIdentity(w, r)
+	}
+	if canaryBucket(service, subject) < compiled.bucket {
+		return compiled.rule.Version
+	}
+	return ""
+}
+
+// Version 返回服务当前的灰度版本，没有规则时返回空字符串
+func (c *Canary) Version(service string) string {
+	if compiled := c.rule(service); compiled != nil {
+		return compiled.rule.Version
+	}
+	return ""
+}
+
+// 服务当前生效的规则
+func (c *Canary) rule(service string) *compiledCanaryRule {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if compiled, ok := c.runtime[service]; ok {
+		return compiled
+	}
+	return c.static[service]
+}
+
+// 订阅变更通知并定期全量加载
+func (c *Canary) watch(ctx context.Context) {
+	pubsub := c.redisClient.Client.Subscribe(ctx, canaryChannel)
+	defer pubsub.Close()
+	messages := pubsub.Channel()
+
+	ticker := time.NewTicker(canaryReloadPeriod)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-messages:
+		case <-ticker.C:
+		}
+		if err := c.Reload(ctx); err != nil && ctx.Err() == nil {
+			log.Printf("同步灰度规则失败: %v", err)
+		}
+	}
+}
+
+// 通知其他网关实例重新加载
+func (c *Canary) publish(ctx context.Context) {
+	if err := c.redisClient.Client.Publish(ctx, canaryChannel, time.Now().UnixNano()).Err(); err != nil {
+		log.Printf("发布灰度规则变更通知失败: %v", err)
+	}
+}
+
+// 请求是否命中请求头、Cookie 或用户规则
+func (compiled *compiledCanaryRule) matches(r *http.Request) bool {
+	for name, want := range compiled.rule.Headers {
+		if value := r.Header.Get(name); value != "" && (want == "*" || value == want) {
+			return true
+		}
+	}
+	if len(compiled.rule.Cookies) > 0 {
+		// 配置文件中的键会被 viper 转为小写，Cookie 名按不区分大小写匹配
+		for _, cookie := range r.Cookies() {
+			for name, want := range compiled.rule.Cookies {
+				if strings.EqualFold(cookie.Name, name) && cookie.Value != "" && (want == "*" || cookie.Value == want) {
+					return true
+				}
+			}
+		}
+	}
+	if len(compiled.users) > 0 {
+		if subject := authenticatedUser(r); subject != "" && compiled.users[subject] {
+			return true
+		}
+	}
+	return false
+}
+
+// 校验并预处理规则
+func compileCanaryRule(rule CanaryRule) (*compiledCanaryRule, error) {
+	rule.Service = strings.TrimSpace(rule.Service)
+	rule.Version = strings.TrimSpace(rule.Version)
+	if rule.Service == "" || rule.Version == "" {
+		return nil, fmt.Errorf("灰度规则缺少 service 或 version")
+	}
+	if rule.Percent < 0 || rule.Percent > 100 {
+		return nil, fmt.Errorf("灰度比例 %v 必须在 0~100 之间", rule.Percent)
+	}
+	for name := range rule.Headers {
+		if strings.TrimSpace(name) == "" {
+			return nil, fmt.Errorf("灰度规则的请求头名不能为空")
+		}
+	}
+	for name := range rule.Cookies {
+		if strings.TrimSpace(name) == "" {
+			return nil, fmt.Errorf("灰度规则的 Cookie 名不能为空")
+		}
+	}
+
+	compiled := &compiledCanaryRule{
+		rule:   rule,
+		users:  make(map[string]bool, len(rule.UserIDs)),
+		bucket: int(rule.Percent * canaryBuckets / 100),
+	}
+	for _, userID := range rule.UserIDs {
+		compiled.users[userID] = true
+	}
+	return compiled, nil
+}
+
+// 分组标识对应的桶号：同一服务下同一标识始终落在同一个桶，调大比例时已在灰度中的用户不会回到稳定版本
+func canaryBucket(service, identity string) int {
+	return int(crc32.ChecksumIEEE([]byte(service+":"+identity)) % canaryBuckets)
+}
+
+// 读取灰度 Cookie，没有时生成并下发
+func canaryIdentity(w http.ResponseWriter, r *http.Request) string {
+	if cookie, err := r.Cookie(canaryCookie); err == nil && cookie.Value != "" {
+		return cookie.Value
+	}
+	var id [16]byte
+	_, _ = rand.Read(id[:])
+	identity := hex.EncodeToString(id[:])
+	http.SetCookie(w, &http.Cookie{
+		Name:     canaryCookie,
+		Value:    identity,
+		Path:     "/",
+		MaxAge:   int(canaryCookieMaxAge.Seconds()),
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	})
+	// 同一请求后续再次分流时（如重试）使用同一标识
+	r.AddCookie(&http.Cookie{Name: canaryCookie, Value: identity})
+	return identity
+}
+
+// 按灰度分组筛选节点：version 非空时只选该版本的节点，否则排除灰度版本的节点；分组内没有节点时使用全部节点
+func (p *Proxy) canaryNodes(service, version string, nodes []*WeightedNode) []*WeightedNode {
+	canaryVersion := p.canary.Version(service)
+	if canaryVersion == "" {
+		return nodes
+	}
+	group := make([]*WeightedNode, 0, len(nodes))
+	for _, node := range nodes {
+		if (node.version == canaryVersion) == (version != "") {
+			group = append(group, node)
+		}
+	}
+	if len(group) == 0 {
+		return nodes
+	}
+	return group
+}
+
+// Canary 返回灰度发布管理
+func (p *Proxy) Canary() *Canary {
+	return p.canary
+}
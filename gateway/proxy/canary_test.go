@@ -0,0 +1,213 @@
+package proxy
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sky_ISService/config"
+	"sky_ISService/pkg/identity"
+	"sky_ISService/shared/cache"
+	"strings"
+	"testing"
+)
+
+func TestCanaryAssign(t *testing.T) {
+	canary := NewCanary(nil, []config.CanaryConfig{
+		{Service: "system", Version: "v2", Headers: map[string]string{"X-Canary": "*", "X-Env": "beta"}},
+		{Service: "auth", Version: "v2", Cookies: map[string]string{"beta": "1"}, UserIDs: []string{"42"}},
+		{Service: "security", Version: "v2", Percent: 100},
+		{Service: "message", Version: "v2", Percent: 0},
+	})
+	tests := []struct {
+		name    string
+		service string
+		header  http.Header
+		cookie  *http.Cookie
+		userID  string
+		want    string
+	}{
+		{name: "没有规则", service: "order", want: ""},
+		{name: "请求头存在即命中", service: "system", header: http.Header{"X-Canary": {"anything"}}, want: "v2"},
+		{name: "请求头值匹配", service: "system", header: http.Header{"X-Env": {"beta"}}, want: "v2"},
+		{name: "请求头值不匹配", service: "system", header: http.Header{"X-Env": {"prod"}}, want: ""},
+		{name: "Cookie 名不区分大小写", service: "auth", cookie: &http.Cookie{Name: "Beta", Value: "1"}, want: "v2"},
+		{name: "Cookie 值不匹配", service: "auth", cookie: &http.Cookie{Name: "beta", Value: "0"}, want: ""},
+		{name: "指定用户", service: "auth", userID: "42", want: "v2"},
+		{name: "其他用户", service: "auth", userID: "43", want: ""},
+		{name: "全部转发", service: "security", want: "v2"},
+		{name: "比例为 0", service: "message", userID: "42", want: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("GET", "/", nil)
+			for name, values := range tt.header {
+				r.Header[name] = values
+			}
+			if tt.cookie != nil {
+				r.AddCookie(tt.cookie)
+			}
+			if tt.userID != "" {
+				r = r.WithContext(identity.WithIdentity(r.Context(), &identity.Identity{UserID: tt.userID}))
+			}
+			if got := canary.Assign(httptest.NewRecorder(), r, tt.service); got != tt.want {
+				t.Fatalf("得到 %q，期望 %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCanaryBucketing(t *testing.T) {
+	canary := NewCanary(nil, []config.CanaryConfig{{Service: "system", Version: "v2", Percent: 20}})
+
+	// 已认证用户按用户 ID 分桶，结果稳定且比例接近配置
+	canaryUsers := 0
+	for i := 0; i < 5000; i++ {
+		r := httptest.NewRequest("GET", "/", nil)
+		r = r.WithContext(identity.WithIdentity(r.Context(), &identity.Identity{UserID: fmt.Sprint(i)}))
+		version := canary.Assign(httptest.NewRecorder(), r, "system")
+		if again := canary.Assign(httptest.NewRecorder(), r, "system"); again != version {
+			t.Fatalf("用户 %d 两次分到不同版本", i)
+		}
+		if version == "v2" {
+			canaryUsers++
+		}
+	}
+	if canaryUsers < 850 || canaryUsers > 1150 {
+		t.Fatalf("%d/5000 个用户分到灰度版本，期望约 20%%", canaryUsers)
+	}
+
+	// 调大比例时已在灰度中的用户保持不变
+	wider := NewCanary(nil, []config.CanaryConfig{{Service: "system", Version: "v2", Percent: 50}})
+	for i := 0; i < 1000; i++ {
+		r := httptest.NewRequest("GET", "/", nil)
+		r = r.WithContext(identity.WithIdentity(r.Context(), &identity.Identity{UserID: fmt.Sprint(i)}))
+		if canary.Assign(httptest.NewRecorder(), r, "system") == "v2" && wider.Assign(httptest.NewRecorder(), r, "system") != "v2" {
+			t.Fatalf("调大比例后用户 %d 回到了稳定版本", i)
+		}
+	}
+
+	// 未登录用户下发 Cookie，携带 Cookie 后结果不变
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest("GET", "/", nil)
+	version := canary.Assign(w, r, "system")
+	cookies := w.Result().Cookies()
+	if len(cookies) != 1 || cookies[0].Name != canaryCookie || !cookies[0].HttpOnly {
+		t.Fatalf("未下发灰度 Cookie: %v", cookies)
+	}
+	// 同一请求再次分流（重试）不再下发
+	w2 := httptest.NewRecorder()
+	if again := canary.Assign(w2, r, "system"); again != version || len(w2.Result().Cookies()) != 0 {
+		t.Fatal("同一请求再次分流时切换了版本或重复下发 Cookie")
+	}
+	next := httptest.NewRequest("GET", "/", nil)
+	next.AddCookie(cookies[0])
+	w3 := httptest.NewRecorder()
+	if again := canary.Assign(w3, next, "system"); again != version || len(w3.Result().Cookies()) != 0 {
+		t.Fatal("携带灰度 Cookie 的请求切换了版本或重复下发 Cookie")
+	}
+}
+
+func TestCompileCanaryRule(t *testing.T) {
+	tests := []struct {
+		name       string
+		rule       CanaryRule
+		wantBucket int
+		wantErr    bool
+	}{
+		{name: "按比例", rule: CanaryRule{Service: "system", Version: "v2", Percent: 12.34}, wantBucket: 1234},
+		{name: "去掉首尾空格", rule: CanaryRule{Service: " system ", Version: " v2 ", Percent: 100}, wantBucket: canaryBuckets},
+		{name: "缺少版本", rule: CanaryRule{Service: "system"}, wantErr: true},
+		{name: "缺少服务", rule: CanaryRule{Version: "v2"}, wantErr: true},
+		{name: "比例超出范围", rule: CanaryRule{Service: "system", Version: "v2", Percent: 101}, wantErr: true},
+		{name: "负比例", rule: CanaryRule{Service: "system", Version: "v2", Percent: -1}, wantErr: true},
+		{name: "空请求头名", rule: CanaryRule{Service: "system", Version: "v2", Headers: map[string]string{" ": "1"}}, wantErr: true},
+		{name: "空 Cookie 名", rule: CanaryRule{Service: "system", Version: "v2", Cookies: map[string]string{"": "1"}}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			compiled, err := compileCanaryRule(tt.rule)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("期望校验失败")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("校验失败: %v", err)
+			}
+			if compiled.bucket != tt.wantBucket || compiled.rule.Service != "system" || compiled.rule.Version != "v2" {
+				t.Fatalf("得到 %+v，桶号 %d，期望 %d", compiled.rule, compiled.bucket, tt.wantBucket)
+			}
+		})
+	}
+}
+
+func TestCanaryRuntimeOverride(t *testing.T) {
+	redisClient, err := cache.NewMemoryRedis()
+	if err != nil {
+		t.Fatalf("启动进程内 Redis 失败: %v", err)
+	}
+	t.Cleanup(func() { _ = redisClient.Close() })
+	canary := NewCanary(redisClient, []config.CanaryConfig{{Service: "system", Version: "v2"}})
+	ctx := context.Background()
+
+	if _, err := canary.Set(ctx, CanaryRule{Service: "system", Version: "v3"}); err != nil {
+		t.Fatalf("设置灰度规则失败: %v", err)
+	}
+	if version := canary.Version("system"); version != "v3" {
+		t.Fatalf("运行时规则未覆盖配置文件规则: %s", version)
+	}
+
+	// 其他网关实例从 Redis 加载
+	other := NewCanary(redisClient, nil)
+	if err := other.Reload(ctx); err != nil {
+		t.Fatalf("加载灰度规则失败: %v", err)
+	}
+	if version := other.Version("system"); version != "v3" {
+		t.Fatalf("其他实例加载到的版本为 %s", version)
+	}
+
+	// 删除运行时规则后恢复使用配置文件规则
+	if err := canary.Remove(ctx, "system"); err != nil {
+		t.Fatalf("删除灰度规则失败: %v", err)
+	}
+	if version := canary.Version("system"); version != "v2" {
+		t.Fatalf("删除运行时规则后版本为 %s，期望 v2", version)
+	}
+	if err := canary.Remove(ctx, "system"); err == nil {
+		t.Fatal("重复删除应当返回错误")
+	}
+}
+
+func TestCanaryNodes(t *testing.T) {
+	p := &Proxy{canary: NewCanary(nil, []config.CanaryConfig{{Service: "system", Version: "v2"}})}
+	nodes := []*WeightedNode{
+		{addr: "10.0.0.1:8080", weight: 1, version: "v1"},
+		{addr: "10.0.0.2:8080", weight: 1, version: "v2"},
+		{addr: "10.0.0.3:8080", weight: 1},
+	}
+	tests := []struct {
+		name    string
+		service string
+		version string
+		nodes   []*WeightedNode
+		want    string
+	}{
+		{name: "灰度分组", service: "system", version: "v2", nodes: nodes, want: "10.0.0.2:8080"},
+		{name: "稳定分组排除灰度节点", service: "system", nodes: nodes, want: "10.0.0.1:8080,10.0.0.3:8080"},
+		{name: "灰度分组没有节点时使用全部节点", service: "system", version: "v2", nodes: nodes[:1], want: "10.0.0.1:8080"},
+		{name: "没有规则的服务", service: "auth", nodes: nodes, want: "10.0.0.1:8080,10.0.0.2:8080,10.0.0.3:8080"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, node := range p.canaryNodes(tt.service, tt.version, tt.nodes) {
+				got = append(got, node.addr)
+			}
+			if fmt.Sprint(got) != fmt.Sprint(strings.Split(tt.want, ",")) {
+				t.Fatalf("得到 %v，期望 %s", got, tt.want)
+			}
+		})
+	}
+}
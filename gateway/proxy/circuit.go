@@ -0,0 +1,66 @@
+package proxy
+
+import (
+	"sky_ISService/config"
+	"sky_ISService/pkg/circuit"
+	"strings"
+)
+
+// 节点熔断器名称中服务名与节点地址的分隔符
+const nodeBreakerSeparator = "@"
+
+// CircuitStatus 返回所有上游服务（及节点）熔断器的状态
+func (p *Proxy) CircuitStatus() []circuit.Snapshot {
+	return p.breakers.Snapshots()
+}
+
+// 熔断器配置：服务级配置覆盖网关默认配置，节点熔断器沿用所属服务的配置
+func breakerSettings(name string) config.CircuitBreakerConfig {
+	service := strings.SplitN(name, nodeBreakerSeparator, 2)[0]
+	settings := config.GetConfig().Gateway.CircuitBreaker
+	override := config.GetConfig().Gateway.Services[service].CircuitBreaker
+	if override.FailureRatio > 0 {
+		settings.FailureRatio = override.FailureRatio
+	}
+	if override.MinRequests > 0 {
+		settings.MinRequests = override.MinRequests
+	}
+	if override.Window > 0 {
+		settings.Window = override.Window
+	}
+	if override.OpenTimeout > 0 {
+		settings.OpenTimeout = override.OpenTimeout
+	}
+	if override.HalfOpenRequests > 0 {
+		settings.HalfOpenRequests = override.HalfOpenRequests
+	}
+	settings.PerNode = settings.PerNode || override.PerNode
+	return settings
+}
+
+// 节点熔断器名称
+func nodeBreakerName(service string, node *WeightedNode) string {
+	return service + nodeBreakerSeparator + node.addr
+}
+
+// 节点熔断器当前是否会放行请求（未开启按节点熔断时始终为 true），选择节点时跳过会被拒绝的节点：
+// 打开状态的节点，以及半开状态下试探名额已被占满的节点
+func (p *Proxy) nodeReady(service string, node *WeightedNode) bool {
+	if !breakerSettings(service).PerNode {
+		return true
+	}
+	return p.breakers.Get(nodeBreakerName(service, node)).Ready()
+}
+
+// 申请服务熔断器的放行，每个客户端请求只申请一次（重试不再占用半开状态的试探名额）
+func (p *Proxy) allowService(service string) (func(circuit.Outcome), error) {
+	return p.breakers.Get(service).Allow()
+}
+
+// 申请节点熔断器的放行，每次尝试申请一次；未开启按节点熔断时直接放行
+func (p *Proxy) allowNode(service string, node *WeightedNode) (func(circuit.Outcome), error) {
+	if !breakerSettings(service).PerNode {
+		return func(circuit.Outcome) {}, nil
+	}
+	return p.breakers.Get(nodeBreakerName(service, node)).Allow()
+}
@@ -0,0 +1,208 @@
+package proxy
+
+import (
+	"net/http"
+	"net/url"
+	"sky_ISService/config"
+	"strconv"
+	"strings"
+)
+
+// 未配置时的默认跨域设置，与原先的行为保持一致（不允许携带凭证）
+var (
+	defaultCORSOrigins = []string{"*"}
+	defaultCORSMethods = []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"}
+	defaultCORSHeaders = []string{"Content-Type", "Authorization"}
+)
+
+// 来源匹配规则
+type originPattern struct {
+	scheme string // 为空表示任意协议
+	host   string // 精确匹配的主机（含端口）
+	suffix string // 通配子域名时的后缀，如 .example.com
+}
+
+// CORSPolicy 编译后的跨域策略
+type CORSPolicy struct {
+	allowAll      bool
+	origins       []originPattern
+	methods       map[string]bool
+	allowMethods  string
+	allowHeaders  string
+	anyHeader     bool
+	exposeHeaders string
+	credentials   bool
+	maxAge        string
+}
+
+// NewCORSPolicy 编译跨域策略，未配置的项使用默认值
+func NewCORSPolicy(settings config.CORSConfig) *CORSPolicy {
+	if len(settings.AllowOrigins) == 0 {
+		settings.AllowOrigins = defaultCORSOrigins
+	}
+	if len(settings.AllowMethods) == 0 {
+		settings.AllowMethods = defaultCORSMethods
+	}
+	if len(settings.AllowHeaders) == 0 {
+		settings.AllowHeaders = defaultCORSHeaders
+	}
+
+	policy := &CORSPolicy{
+		methods:       make(map[string]bool, len(settings.AllowMethods)),
+		exposeHeaders: strings.Join(settings.ExposeHeaders, ", "),
+		credentials:   settings.Credentials(),
+	}
+	for _, origin := range settings.AllowOrigins {
+		if origin == "*" {
+			policy.allowAll = true
+			continue
+		}
+		policy.origins = append(policy.origins, parseOriginPattern(origin))
+	}
+	methods := make([]string, 0, len(settings.AllowMethods))
+	for _, method := range settings.AllowMethods {
+		method = strings.ToUpper(strings.TrimSpace(method))
+		policy.methods[method] = true
+		methods = append(methods, method)
+	}
+	policy.allowMethods = strings.Join(methods, ", ")
+	// 任意来源与携带凭证不能同时开启（配置校验已拒绝），这里按不允许携带凭证处理
+	if policy.allowAll {
+		policy.credentials = false
+	}
+	for _, header := range settings.AllowHeaders {
+		if header == "*" {
+			policy.anyHeader = true
+		}
+	}
+	policy.allowHeaders = strings.Join(settings.AllowHeaders, ", ")
+	if settings.MaxAge > 0 {
+		policy.maxAge = strconv.Itoa(int(settings.MaxAge.Seconds()))
+	}
+	return policy
+}
+
+// Handle 写入跨域响应头；预检请求在这里直接响应并返回 true
+func (c *CORSPolicy) Handle(w http.ResponseWriter, r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	preflight := r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != ""
+
+	// 响应随 Origin 变化时必须声明 Vary，避免共享缓存把一个来源的响应返回给另一个来源
+	if !c.allowAll || c.credentials {
+		w.Header().Add("Vary", "Origin")
+	}
+	if preflight {
+		w.Header().Add("Vary", "Access-Control-Request-Method")
+		w.Header().Add("Vary", "Access-Control-Request-Headers")
+	}
+	if origin == "" {
+		return false
+	}
+
+	if !c.originAllowed(origin) {
+		if preflight {
+			w.WriteHeader(http.StatusForbidden)
+			return true
+		}
+		return false
+	}
+
+	// 携带凭证时浏览器不接受 *，回显具体来源
+	if c.allowAll && !c.credentials {
+		w.Header().Set("Access-Control-Allow-Origin", "*")
+	} else {
+		w.Header().Set("Access-Control-Allow-Origin", origin)
+	}
+	if c.credentials {
+		w.Header().Set("Access-Control-Allow-Credentials", "true")
+	}
+
+	if !preflight {
+		if c.exposeHeaders != "" {
+			w.Header().Set("Access-Control-Expose-Headers", c.exposeHeaders)
+		}
+		return false
+	}
+
+	if !c.methods[strings.ToUpper(r.Header.Get("Access-Control-Request-Method"))] {
+		w.WriteHeader(http.StatusForbidden)
+		return true
+	}
+	w.Header().Set("Access-Control-Allow-Methods", c.allowMethods)
+	if c.anyHeader {
+		if requested := r.Header.Get("Access-Control-Request-Headers"); requested != "" {
+			w.Header().Set("Access-Control-Allow-Headers", requested)
+		}
+	} else {
+		w.Header().Set("Access-Control-Allow-Headers", c.allowHeaders)
+	}
+	if c.maxAge != "" {
+		w.Header().Set("Access-Control-Max-Age", c.maxAge)
+	}
+	w.WriteHeader(http.StatusNoContent)
+	return true
+}
+
+// 判断来源是否在白名单中
+func (c *CORSPolicy) originAllowed(origin string) bool {
+	if c.allowAll {
+		return true
+	}
+	parsed, err := url.Parse(origin)
+	if err != nil || parsed.Host == "" {
+		return false
+	}
+	host := strings.ToLower(parsed.Host)
+	for _, pattern := range c.origins {
+		if pattern.scheme != "" && pattern.scheme != parsed.Scheme {
+			continue
+		}
+		if pattern.suffix != "" {
+			if strings.HasSuffix(host, pattern.suffix) && len(host) > len(pattern.suffix) {
+				return true
+			}
+			continue
+		}
+		if pattern.host == host {
+			return true
+		}
+	}
+	return false
+}
+
+// 解析来源规则：https://admin.example.com、https://*.example.com、*.example.com
+func parseOriginPattern(origin string) originPattern {
+	origin = strings.ToLower(strings.TrimSuffix(strings.TrimSpace(origin), "/"))
+	var pattern originPattern
+	if scheme, rest, ok := strings.Cut(origin, "://"); ok {
+		pattern.scheme = scheme
+		origin = rest
+	}
+	if strings.HasPrefix(origin, "*.") {
+		pattern.suffix = origin[1:]
+	} else {
+		pattern.host = origin
+	}
+	return pattern
+}
+
+// HandleCORS 按请求命中路由的跨域策略写入响应头，预检请求直接响应并返回 true
+// 需要在 JWT 等会拦截请求的中间件之前调用，保证预检请求和被拦截的响应都带有跨域头。
+// 预检请求按 Access-Control-Request-Method 声明的方法匹配路由，限定了方法的路由也能匹配到自己的预检请求
+func (p *Proxy) HandleCORS(w http.ResponseWriter, r *http.Request) bool {
+	match := r
+	if method := r.Header.Get("Access-Control-Request-Method"); r.Method == http.MethodOptions && method != "" {
+		match = r.Clone(r.Context())
+		match.Method = strings.ToUpper(method)
+	}
+	return p.MatchRoute(match).CORS().Handle(w, r)
+}
+
+// 去掉上游返回的跨域响应头，统一由网关输出
+func stripUpstreamCORSHeaders(header http.Header) {
+	for name := range header {
+		if strings.HasPrefix(name, "Access-Control-") {
+			header.Del(name)
+		}
+	}
+}
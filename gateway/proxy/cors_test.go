@@ -0,0 +1,195 @@
+package proxy
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sky_ISService/config"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCORSOriginAllowed(t *testing.T) {
+	policy := NewCORSPolicy(config.CORSConfig{
+		AllowOrigins: []string{"https://admin.example.com/", "https://*.example.org", "*.example.net", "http://localhost:3000"},
+	})
+	tests := []struct {
+		origin string
+		want   bool
+	}{
+		{origin: "https://admin.example.com", want: true},
+		{origin: "HTTPS://ADMIN.EXAMPLE.COM", want: true},
+		{origin: "http://admin.example.com", want: false},
+		{origin: "https://evil-admin.example.com", want: false},
+		{origin: "https://app.example.org", want: true},
+		{origin: "https://a.b.example.org", want: true},
+		{origin: "https://example.org", want: false},
+		{origin: "https://evilexample.org", want: false},
+		{origin: "http://app.example.org", want: false},
+		{origin: "http://app.example.net", want: true},
+		{origin: "https://app.example.net", want: true},
+		{origin: "http://localhost:3000", want: true},
+		{origin: "http://localhost:3001", want: false},
+		{origin: "null", want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.origin, func(t *testing.T) {
+			if got := policy.originAllowed(tt.origin); got != tt.want {
+				t.Fatalf("得到 %v，期望 %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCORSHandle(t *testing.T) {
+	allow := true
+	tests := []struct {
+		name            string
+		settings        config.CORSConfig
+		method          string
+		origin          string
+		requestMethod   string // 预检请求声明的方法
+		requestHeaders  string
+		wantHandled     bool
+		wantStatus      int
+		wantOrigin      string
+		wantCredentials string
+		wantVary        string
+		wantHeaders     map[string]string
+	}{
+		{
+			name:       "默认允许任意来源且不携带凭证",
+			method:     "GET",
+			origin:     "https://app.example.com",
+			wantStatus: http.StatusOK,
+			wantOrigin: "*",
+		},
+		{
+			name:     "任意来源时忽略携带凭证",
+			settings: config.CORSConfig{AllowCredentials: &allow},
+			method:   "GET", origin: "https://app.example.com",
+			wantStatus: http.StatusOK,
+			wantOrigin: "*",
+		},
+		{
+			name:     "白名单来源携带凭证时回显来源",
+			settings: config.CORSConfig{AllowOrigins: []string{"https://app.example.com"}, AllowCredentials: &allow, ExposeHeaders: []string{"X-Request-ID"}},
+			method:   "GET", origin: "https://app.example.com",
+			wantStatus:      http.StatusOK,
+			wantOrigin:      "https://app.example.com",
+			wantCredentials: "true",
+			wantVary:        "Origin",
+			wantHeaders:     map[string]string{"Access-Control-Expose-Headers": "X-Request-ID"},
+		},
+		{
+			name:     "不在白名单的来源不写跨域头",
+			settings: config.CORSConfig{AllowOrigins: []string{"https://app.example.com"}},
+			method:   "GET", origin: "https://evil.example.com",
+			wantStatus: http.StatusOK,
+			wantVary:   "Origin",
+		},
+		{
+			name:       "没有 Origin 时仍声明 Vary",
+			settings:   config.CORSConfig{AllowOrigins: []string{"https://app.example.com"}},
+			method:     "GET",
+			wantStatus: http.StatusOK,
+			wantVary:   "Origin",
+		},
+		{
+			name:     "预检请求",
+			settings: config.CORSConfig{AllowOrigins: []string{"https://app.example.com"}, AllowMethods: []string{"get", "post"}, MaxAge: 10 * time.Minute},
+			method:   "OPTIONS", origin: "https://app.example.com", requestMethod: "post",
+			wantHandled: true,
+			wantStatus:  http.StatusNoContent,
+			wantOrigin:  "https://app.example.com",
+			wantVary:    "Origin, Access-Control-Request-Method, Access-Control-Request-Headers",
+			wantHeaders: map[string]string{
+				"Access-Control-Allow-Methods": "GET, POST",
+				"Access-Control-Allow-Headers": "Content-Type, Authorization",
+				"Access-Control-Max-Age":       "600",
+			},
+		},
+		{
+			name:     "预检请求的方法不被允许",
+			settings: config.CORSConfig{AllowMethods: []string{"GET"}},
+			method:   "OPTIONS", origin: "https://app.example.com", requestMethod: "DELETE",
+			wantHandled: true,
+			wantStatus:  http.StatusForbidden,
+			wantOrigin:  "*",
+			wantVary:    "Access-Control-Request-Method, Access-Control-Request-Headers",
+		},
+		{
+			name:     "预检请求的来源不被允许",
+			settings: config.CORSConfig{AllowOrigins: []string{"https://app.example.com"}},
+			method:   "OPTIONS", origin: "https://evil.example.com", requestMethod: "GET",
+			wantHandled: true,
+			wantStatus:  http.StatusForbidden,
+			wantVary:    "Origin, Access-Control-Request-Method, Access-Control-Request-Headers",
+		},
+		{
+			name:     "允许任意请求头时回显预检声明的请求头",
+			settings: config.CORSConfig{AllowHeaders: []string{"*"}},
+			method:   "OPTIONS", origin: "https://app.example.com", requestMethod: "GET", requestHeaders: "X-Custom, Content-Type",
+			wantHandled: true,
+			wantStatus:  http.StatusNoContent,
+			wantOrigin:  "*",
+			wantVary:    "Access-Control-Request-Method, Access-Control-Request-Headers",
+			wantHeaders: map[string]string{"Access-Control-Allow-Headers": "X-Custom, Content-Type"},
+		},
+		{
+			name:       "没有声明方法的 OPTIONS 不是预检请求",
+			method:     "OPTIONS",
+			origin:     "https://app.example.com",
+			wantStatus: http.StatusOK,
+			wantOrigin: "*",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(tt.method, "/system/api/v1/users", nil)
+			if tt.origin != "" {
+				r.Header.Set("Origin", tt.origin)
+			}
+			if tt.requestMethod != "" {
+				r.Header.Set("Access-Control-Request-Method", tt.requestMethod)
+			}
+			if tt.requestHeaders != "" {
+				r.Header.Set("Access-Control-Request-Headers", tt.requestHeaders)
+			}
+			w := httptest.NewRecorder()
+
+			if handled := NewCORSPolicy(tt.settings).Handle(w, r); handled != tt.wantHandled {
+				t.Fatalf("handled 为 %v，期望 %v", handled, tt.wantHandled)
+			}
+			if w.Code != tt.wantStatus {
+				t.Fatalf("状态码 %d，期望 %d", w.Code, tt.wantStatus)
+			}
+			header := w.Header()
+			if got := header.Get("Access-Control-Allow-Origin"); got != tt.wantOrigin {
+				t.Fatalf("Access-Control-Allow-Origin 为 %q，期望 %q", got, tt.wantOrigin)
+			}
+			if got := header.Get("Access-Control-Allow-Credentials"); got != tt.wantCredentials {
+				t.Fatalf("Access-Control-Allow-Credentials 为 %q，期望 %q", got, tt.wantCredentials)
+			}
+			if got := strings.Join(header.Values("Vary"), ", "); got != tt.wantVary {
+				t.Fatalf("Vary 为 %q，期望 %q", got, tt.wantVary)
+			}
+			for name, want := range tt.wantHeaders {
+				if got := header.Get(name); got != want {
+					t.Fatalf("%s 为 %q，期望 %q", name, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestStripUpstreamCORSHeaders(t *testing.T) {
+	header := http.Header{}
+	header.Set("Access-Control-Allow-Origin", "*")
+	header.Set("Access-Control-Allow-Credentials", "true")
+	header.Set("Content-Type", "application/json")
+	stripUpstreamCORSHeaders(header)
+	if len(header) != 1 || header.Get("Content-Type") == "" {
+		t.Fatalf("上游跨域头未清除: %v", header)
+	}
+}
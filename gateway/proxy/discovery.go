@@ -0,0 +1,402 @@
+package proxy
+
+import (
+	"context"
+	"fmt"
+	"github.com/hashicorp/consul/api"
+	"log"
+	consul "sky_ISService/shared/registerservice"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultNodeWeight   = 10              // 未声明权重的实例默认权重
+	defaultWaitTime     = 5 * time.Minute // 阻塞查询默认等待时间
+	discoveryRetryDelay = 3 * time.Second // 查询失败后的重试间隔
+	weightTagPrefix     = "weight="       // 通过 tag 声明权重，如 weight=20
+	versionTagPrefix    = "version="      // 通过 tag 声明部署版本，如 version=v2
+)
+
+// ServiceInstance 服务目录中的一个服务实例
+type ServiceInstance struct {
+	ID      string            // 实例 ID
+	Service string            // 服务名
+	Address string            // 实例地址
+	Port    int               // 实例端口
+	Tags    []string          // 标签
+	Meta    map[string]string // 元数据
+}
+
+// Catalog 服务目录，支持阻塞查询：当 waitIndex 未变化时挂起，直到目录变更或超时
+type Catalog interface {
+	// Services 返回当前所有服务名以及新的索引
+	Services(ctx context.Context, waitIndex uint64) ([]string, uint64, error)
+	// Instances 返回指定服务的健康实例以及新的索引
+	Instances(ctx context.Context, service string, waitIndex uint64) ([]*ServiceInstance, uint64, error)
+}
+
+// ConsulCatalog 基于 Consul 的服务目录
+type ConsulCatalog struct {
+	client   *api.Client
+	waitTime time.Duration
+}
+
+// NewConsulCatalog 创建 Consul 服务目录
+func NewConsulCatalog(client *api.Client, waitTime time.Duration) *ConsulCatalog {
+	if waitTime <= 0 {
+		waitTime = defaultWaitTime
+	}
+	return &ConsulCatalog{client: client, waitTime: waitTime}
+}
+
+// Services 阻塞查询 Consul 中的服务列表
+func (c *ConsulCatalog) Services(ctx context.Context, waitIndex uint64) ([]string, uint64, error) {
+	opts := (&api.QueryOptions{WaitIndex: waitIndex, WaitTime: c.waitTime}).WithContext(ctx)
+	services, meta, err := c.client.Catalog().Services(opts)
+	if err != nil {
+		return nil, waitIndex, fmt.Errorf("查询 Consul 服务列表失败: %v", err)
+	}
+
+	names := make([]string, 0, len(services))
+	for name := range services {
+		names = append(names, name)
+	}
+	return names, meta.LastIndex, nil
+}
+
+// Instances 阻塞查询 Consul 中指定服务的健康实例
+func (c *ConsulCatalog) Instances(ctx context.Context, service string, waitIndex uint64) ([]*ServiceInstance, uint64, error) {
+	opts := (&api.QueryOptions{WaitIndex: waitIndex, WaitTime: c.waitTime}).WithContext(ctx)
+	entries, meta, err := c.client.Health().Service(service, "", true, opts)
+	if err != nil {
+		return nil, waitIndex, fmt.Errorf("查询 Consul 服务 %s 失败: %v", service, err)
+	}
+
+	instances := make([]*ServiceInstance, 0, len(entries))
+	for _, entry := range entries {
+		address := entry.Service.Address
+		if address == "" {
+			address = entry.Node.Address // 服务未声明地址时使用节点地址
+		}
+		instances = append(instances, &ServiceInstance{
+			ID:      entry.Service.ID,
+			Service: entry.Service.Service,
+			Address: address,
+			Port:    entry.Service.Port,
+			Tags:    entry.Service.Tags,
+			Meta:    entry.Service.Meta,
+		})
+	}
+	return instances, meta.LastIndex, nil
+}
+
+// MemoryCatalog 内存服务目录，用于本地开发和测试替代 Consul
+type MemoryCatalog struct {
+	mu        sync.Mutex
+	index     uint64
+	instances map[string]map[string]*ServiceInstance // 服务名 -> 实例 ID -> 实例
+	changed   chan struct{}                          // 每次变更时关闭并替换，用于唤醒阻塞查询
+}
+
+// NewMemoryCatalog 创建内存服务目录
+func NewMemoryCatalog() *MemoryCatalog {
+	return &MemoryCatalog{
+		index:     1,
+		instances: make(map[string]map[string]*ServiceInstance),
+		changed:   make(chan struct{}),
+	}
+}
+
+// Register 注册（或更新）一个服务实例
+func (c *MemoryCatalog) Register(instance *ServiceInstance) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.instances[instance.Service] == nil {
+		c.instances[instance.Service] = make(map[string]*ServiceInstance)
+	}
+	c.instances[instance.Service][instance.ID] = instance
+	c.notifyLocked()
+}
+
+// Deregister 注销一个服务实例，服务下没有实例时服务也一并移除
+func (c *MemoryCatalog) Deregister(service, id string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.instances[service], id)
+	if len(c.instances[service]) == 0 {
+		delete(c.instances, service)
+	}
+	c.notifyLocked()
+}
+
+// Services 返回所有服务名，waitIndex 未变化时阻塞
+func (c *MemoryCatalog) Services(ctx context.Context, waitIndex uint64) ([]string, uint64, error) {
+	if err := c.wait(ctx, waitIndex); err != nil {
+		return nil, waitIndex, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	names := make([]string, 0, len(c.instances))
+	for name := range c.instances {
+		names = append(names, name)
+	}
+	return names, c.index, nil
+}
+
+// Instances 返回指定服务的实例，waitIndex 未变化时阻塞
+func (c *MemoryCatalog) Instances(ctx context.Context, service string, waitIndex uint64) ([]*ServiceInstance, uint64, error) {
+	if err := c.wait(ctx, waitIndex); err != nil {
+		return nil, waitIndex, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	instances := make([]*ServiceInstance, 0, len(c.instances[service]))
+	for _, instance := range c.instances[service] {
+		copied := *instance
+		instances = append(instances, &copied)
+	}
+	return instances, c.index, nil
+}
+
+// 唤醒所有阻塞中的查询（调用方需持有锁）
+func (c *MemoryCatalog) notifyLocked() {
+	c.index++
+	close(c.changed)
+	c.changed = make(chan struct{})
+}
+
+// 等待索引超过 waitIndex
+func (c *MemoryCatalog) wait(ctx context.Context, waitIndex uint64) error {
+	for {
+		c.mu.Lock()
+		if c.index > waitIndex {
+			c.mu.Unlock()
+			return nil
+		}
+		changed := c.changed
+		c.mu.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-changed:
+		}
+	}
+}
+
+// Discovery 监听服务目录，实时重建 Proxy 的服务节点
+type Discovery struct {
+	proxy   *Proxy
+	catalog Catalog
+	exclude map[string]bool
+
+	mu       sync.Mutex
+	watchers map[string]context.CancelFunc // 服务名 -> 停止该服务监听
+	cancel   context.CancelFunc
+	wg       sync.WaitGroup
+}
+
+// NewDiscovery 创建服务发现，exclude 中的服务不会被代理
+func NewDiscovery(p *Proxy, catalog Catalog, exclude []string) *Discovery {
+	if len(exclude) == 0 {
+		exclude = []string{"gateway", "consul"}
+	}
+	excluded := make(map[string]bool, len(exclude))
+	for _, name := range exclude {
+		excluded[name] = true
+	}
+	return &Discovery{
+		proxy:    p,
+		catalog:  catalog,
+		exclude:  excluded,
+		watchers: make(map[string]context.CancelFunc),
+	}
+}
+
+// Start 开始监听服务目录
+func (d *Discovery) Start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	d.cancel = cancel
+
+	d.wg.Add(1)
+	go func() {
+		defer d.wg.Done()
+		d.watchServices(ctx)
+	}()
+}
+
+// Stop 停止监听并等待所有监听协程退出
+func (d *Discovery) Stop() {
+	if d.cancel != nil {
+		d.cancel()
+	}
+	d.wg.Wait()
+}
+
+// 监听服务列表的增减
+func (d *Discovery) watchServices(ctx context.Context) {
+	var index uint64
+	for {
+		names, newIndex, err := d.catalog.Services(ctx, index)
+		if ctx.Err() != nil {
+			return
+		}
+		if err != nil {
+			log.Printf("服务发现: %v", err)
+			if !sleepContext(ctx, discoveryRetryDelay) {
+				return
+			}
+			continue
+		}
+
+		index = nextIndex(index, newIndex)
+		d.syncWatchers(ctx, names)
+	}
+}
+
+// 为新出现的服务启动监听，停止已消失服务的监听
+func (d *Discovery) syncWatchers(ctx context.Context, names []string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	current := make(map[string]bool, len(names))
+	for _, name := range names {
+		if d.exclude[name] {
+			continue
+		}
+		current[name] = true
+		if _, ok := d.watchers[name]; ok {
+			continue
+		}
+
+		watchCtx, cancel := context.WithCancel(ctx)
+		d.watchers[name] = cancel
+		d.wg.Add(1)
+		go func(service string) {
+			defer d.wg.Done()
+			d.watchInstances(watchCtx, service)
+		}(name)
+	}
+
+	for name, cancel := range d.watchers {
+		if !current[name] {
+			cancel()
+			delete(d.watchers, name)
+			d.proxy.SetServiceNodes(name, nil)
+		}
+	}
+}
+
+// 监听单个服务的实例变化
+func (d *Discovery) watchInstances(ctx context.Context, service string) {
+	var index uint64
+	for {
+		instances, newIndex, err := d.catalog.Instances(ctx, service, index)
+		if ctx.Err() != nil {
+			return
+		}
+		if err != nil {
+			log.Printf("服务发现: %v", err)
+			if !sleepContext(ctx, discoveryRetryDelay) {
+				return
+			}
+			continue
+		}
+
+		// 索引未变化说明是阻塞查询超时，无需重建
+		if newIndex == index {
+			continue
+		}
+		index = nextIndex(index, newIndex)
+		if !d.applyNodes(ctx, service, buildNodes(instances)) {
+			return
+		}
+	}
+}
+
+// 更新服务节点，返回 false 表示监听已停止。与 syncWatchers 持有同一把锁，
+// 服务被移除（监听已取消）后返回的查询结果会被丢弃，不会把已下线的服务重新加回来
+func (d *Discovery) applyNodes(ctx context.Context, service string, nodes []*WeightedNode) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if ctx.Err() != nil {
+		return false
+	}
+	d.proxy.SetServiceNodes(service, nodes)
+	return true
+}
+
+// 将服务实例转换为加权节点，权重为 0 的实例视为摘除流量
+func buildNodes(instances []*ServiceInstance) []*WeightedNode {
+	nodes := make([]*WeightedNode, 0, len(instances))
+	for _, instance := range instances {
+		weight := instanceWeight(instance)
+		if weight <= 0 {
+			continue
+		}
+		nodes = append(nodes, &WeightedNode{
+			addr:    fmt.Sprintf("%s:%d", instance.Address, instance.Port),
+			weight:  weight,
+			version: instanceVersion(instance),
+		})
+	}
+	// 保证节点顺序稳定，便于比较和排查
+	sort.Slice(nodes, func(i, j int) bool { return nodes[i].addr < nodes[j].addr })
+	return nodes
+}
+
+// 从元数据或标签中读取实例权重，优先使用元数据
+func instanceWeight(instance *ServiceInstance) int {
+	if value, ok := instance.Meta[consul.MetaWeight]; ok {
+		if weight, err := strconv.Atoi(value); err == nil {
+			return weight
+		}
+	}
+	for _, tag := range instance.Tags {
+		if strings.HasPrefix(tag, weightTagPrefix) {
+			if weight, err := strconv.Atoi(strings.TrimPrefix(tag, weightTagPrefix)); err == nil {
+				return weight
+			}
+		}
+	}
+	return defaultNodeWeight
+}
+
+// 从元数据或标签中读取实例的部署版本，优先使用元数据
+func instanceVersion(instance *ServiceInstance) string {
+	if value, ok := instance.Meta[consul.MetaVersion]; ok {
+		return value
+	}
+	for _, tag := range instance.Tags {
+		if strings.HasPrefix(tag, versionTagPrefix) {
+			return strings.TrimPrefix(tag, versionTagPrefix)
+		}
+	}
+	return ""
+}
+
+// Consul 索引回退时需要从 0 重新开始查询
+func nextIndex(old, latest uint64) uint64 {
+	if latest < old {
+		return 0
+	}
+	return latest
+}
+
+// 可被取消的休眠，返回 false 表示 ctx 已结束
+func sleepContext(ctx context.Context, d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
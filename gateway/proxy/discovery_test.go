@@ -0,0 +1,187 @@
+package proxy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	consul "sky_ISService/shared/registerservice"
+	"strings"
+	"testing"
+	"time"
+)
+
+// 只包含服务发现所需字段的代理
+func newDiscoveryTestProxy(static map[string][]*WeightedNode) *Proxy {
+	p := &Proxy{
+		services:       make(map[string][]*WeightedNode),
+		staticServices: make(map[string][]*WeightedNode),
+		nodeVersions:   make(map[string]uint64),
+	}
+	for name, nodes := range static {
+		p.services[name] = nodes
+		p.staticServices[name] = nodes
+	}
+	return p
+}
+
+// 服务当前的节点，格式为 地址(权重,版本)
+func serviceNodes(p *Proxy, service string) string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	nodes, ok := p.services[service]
+	if !ok {
+		return "<none>"
+	}
+	parts := make([]string, 0, len(nodes))
+	for _, node := range nodes {
+		parts = append(parts, fmt.Sprintf("%s(%d,%s)", node.addr, node.weight, node.version))
+	}
+	return strings.Join(parts, " ")
+}
+
+// 等待服务节点变为预期值
+func waitForNodes(t *testing.T, p *Proxy, service, want string) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		got := serviceNodes(p, service)
+		if got == want {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("服务 %s 的节点为 %q，期望 %q", service, got, want)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestMemoryCatalogBlockingQuery(t *testing.T) {
+	catalog := NewMemoryCatalog()
+	_, index, err := catalog.Instances(context.Background(), "system", 0)
+	if err != nil {
+		t.Fatalf("查询实例失败: %v", err)
+	}
+
+	// 索引未变化时阻塞，直到超时
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, _, err := catalog.Instances(ctx, "system", index); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("索引未变化时应阻塞到超时，实际返回 %v", err)
+	}
+
+	// 注册实例后唤醒阻塞中的查询
+	type result struct {
+		instances []*ServiceInstance
+		index     uint64
+		err       error
+	}
+	done := make(chan result, 1)
+	go func() {
+		instances, newIndex, err := catalog.Instances(context.Background(), "system", index)
+		done <- result{instances, newIndex, err}
+	}()
+	time.Sleep(10 * time.Millisecond)
+	catalog.Register(&ServiceInstance{ID: "system-8081", Service: "system", Address: "10.0.0.1", Port: 8081})
+
+	select {
+	case got := <-done:
+		if got.err != nil {
+			t.Fatalf("查询实例失败: %v", got.err)
+		}
+		if got.index <= index {
+			t.Fatalf("目录变更后索引应增大: %d -> %d", index, got.index)
+		}
+		if len(got.instances) != 1 || got.instances[0].ID != "system-8081" {
+			t.Fatalf("查询到的实例不正确: %+v", got.instances)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("注册实例后阻塞查询没有返回")
+	}
+
+	services, _, err := catalog.Services(context.Background(), 0)
+	if err != nil || len(services) != 1 || services[0] != "system" {
+		t.Fatalf("服务列表不正确: %v %v", services, err)
+	}
+
+	catalog.Deregister("system", "system-8081")
+	services, _, _ = catalog.Services(context.Background(), 0)
+	if len(services) != 0 {
+		t.Fatalf("服务下没有实例时应从服务列表移除: %v", services)
+	}
+}
+
+func TestDiscoveryRebuildsServiceNodes(t *testing.T) {
+	p := newDiscoveryTestProxy(map[string][]*WeightedNode{
+		"system": {{addr: "127.0.0.1:8081", weight: defaultNodeWeight}},
+	})
+	catalog := NewMemoryCatalog()
+	discovery := NewDiscovery(p, catalog, nil)
+	discovery.Start()
+	defer discovery.Stop()
+
+	// 权重与版本优先读取元数据，其次读取标签
+	catalog.Register(&ServiceInstance{ID: "system-1", Service: "system", Address: "10.0.0.1", Port: 8081,
+		Meta: map[string]string{consul.MetaWeight: "30", consul.MetaVersion: "v2"}})
+	catalog.Register(&ServiceInstance{ID: "system-2", Service: "system", Address: "10.0.0.2", Port: 8081,
+		Tags: []string{"weight=20"}})
+	waitForNodes(t, p, "system", "10.0.0.1:8081(30,v2) 10.0.0.2:8081(20,)")
+
+	// 扩容、缩容无需重启网关
+	catalog.Register(&ServiceInstance{ID: "system-3", Service: "system", Address: "10.0.0.3", Port: 8081})
+	waitForNodes(t, p, "system", fmt.Sprintf("10.0.0.1:8081(30,v2) 10.0.0.2:8081(20,) 10.0.0.3:8081(%d,)", defaultNodeWeight))
+	catalog.Deregister("system", "system-1")
+	catalog.Deregister("system", "system-3")
+	waitForNodes(t, p, "system", "10.0.0.2:8081(20,)")
+
+	// 权重为 0 的实例摘除流量
+	catalog.Register(&ServiceInstance{ID: "system-2", Service: "system", Address: "10.0.0.2", Port: 8081,
+		Meta: map[string]string{consul.MetaWeight: "0"}})
+	catalog.Register(&ServiceInstance{ID: "system-4", Service: "system", Address: "10.0.0.4", Port: 8081})
+	waitForNodes(t, p, "system", fmt.Sprintf("10.0.0.4:8081(%d,)", defaultNodeWeight))
+
+	// 所有实例下线后回退到静态节点
+	catalog.Deregister("system", "system-2")
+	catalog.Deregister("system", "system-4")
+	waitForNodes(t, p, "system", fmt.Sprintf("127.0.0.1:8081(%d,)", defaultNodeWeight))
+
+	// 没有静态节点的服务下线后移除
+	catalog.Register(&ServiceInstance{ID: "order-1", Service: "order", Address: "10.0.1.1", Port: 8085})
+	waitForNodes(t, p, "order", fmt.Sprintf("10.0.1.1:8085(%d,)", defaultNodeWeight))
+	catalog.Deregister("order", "order-1")
+	waitForNodes(t, p, "order", "<none>")
+
+	// 排除的服务不会被代理
+	catalog.Register(&ServiceInstance{ID: "gateway-id", Service: "gateway", Address: "127.0.0.1", Port: 8080})
+	catalog.Register(&ServiceInstance{ID: "auth-1", Service: "auth", Address: "10.0.2.1", Port: 8083})
+	waitForNodes(t, p, "auth", fmt.Sprintf("10.0.2.1:8083(%d,)", defaultNodeWeight))
+	if got := serviceNodes(p, "gateway"); got != "<none>" {
+		t.Fatalf("排除的服务不应被代理: %s", got)
+	}
+}
+
+func TestDiscoveryStopWaitsForWatchers(t *testing.T) {
+	p := newDiscoveryTestProxy(nil)
+	catalog := NewMemoryCatalog()
+	catalog.Register(&ServiceInstance{ID: "auth-1", Service: "auth", Address: "10.0.2.1", Port: 8083})
+	discovery := NewDiscovery(p, catalog, nil)
+	discovery.Start()
+	waitForNodes(t, p, "auth", fmt.Sprintf("10.0.2.1:8083(%d,)", defaultNodeWeight))
+
+	stopped := make(chan struct{})
+	go func() {
+		discovery.Stop()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-time.After(time.Second):
+		t.Fatal("停止服务发现时阻塞查询没有退出")
+	}
+
+	// 停止后目录变更不再影响代理
+	catalog.Deregister("auth", "auth-1")
+	time.Sleep(20 * time.Millisecond)
+	if got := serviceNodes(p, "auth"); got != fmt.Sprintf("10.0.2.1:8083(%d,)", defaultNodeWeight) {
+		t.Fatalf("停止后节点不应变化: %s", got)
+	}
+}
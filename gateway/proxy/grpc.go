@@ -0,0 +1,585 @@
+package proxy
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"sky_ISService/config"
+	"sky_ISService/pkg/metrics"
+	"sky_ISService/pkg/tracing"
+	"sky_ISService/utils"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	reflectionpb "google.golang.org/grpc/reflection/grpc_reflection_v1"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+const (
+	defaultGRPCTimeout   = 10 * time.Second
+	grpcMaxBodySize      = 4 << 20
+	defaultSystemRPCPort = "9999" // services/system/grpc 监听的端口
+)
+
+// 转发给 gRPC 服务的请求头（作为 metadata），请求 ID 与链路信息由 tracing 拦截器传递
+var grpcForwardHeaders = []string{"Authorization", utils.RealIPHeader}
+
+// gRPC 状态码与 HTTP 状态码的对应关系
+var grpcHTTPStatus = map[codes.Code]int{
+	codes.OK:                 http.StatusOK,
+	codes.Canceled:           499,
+	codes.Unknown:            http.StatusInternalServerError,
+	codes.InvalidArgument:    http.StatusBadRequest,
+	codes.DeadlineExceeded:   http.StatusGatewayTimeout,
+	codes.NotFound:           http.StatusNotFound,
+	codes.AlreadyExists:      http.StatusConflict,
+	codes.PermissionDenied:   http.StatusForbidden,
+	codes.ResourceExhausted:  http.StatusTooManyRequests,
+	codes.FailedPrecondition: http.StatusBadRequest,
+	codes.Aborted:            http.StatusConflict,
+	codes.OutOfRange:         http.StatusBadRequest,
+	codes.Unimplemented:      http.StatusNotImplemented,
+	codes.Internal:           http.StatusInternalServerError,
+	codes.Unavailable:        http.StatusServiceUnavailable,
+	codes.DataLoss:           http.StatusInternalServerError,
+	codes.Unauthenticated:    http.StatusUnauthorized,
+}
+
+// GRPCRouteInfo gRPC 转码路由信息（用于调试接口）
+type GRPCRouteInfo struct {
+	Name         string `json:"name"`
+	Method       string `json:"method"`
+	Path         string `json:"path"`
+	Target       string `json:"target"`
+	Service      string `json:"service"`
+	RPC          string `json:"rpc"`
+	Body         string `json:"body"`
+	Timeout      string `json:"timeout"`
+	AuthRequired bool   `json:"auth_required"`
+}
+
+// 编译后的 gRPC 转码路由
+type grpcRoute struct {
+	config.GRPCRouteConfig
+	segments []string // 路径模板按 / 拆分后的各段，{field} 为路径参数
+}
+
+// GRPCTranscoder HTTP/JSON 转 gRPC：通过服务端反射获取方法描述，使用动态消息完成 JSON 与 protobuf 的互转
+type GRPCTranscoder struct {
+	routes []*grpcRoute
+
+	mu      sync.Mutex
+	conns   map[string]*grpc.ClientConn              // 服务地址 -> 连接
+	methods map[string]protoreflect.MethodDescriptor // 服务地址/方法全名 -> 方法描述
+	dialers map[string]GRPCDialer                    // gRPC 服务全名 -> 拨号函数（见 UseDialer）
+}
+
+// GRPCDialer 建立到 gRPC 服务的连接，用于连接不监听端口的服务（如进程内的 gRPC 服务）
+type GRPCDialer func(ctx context.Context, addr string) (net.Conn, error)
+
+// 未配置转码路由时使用的默认路由
+func defaultGRPCRoutes() []config.GRPCRouteConfig {
+	return []config.GRPCRouteConfig{
+		{
+			Name:    "system-verify-admin",
+			Method:  http.MethodPost,
+			Path:    "/system/rpc/verify-admin",
+			Target:  fmt.Sprintf("%s:%s", config.GetConfig().System.Addr, defaultSystemRPCPort),
+			Service: "system.SystemService",
+			RPC:     "VerifyIsSystemAdmin",
+		},
+	}
+}
+
+// NewGRPCTranscoder 编译转码路由，未配置时使用默认路由
+func NewGRPCTranscoder(configs []config.GRPCRouteConfig) (*GRPCTranscoder, error) {
+	if len(configs) == 0 {
+		configs = defaultGRPCRoutes()
+	}
+
+	routes := make([]*grpcRoute, 0, len(configs))
+	for i, routeConfig := range configs {
+		if routeConfig.Target == "" || routeConfig.Service == "" || routeConfig.RPC == "" {
+			return nil, fmt.Errorf("gRPC 路由 %d 缺少 target、service 或 rpc", i)
+		}
+		if !strings.HasPrefix(routeConfig.Path, "/") {
+			return nil, fmt.Errorf("gRPC 路由 %d 的路径 %s 必须以 / 开头", i, routeConfig.Path)
+		}
+		if routeConfig.Name == "" {
+			routeConfig.Name = fmt.Sprintf("grpc%d", i)
+		}
+		if routeConfig.Method == "" {
+			routeConfig.Method = http.MethodPost
+		}
+		routeConfig.Method = strings.ToUpper(routeConfig.Method)
+		if routeConfig.Body == "" {
+			routeConfig.Body = "*"
+		}
+		if routeConfig.Timeout <= 0 {
+			routeConfig.Timeout = defaultGRPCTimeout
+		}
+		routes = append(routes, &grpcRoute{
+			GRPCRouteConfig: routeConfig,
+			segments:        strings.Split(strings.Trim(routeConfig.Path, "/"), "/"),
+		})
+	}
+	return &GRPCTranscoder{
+		routes:  routes,
+		conns:   make(map[string]*grpc.ClientConn),
+		methods: make(map[string]protoreflect.MethodDescriptor),
+		dialers: make(map[string]GRPCDialer),
+	}, nil
+}
+
+// Match 返回请求命中的转码路由及路径参数，没有命中时返回 nil
+func (t *GRPCTranscoder) Match(r *http.Request) (*grpcRoute, map[string]string) {
+	segments := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	for _, route := range t.routes {
+		if route.Method != r.Method || len(route.segments) != len(segments) {
+			continue
+		}
+		params := make(map[string]string)
+		matched := true
+		for i, segment := range route.segments {
+			if strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}") {
+				params[segment[1:len(segment)-1]] = segments[i]
+				continue
+			}
+			if segment != segments[i] {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			return route, params
+		}
+	}
+	return nil, nil
+}
+
+// Routes 返回转码路由信息
+func (t *GRPCTranscoder) Routes() []GRPCRouteInfo {
+	infos := make([]GRPCRouteInfo, 0, len(t.routes))
+	for _, route := range t.routes {
+		infos = append(infos, GRPCRouteInfo{
+			Name:         route.Name,
+			Method:       route.Method,
+			Path:         route.Path,
+			Target:       route.Target,
+			Service:      route.Service,
+			RPC:          route.RPC,
+			Body:         route.Body,
+			Timeout:      route.Timeout.String(),
+			AuthRequired: route.RequiresAuth(),
+		})
+	}
+	return infos
+}
+
+// Serve 将 HTTP 请求转换为 gRPC 调用，结果以 utils.Response 格式返回
+func (t *GRPCTranscoder) Serve(w http.ResponseWriter, r *http.Request, route *grpcRoute, params map[string]string) {
+	ctx, cancel := context.WithTimeout(r.Context(), route.Timeout)
+	defer cancel()
+
+	method, err := t.method(ctx, route)
+	if err != nil {
+		log.Printf("获取 gRPC 方法 %s/%s 失败: %v", route.Service, route.RPC, err)
+		writeGRPCError(w, status.Convert(err))
+		return
+	}
+	if method.IsStreamingClient() || method.IsStreamingServer() {
+		writeGRPCError(w, status.New(codes.Unimplemented, "不支持流式 gRPC 方法"))
+		return
+	}
+
+	request := dynamicpb.NewMessage(method.Input())
+	if err := buildGRPCRequest(request, r, route, params); err != nil {
+		writeGRPCError(w, status.New(codes.InvalidArgument, err.Error()))
+		return
+	}
+
+	md := metadata.MD{}
+	for _, header := range grpcForwardHeaders {
+		if value := r.Header.Get(header); value != "" {
+			md.Set(header, value)
+		}
+	}
+	ctx = metadata.NewOutgoingContext(ctx, md)
+
+	conn, err := t.conn(route)
+	if err != nil {
+		writeGRPCError(w, status.New(codes.Unavailable, err.Error()))
+		return
+	}
+	response := dynamicpb.NewMessage(method.Output())
+	fullMethod := fmt.Sprintf("/%s/%s", route.Service, route.RPC)
+	if err := conn.Invoke(ctx, fullMethod, request, response); err != nil {
+		st := status.Convert(err)
+		if st.Code() == codes.Unimplemented {
+			// 服务端可能已更新，下次调用重新获取方法描述
+			t.forget(route)
+		}
+		writeGRPCError(w, st)
+		return
+	}
+
+	data, err := protojson.MarshalOptions{EmitUnpopulated: true}.Marshal(response)
+	if err != nil {
+		writeGRPCError(w, status.New(codes.Internal, fmt.Sprintf("响应转换失败: %v", err)))
+		return
+	}
+	writeJSON(w, http.StatusOK, utils.Response{Code: utils.SuccessCode, Message: "success", Data: json.RawMessage(data)})
+}
+
+// Close 关闭所有 gRPC 连接
+func (t *GRPCTranscoder) Close() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for target, conn := range t.conns {
+		if err := conn.Close(); err != nil {
+			log.Printf("关闭 gRPC 连接 %s 失败: %v", target, err)
+		}
+	}
+	t.conns = make(map[string]*grpc.ClientConn)
+}
+
+// UseDialer 转发 gRPC 服务（全名，如 system.SystemService）的调用时使用 dialer 建立连接，忽略路由的 target（sky dev 连接进程内的 gRPC 服务）
+func (t *GRPCTranscoder) UseDialer(service string, dialer GRPCDialer) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.dialers[service] = dialer
+}
+
+// 获取（必要时创建）到路由目标服务的连接
+func (t *GRPCTranscoder) conn(route *grpcRoute) (*grpc.ClientConn, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	target := route.Target
+	options := []grpc.DialOption{
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithChainUnaryInterceptor(tracing.UnaryClientInterceptor(), metrics.UnaryClientInterceptor()),
+	}
+	if dialer, ok := t.dialers[route.Service]; ok {
+		target = "passthrough:///" + route.Service
+		options = append(options, grpc.WithContextDialer(dialer))
+	}
+	if conn, ok := t.conns[target]; ok {
+		return conn, nil
+	}
+	conn, err := grpc.NewClient(target, options...)
+	if err != nil {
+		return nil, fmt.Errorf("无法连接 gRPC 服务 %s: %v", target, err)
+	}
+	t.conns[target] = conn
+	return conn, nil
+}
+
+// 获取方法描述，首次使用时通过服务端反射获取并缓存
+func (t *GRPCTranscoder) method(ctx context.Context, route *grpcRoute) (protoreflect.MethodDescriptor, error) {
+	key := route.Target + "/" + route.Service + "/" + route.RPC
+	t.mu.Lock()
+	method, ok := t.methods[key]
+	t.mu.Unlock()
+	if ok {
+		return method, nil
+	}
+
+	conn, err := t.conn(route)
+	if err != nil {
+		return nil, err
+	}
+	service, err := resolveGRPCService(ctx, conn, route.Service)
+	if err != nil {
+		return nil, err
+	}
+	method = service.Methods().ByName(protoreflect.Name(route.RPC))
+	if method == nil {
+		return nil, status.Errorf(codes.Unimplemented, "服务 %s 没有方法 %s", route.Service, route.RPC)
+	}
+
+	t.mu.Lock()
+	t.methods[key] = method
+	t.mu.Unlock()
+	return method, nil
+}
+
+// 清除缓存的方法描述
+func (t *GRPCTranscoder) forget(route *grpcRoute) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	delete(t.methods, route.Target+"/"+route.Service+"/"+route.RPC)
+}
+
+// 通过服务端反射获取服务描述（包含依赖的 proto 文件）
+func resolveGRPCService(ctx context.Context, conn *grpc.ClientConn, service string) (protoreflect.ServiceDescriptor, error) {
+	stream, err := reflectionpb.NewServerReflectionClient(conn).ServerReflectionInfo(ctx)
+	if err != nil {
+		return nil, status.Errorf(codes.Unavailable, "gRPC 反射不可用: %v", err)
+	}
+	defer stream.CloseSend()
+
+	files := make(map[string]*descriptorpb.FileDescriptorProto)
+	fetch := func(request *reflectionpb.ServerReflectionRequest) error {
+		if err := stream.Send(request); err != nil {
+			return status.Errorf(codes.Unavailable, "gRPC 反射请求失败: %v", err)
+		}
+		response, err := stream.Recv()
+		if err != nil {
+			return status.Errorf(codes.Unavailable, "gRPC 反射请求失败: %v", err)
+		}
+		if errorResponse := response.GetErrorResponse(); errorResponse != nil {
+			return status.Error(codes.Code(errorResponse.GetErrorCode()), errorResponse.GetErrorMessage())
+		}
+		for _, raw := range response.GetFileDescriptorResponse().GetFileDescriptorProto() {
+			file := &descriptorpb.FileDescriptorProto{}
+			if err := proto.Unmarshal(raw, file); err != nil {
+				return status.Errorf(codes.Internal, "解析 proto 文件描述失败: %v", err)
+			}
+			files[file.GetName()] = file
+		}
+		return nil
+	}
+
+	if err := fetch(&reflectionpb.ServerReflectionRequest{
+		MessageRequest: &reflectionpb.ServerReflectionRequest_FileContainingSymbol{FileContainingSymbol: service},
+	}); err != nil {
+		return nil, err
+	}
+
+	// 补齐依赖：本地已注册的（如 google/protobuf 下的文件）直接使用，其余向服务端请求
+	for {
+		missing := ""
+		for _, file := range files {
+			for _, dependency := range file.GetDependency() {
+				if _, ok := files[dependency]; ok {
+					continue
+				}
+				if local, err := protoregistry.GlobalFiles.FindFileByPath(dependency); err == nil {
+					files[dependency] = protodesc.ToFileDescriptorProto(local)
+					continue
+				}
+				missing = dependency
+			}
+		}
+		if missing == "" {
+			break
+		}
+		if err := fetch(&reflectionpb.ServerReflectionRequest{
+			MessageRequest: &reflectionpb.ServerReflectionRequest_FileByFilename{FileByFilename: missing},
+		}); err != nil {
+			return nil, err
+		}
+		if _, ok := files[missing]; !ok {
+			return nil, status.Errorf(codes.Internal, "服务端未返回依赖文件 %s", missing)
+		}
+	}
+
+	set := &descriptorpb.FileDescriptorSet{}
+	for _, file := range files {
+		set.File = append(set.File, file)
+	}
+	registry, err := protodesc.NewFiles(set)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "构建 proto 描述失败: %v", err)
+	}
+	descriptor, err := registry.FindDescriptorByName(protoreflect.FullName(service))
+	if err != nil {
+		return nil, status.Errorf(codes.Unimplemented, "找不到 gRPC 服务 %s", service)
+	}
+	serviceDescriptor, ok := descriptor.(protoreflect.ServiceDescriptor)
+	if !ok {
+		return nil, status.Errorf(codes.Internal, "%s 不是 gRPC 服务", service)
+	}
+	return serviceDescriptor, nil
+}
+
+// 按路由配置填充请求消息：请求体 -> 查询参数 -> 路径参数，后者覆盖前者
+func buildGRPCRequest(message *dynamicpb.Message, r *http.Request, route *grpcRoute, params map[string]string) error {
+	if route.Body != "-" && r.Body != nil {
+		body, err := io.ReadAll(io.LimitReader(r.Body, grpcMaxBodySize+1))
+		if err != nil {
+			return fmt.Errorf("读取请求体失败: %v", err)
+		}
+		if len(body) > grpcMaxBodySize {
+			return fmt.Errorf("请求体超过 %d 字节", grpcMaxBodySize)
+		}
+		if len(strings.TrimSpace(string(body))) > 0 {
+			if err := unmarshalGRPCBody(message, route.Body, body); err != nil {
+				return err
+			}
+		}
+	}
+
+	for name, values := range r.URL.Query() {
+		field := findGRPCField(message.Descriptor(), name)
+		if field == nil {
+			continue // 忽略与请求消息无关的查询参数（如 access_token）
+		}
+		if err := setGRPCField(message, field, values); err != nil {
+			return err
+		}
+	}
+	for name, value := range params {
+		field := findGRPCField(message.Descriptor(), name)
+		if field == nil {
+			return fmt.Errorf("路径参数 %s 在请求消息中不存在", name)
+		}
+		if err := setGRPCField(message, field, []string{value}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// 请求体映射到整个消息或指定的消息字段
+func unmarshalGRPCBody(message *dynamicpb.Message, bodyField string, body []byte) error {
+	options := protojson.UnmarshalOptions{DiscardUnknown: true}
+	if bodyField == "*" {
+		if err := options.Unmarshal(body, message); err != nil {
+			return fmt.Errorf("请求体格式错误: %v", err)
+		}
+		return nil
+	}
+
+	field := findGRPCField(message.Descriptor(), bodyField)
+	if field == nil || field.Message() == nil || field.IsList() || field.IsMap() {
+		return fmt.Errorf("body 映射的字段 %s 不存在或不是消息类型", bodyField)
+	}
+	if err := options.Unmarshal(body, message.Mutable(field).Message().Interface()); err != nil {
+		return fmt.Errorf("请求体格式错误: %v", err)
+	}
+	return nil
+}
+
+// 按 JSON 名或 proto 字段名查找字段
+func findGRPCField(descriptor protoreflect.MessageDescriptor, name string) protoreflect.FieldDescriptor {
+	if field := descriptor.Fields().ByJSONName(name); field != nil {
+		return field
+	}
+	return descriptor.Fields().ByName(protoreflect.Name(name))
+}
+
+// 将字符串参数写入标量字段（重复字段按多个值追加）
+func setGRPCField(message *dynamicpb.Message, field protoreflect.FieldDescriptor, values []string) error {
+	if field.IsMap() || field.Message() != nil {
+		return fmt.Errorf("参数 %s 不是标量字段", field.Name())
+	}
+	if field.IsList() {
+		list := message.Mutable(field).List()
+		for _, value := range values {
+			parsed, err := parseGRPCScalar(field, value)
+			if err != nil {
+				return err
+			}
+			list.Append(parsed)
+		}
+		return nil
+	}
+	parsed, err := parseGRPCScalar(field, values[len(values)-1])
+	if err != nil {
+		return err
+	}
+	message.Set(field, parsed)
+	return nil
+}
+
+// 解析标量字段的字符串值
+func parseGRPCScalar(field protoreflect.FieldDescriptor, value string) (protoreflect.Value, error) {
+	invalid := func(err error) (protoreflect.Value, error) {
+		return protoreflect.Value{}, fmt.Errorf("参数 %s 的值 %q 无效: %v", field.Name(), value, err)
+	}
+	switch field.Kind() {
+	case protoreflect.StringKind:
+		return protoreflect.ValueOfString(value), nil
+	case protoreflect.BoolKind:
+		parsed, err := strconv.ParseBool(value)
+		if err != nil {
+			return invalid(err)
+		}
+		return protoreflect.ValueOfBool(parsed), nil
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind:
+		parsed, err := strconv.ParseInt(value, 10, 32)
+		if err != nil {
+			return invalid(err)
+		}
+		return protoreflect.ValueOfInt32(int32(parsed)), nil
+	case protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind:
+		parsed, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return invalid(err)
+		}
+		return protoreflect.ValueOfInt64(parsed), nil
+	case protoreflect.Uint32Kind, protoreflect.Fixed32Kind:
+		parsed, err := strconv.ParseUint(value, 10, 32)
+		if err != nil {
+			return invalid(err)
+		}
+		return protoreflect.ValueOfUint32(uint32(parsed)), nil
+	case protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		parsed, err := strconv.ParseUint(value, 10, 64)
+		if err != nil {
+			return invalid(err)
+		}
+		return protoreflect.ValueOfUint64(parsed), nil
+	case protoreflect.FloatKind:
+		parsed, err := strconv.ParseFloat(value, 32)
+		if err != nil {
+			return invalid(err)
+		}
+		return protoreflect.ValueOfFloat32(float32(parsed)), nil
+	case protoreflect.DoubleKind:
+		parsed, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return invalid(err)
+		}
+		return protoreflect.ValueOfFloat64(parsed), nil
+	case protoreflect.BytesKind:
+		parsed, err := base64.StdEncoding.DecodeString(value)
+		if err != nil {
+			return invalid(err)
+		}
+		return protoreflect.ValueOfBytes(parsed), nil
+	case protoreflect.EnumKind:
+		if enumValue := field.Enum().Values().ByName(protoreflect.Name(value)); enumValue != nil {
+			return protoreflect.ValueOfEnum(enumValue.Number()), nil
+		}
+		parsed, err := strconv.ParseInt(value, 10, 32)
+		if err != nil {
+			return invalid(err)
+		}
+		return protoreflect.ValueOfEnum(protoreflect.EnumNumber(parsed)), nil
+	}
+	return invalid(fmt.Errorf("不支持的字段类型 %s", field.Kind()))
+}
+
+// gRPC 错误转换为 utils.Response
+func writeGRPCError(w http.ResponseWriter, st *status.Status) {
+	code, ok := grpcHTTPStatus[st.Code()]
+	if !ok {
+		code = http.StatusInternalServerError
+	}
+	writeJSON(w, code, utils.Response{Code: code, Message: st.Message()})
+}
+
+// GRPCTranscoder 返回 HTTP/JSON 转 gRPC 转码器
+func (p *Proxy) GRPCTranscoder() *GRPCTranscoder {
+	return p.grpc
+}
@@ -0,0 +1,362 @@
+package proxy
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"sky_ISService/config"
+	"sort"
+	"sync"
+	"time"
+)
+
+// 节点健康状态
+const (
+	NodeHealthy    = "healthy"    // 正常
+	NodeUnhealthy  = "unhealthy"  // 最近有失败，但尚未达到摘除阈值
+	NodeEjected    = "ejected"    // 已摘除，不分配流量
+	NodeRecovering = "recovering" // 重新接入中，权重逐步恢复
+)
+
+// 慢启动期间的最低权重比例
+const slowStartMinRatio = 0.1
+
+// nodeHealth 单个节点的健康状态
+type nodeHealth struct {
+	consecutiveFailures int       // 连续失败次数
+	ejectionCount       int       // 连续被摘除的次数，用于计算摘除时长
+	ejectedUntil        time.Time // 摘除截止时间
+	readmittedAt        time.Time // 重新接入时间
+	lastCheckAt         time.Time // 最近一次探测时间
+	lastError           string    // 最近一次失败原因
+	totalRequests       int64     // 累计请求数（含探测）
+	totalFailures       int64     // 累计失败数（含探测）
+}
+
+// NodeStatus 节点健康状态快照
+type NodeStatus struct {
+	Addr                string     `json:"addr"`
+	Version             string     `json:"version,omitempty"`
+	Weight              int        `json:"weight"`
+	EffectiveWeight     int        `json:"effective_weight"`
+	State               string     `json:"state"`
+	ConsecutiveFailures int        `json:"consecutive_failures"`
+	EjectedUntil        *time.Time `json:"ejected_until,omitempty"`
+	LastCheckAt         *time.Time `json:"last_check_at,omitempty"`
+	LastError           string     `json:"last_error,omitempty"`
+	TotalRequests       int64      `json:"total_requests"`
+	TotalFailures       int64      `json:"total_failures"`
+}
+
+// HealthChecker 节点健康检查：主动 HTTP 探测 + 被动统计真实请求结果，失败节点临时摘除并慢启动恢复
+type HealthChecker struct {
+	settings config.HealthCheckConfig
+	client   *http.Client
+
+	mu    sync.Mutex
+	nodes map[string]*nodeHealth // 节点地址 -> 健康状态
+
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewHealthChecker 创建健康检查器，未配置的项使用默认值
+func NewHealthChecker(settings config.HealthCheckConfig) *HealthChecker {
+	if settings.Path == "" {
+		settings.Path = "/health"
+	}
+	if settings.Interval <= 0 {
+		settings.Interval = 10 * time.Second
+	}
+	if settings.Timeout <= 0 {
+		settings.Timeout = 2 * time.Second
+	}
+	if settings.FailureThreshold <= 0 {
+		settings.FailureThreshold = 3
+	}
+	if settings.BaseEjectionTime <= 0 {
+		settings.BaseEjectionTime = 30 * time.Second
+	}
+	if settings.MaxEjectionTime <= 0 {
+		settings.MaxEjectionTime = 5 * time.Minute
+	}
+	if settings.SlowStart <= 0 {
+		settings.SlowStart = 30 * time.Second
+	}
+	return &HealthChecker{
+		settings: settings,
+		client:   &http.Client{Timeout: settings.Timeout},
+		nodes:    make(map[string]*nodeHealth),
+	}
+}
+
+// RecordSuccess 记录一次成功的请求或探测
+func (h *HealthChecker) RecordSuccess(addr string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	state := h.stateLocked(addr)
+	state.totalRequests++
+	state.consecutiveFailures = 0
+	state.lastError = ""
+	// 完整度过慢启动期后清空摘除次数，下次摘除重新从基础时长计算
+	if !state.readmittedAt.IsZero() && time.Since(state.readmittedAt) >= h.settings.SlowStart {
+		state.readmittedAt = time.Time{}
+		state.ejectionCount = 0
+	}
+}
+
+// RecordFailure 记录一次失败的请求或探测，连续失败达到阈值时摘除节点
+func (h *HealthChecker) RecordFailure(addr string, reason string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	now := time.Now()
+	state := h.stateLocked(addr)
+	state.totalRequests++
+	state.totalFailures++
+	state.consecutiveFailures++
+	state.lastError = reason
+
+	if state.consecutiveFailures < h.settings.FailureThreshold || h.isEjectedLocked(state, now) {
+		return
+	}
+
+	// 摘除时长随连续摘除次数线性增长，不超过上限
+	state.ejectionCount++
+	duration := time.Duration(state.ejectionCount) * h.settings.BaseEjectionTime
+	if duration > h.settings.MaxEjectionTime {
+		duration = h.settings.MaxEjectionTime
+	}
+	state.ejectedUntil = now.Add(duration)
+	state.readmittedAt = time.Time{}
+	state.consecutiveFailures = 0
+	log.Printf("节点 %s 连续失败，摘除 %s: %s", addr, duration, reason)
+}
+
+// EffectiveWeight 返回节点当前可分配的权重：摘除期间为 0，慢启动期间按时间比例逐步恢复
+func (h *HealthChecker) EffectiveWeight(node *WeightedNode) int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	state, ok := h.nodes[node.addr]
+	if !ok {
+		return node.weight
+	}
+	now := time.Now()
+	if h.isEjectedLocked(state, now) {
+		return 0
+	}
+	if state.readmittedAt.IsZero() {
+		return node.weight
+	}
+
+	ratio := float64(now.Sub(state.readmittedAt)) / float64(h.settings.SlowStart)
+	if ratio >= 1 {
+		return node.weight
+	}
+	if ratio < slowStartMinRatio {
+		ratio = slowStartMinRatio
+	}
+	weight := int(float64(node.weight) * ratio)
+	if weight < 1 {
+		weight = 1
+	}
+	return weight
+}
+
+// Status 返回节点健康状态快照
+func (h *HealthChecker) Status(node *WeightedNode) NodeStatus {
+	effectiveWeight := h.EffectiveWeight(node)
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	status := NodeStatus{
+		Addr:            node.addr,
+		Version:         node.version,
+		Weight:          node.weight,
+		EffectiveWeight: effectiveWeight,
+		State:           NodeHealthy,
+	}
+	state, ok := h.nodes[node.addr]
+	if !ok {
+		return status
+	}
+
+	now := time.Now()
+	status.ConsecutiveFailures = state.consecutiveFailures
+	status.LastError = state.lastError
+	status.TotalRequests = state.totalRequests
+	status.TotalFailures = state.totalFailures
+	if !state.lastCheckAt.IsZero() {
+		lastCheckAt := state.lastCheckAt
+		status.LastCheckAt = &lastCheckAt
+	}
+	switch {
+	case h.isEjectedLocked(state, now):
+		ejectedUntil := state.ejectedUntil
+		status.EjectedUntil = &ejectedUntil
+		status.State = NodeEjected
+	case !state.readmittedAt.IsZero() && now.Sub(state.readmittedAt) < h.settings.SlowStart:
+		status.State = NodeRecovering
+	case state.consecutiveFailures > 0:
+		status.State = NodeUnhealthy
+	}
+	return status
+}
+
+// Start 按配置间隔主动探测 nodes 返回的全部节点
+func (h *HealthChecker) Start(nodes func() []*WeightedNode) {
+	ctx, cancel := context.WithCancel(context.Background())
+	h.cancel = cancel
+
+	h.wg.Add(1)
+	go func() {
+		defer h.wg.Done()
+		ticker := time.NewTicker(h.settings.Interval)
+		defer ticker.Stop()
+		for {
+			h.probeAll(ctx, nodes())
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// Stop 停止主动探测
+func (h *HealthChecker) Stop() {
+	if h.cancel != nil {
+		h.cancel()
+	}
+	h.wg.Wait()
+}
+
+// 并发探测所有节点，并清理已不存在节点的状态
+func (h *HealthChecker) probeAll(ctx context.Context, nodes []*WeightedNode) {
+	addrs := make(map[string]bool, len(nodes))
+	for _, node := range nodes {
+		addrs[node.addr] = true
+	}
+
+	var wg sync.WaitGroup
+	for addr := range addrs {
+		wg.Add(1)
+		go func(addr string) {
+			defer wg.Done()
+			h.probe(ctx, addr)
+		}(addr)
+	}
+	wg.Wait()
+
+	h.mu.Lock()
+	for addr := range h.nodes {
+		if !addrs[addr] {
+			delete(h.nodes, addr)
+		}
+	}
+	h.mu.Unlock()
+}
+
+// 探测单个节点，只有 2xx 响应视为存活（404、401 说明探测路径不可用，不能证明节点正常）
+func (h *HealthChecker) probe(ctx context.Context, addr string) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf("http://%s%s", addr, h.settings.Path), nil)
+	if err != nil {
+		return
+	}
+
+	resp, err := h.client.Do(req)
+	if ctx.Err() != nil {
+		return
+	}
+
+	h.mu.Lock()
+	h.stateLocked(addr).lastCheckAt = time.Now()
+	h.mu.Unlock()
+
+	if err != nil {
+		h.RecordFailure(addr, fmt.Sprintf("健康探测失败: %v", err))
+		return
+	}
+	resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		h.RecordFailure(addr, fmt.Sprintf("健康探测返回 %d", resp.StatusCode))
+		return
+	}
+	h.RecordSuccess(addr)
+}
+
+// 获取或创建节点状态（调用方需持有锁）
+func (h *HealthChecker) stateLocked(addr string) *nodeHealth {
+	state, ok := h.nodes[addr]
+	if !ok {
+		state = &nodeHealth{}
+		h.nodes[addr] = state
+	}
+	return state
+}
+
+// 判断节点是否处于摘除期，摘除到期时转为慢启动（调用方需持有锁）
+func (h *HealthChecker) isEjectedLocked(state *nodeHealth, now time.Time) bool {
+	if state.ejectedUntil.IsZero() {
+		return false
+	}
+	if now.Before(state.ejectedUntil) {
+		return true
+	}
+	state.readmittedAt = state.ejectedUntil
+	state.ejectedUntil = time.Time{}
+	return false
+}
+
+// ServiceHealth 服务及其节点的健康状态
+type ServiceHealth struct {
+	Service string       `json:"service"`
+	Nodes   []NodeStatus `json:"nodes"`
+}
+
+// HealthStatus 返回所有服务节点的健康状态，按服务名排序
+func (p *Proxy) HealthStatus() []ServiceHealth {
+	p.mu.Lock()
+	services := make(map[string][]*WeightedNode, len(p.services))
+	for name, nodes := range p.services {
+		services[name] = nodes
+	}
+	p.mu.Unlock()
+
+	result := make([]ServiceHealth, 0, len(services))
+	for name, nodes := range services {
+		item := ServiceHealth{Service: name, Nodes: make([]NodeStatus, 0, len(nodes))}
+		for _, node := range nodes {
+			item.Nodes = append(item.Nodes, p.health.Status(node))
+		}
+		result = append(result, item)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Service < result[j].Service })
+	return result
+}
+
+// StartHealthCheck 启动节点主动健康探测
+func (p *Proxy) StartHealthCheck() {
+	p.health.Start(p.allNodes)
+}
+
+// StopHealthCheck 停止节点主动健康探测
+func (p *Proxy) StopHealthCheck() {
+	p.health.Stop()
+}
+
+// 所有服务的节点快照
+func (p *Proxy) allNodes() []*WeightedNode {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	var nodes []*WeightedNode
+	for _, serviceNodes := range p.services {
+		nodes = append(nodes, serviceNodes...)
+	}
+	return nodes
+}
@@ -0,0 +1,146 @@
+package proxy
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+)
+
+// 进程内节点地址的后缀：<服务名>.inprocess
+const inProcessSuffix = ".inprocess"
+
+// 转发到进程内节点时请求的来源地址（等同于经本机网关转发）
+const inProcessRemoteAddr = "127.0.0.1:0"
+
+// MountInProcess 将服务挂载为进程内节点（sky dev 使用）：请求直接交给 handler 处理，不经过网络，
+// 插件、鉴权、重试、熔断等与转发到网络节点时一致；该节点同时替换服务的静态节点，服务发现无实例时仍回退到它。
+// 进程内节点不支持 WebSocket 协议升级。需在 StartHealthCheck 之前调用
+func (p *Proxy) MountInProcess(service string, handler http.Handler) {
+	addr := service + inProcessSuffix
+	p.transports.inProcess.Store(addr, handler)
+	p.transports.mounted.Store(true)
+	// 主动健康探测同样直接交给 handler
+	p.health.client.Transport = &inProcessTransport{handlers: &p.transports.inProcess, next: http.DefaultTransport}
+
+	node := &WeightedNode{addr: addr, weight: defaultNodeWeight}
+	p.mu.Lock()
+	p.staticServices[service] = []*WeightedNode{node}
+	p.mu.Unlock()
+	p.SetServiceNodes(service, []*WeightedNode{node})
+}
+
+// 进程内节点的 Transport：目标地址已挂载时在当前进程内处理请求，否则交给 next
+type inProcessTransport struct {
+	handlers *sync.Map // 节点地址 -> http.Handler
+	next     http.RoundTripper
+}
+
+func (t *inProcessTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	handler, ok := t.handlers.Load(req.URL.Host)
+	if !ok {
+		return t.next.RoundTrip(req)
+	}
+
+	// 构造服务端看到的请求：只保留路径与查询参数，请求体由处理函数直接读取
+	inbound := req.Clone(req.Context())
+	inbound.URL = &url.URL{Path: req.URL.Path, RawPath: req.URL.RawPath, RawQuery: req.URL.RawQuery}
+	inbound.RequestURI = req.URL.RequestURI()
+	inbound.RemoteAddr = inProcessRemoteAddr
+	if inbound.Host == "" {
+		inbound.Host = req.URL.Host
+	}
+	if inbound.Body == nil {
+		inbound.Body = http.NoBody
+	}
+
+	reader, writer := io.Pipe()
+	w := &inProcessResponseWriter{
+		header:  make(http.Header),
+		request: req,
+		body:    reader,
+		pipe:    writer,
+		ready:   make(chan struct{}),
+	}
+	failed := make(chan error, 1)
+	go func() {
+		defer func() {
+			if recovered := recover(); recovered != nil {
+				err := fmt.Errorf("进程内节点 %s 处理请求时 panic: %v", req.URL.Host, recovered)
+				log.Print(err)
+				failed <- err
+				writer.CloseWithError(err)
+				return
+			}
+			w.WriteHeader(http.StatusOK) // 处理函数没有写出响应时与 net/http 一致返回 200
+			writer.Close()
+		}()
+		handler.(http.Handler).ServeHTTP(w, inbound)
+	}()
+
+	select {
+	case <-w.ready:
+		return w.response, nil
+	case err := <-failed:
+		return nil, err
+	case <-req.Context().Done():
+		reader.CloseWithError(req.Context().Err())
+		return nil, req.Context().Err()
+	}
+}
+
+// 进程内节点的响应：写出响应头后 RoundTrip 返回，响应体通过管道逐段交给网关，流式响应无需等待处理结束
+type inProcessResponseWriter struct {
+	header   http.Header
+	request  *http.Request
+	body     *io.PipeReader
+	pipe     *io.PipeWriter
+	response *http.Response
+	ready    chan struct{} // 响应头已写出
+	once     sync.Once
+}
+
+func (w *inProcessResponseWriter) Header() http.Header {
+	return w.header
+}
+
+func (w *inProcessResponseWriter) WriteHeader(statusCode int) {
+	// 1xx 信息响应不转发
+	if statusCode >= 100 && statusCode < 200 && statusCode != http.StatusSwitchingProtocols {
+		return
+	}
+	w.once.Do(func() {
+		header := w.header.Clone()
+		contentLength := int64(-1)
+		if value := header.Get("Content-Length"); value != "" {
+			if n, err := strconv.ParseInt(value, 10, 64); err == nil {
+				contentLength = n
+			}
+		}
+		w.response = &http.Response{
+			Status:        fmt.Sprintf("%d %s", statusCode, http.StatusText(statusCode)),
+			StatusCode:    statusCode,
+			Proto:         "HTTP/1.1",
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+			Header:        header,
+			Body:          w.body,
+			ContentLength: contentLength,
+			Request:       w.request,
+		}
+		close(w.ready)
+	})
+}
+
+func (w *inProcessResponseWriter) Write(data []byte) (int, error) {
+	w.WriteHeader(http.StatusOK)
+	return w.pipe.Write(data) // 网关停止读取（客户端断开）后返回 io.ErrClosedPipe
+}
+
+// Flush 管道无缓冲，写入的数据已交给网关，这里只需确保响应头已写出
+func (w *inProcessResponseWriter) Flush() {
+	w.WriteHeader(http.StatusOK)
+}
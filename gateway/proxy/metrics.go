@@ -0,0 +1,151 @@
+package proxy
+
+import (
+	"sky_ISService/pkg/circuit"
+	"sky_ISService/pkg/metrics"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	upstreamRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "sky",
+		Subsystem: "gateway",
+		Name:      "upstream_requests_total",
+		Help:      "转发到上游服务的请求数，status 为 error 表示未收到上游响应",
+	}, []string{"service", "route", "status"})
+
+	upstreamDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: "sky",
+		Subsystem: "gateway",
+		Name:      "upstream_duration_seconds",
+		Help:      "上游服务响应耗时（收到响应头为止）",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"service", "route"})
+
+	upstreamSelections = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "sky",
+		Subsystem: "gateway",
+		Name:      "upstream_selections_total",
+		Help:      "负载均衡选中各节点的次数，node 为 none 表示没有可用节点，节点下线后删除对应的时间序列",
+	}, []string{"service", "node"})
+
+	upstreamRetries = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "sky",
+		Subsystem: "gateway",
+		Name:      "upstream_retries_total",
+		Help:      "上游请求重试次数，reason 为 error 表示连接失败，否则为上游响应码",
+	}, []string{"service", "reason"})
+
+	retryBudgetExhausted = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "sky",
+		Subsystem: "gateway",
+		Name:      "retry_budget_exhausted_total",
+		Help:      "因重试预算耗尽而放弃重试的次数",
+	}, []string{"service"})
+
+	apiKeyRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "sky",
+		Subsystem: "gateway",
+		Name:      "api_key_requests_total",
+		Help:      "携带 API Key 的请求数，result 为 ok 表示通过，否则为拒绝原因",
+	}, []string{"result"})
+
+	signedRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "sky",
+		Subsystem: "gateway",
+		Name:      "signed_requests_total",
+		Help:      "合作方签名请求数，result 为 ok 表示验签通过，否则为拒绝原因",
+	}, []string{"app", "result"})
+
+	breakerTransitions = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "sky",
+		Subsystem: "gateway",
+		Name:      "circuit_breaker_transitions_total",
+		Help:      "熔断器状态变化次数",
+	}, []string{"breaker", "from", "to"})
+
+	breakerStateDesc = prometheus.NewDesc("sky_gateway_circuit_breaker_state",
+		"熔断器状态：0 关闭，1 半开，2 打开", []string{"breaker"}, nil)
+	breakerFailureRatioDesc = prometheus.NewDesc("sky_gateway_circuit_breaker_failure_ratio",
+		"熔断器当前窗口内的失败率", []string{"breaker"}, nil)
+)
+
+// 熔断器状态对应的指标值
+var breakerStateValues = map[circuit.State]float64{
+	circuit.StateClosed:   0,
+	circuit.StateHalfOpen: 1,
+	circuit.StateOpen:     2,
+}
+
+// 熔断器采集器，采集时读取所有熔断器的快照
+type breakerCollector struct {
+	breakers *circuit.Group
+}
+
+func (c *breakerCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- breakerStateDesc
+	ch <- breakerFailureRatioDesc
+}
+
+func (c *breakerCollector) Collect(ch chan<- prometheus.Metric) {
+	for _, snapshot := range c.breakers.Snapshots() {
+		ch <- prometheus.MustNewConstMetric(breakerStateDesc, prometheus.GaugeValue, breakerStateValues[snapshot.State], snapshot.Name)
+		ch <- prometheus.MustNewConstMetric(breakerFailureRatioDesc, prometheus.GaugeValue, snapshot.FailureRatio, snapshot.Name)
+	}
+}
+
+// 注册代理相关的指标
+func (p *Proxy) registerMetrics() {
+	p.breakers.OnStateChange(func(name string, from, to circuit.State) {
+		breakerTransitions.WithLabelValues(name, string(from), string(to)).Inc()
+	})
+	metrics.Register(&breakerCollector{breakers: p.breakers})
+}
+
+// 记录节点选择结果
+func recordUpstreamSelection(service string, node *WeightedNode) {
+	addr := "none"
+	if node != nil {
+		addr = node.addr
+	}
+	upstreamSelections.WithLabelValues(service, addr).Inc()
+}
+
+// 删除服务中已移除节点的选择次数与熔断状态变化指标
+func forgetRemovedNodes(service string, previous, current []*WeightedNode) {
+	active := make(map[string]bool, len(current))
+	for _, node := range current {
+		active[node.addr] = true
+	}
+	for _, node := range previous {
+		if active[node.addr] {
+			continue
+		}
+		upstreamSelections.DeleteLabelValues(service, node.addr)
+		breakerTransitions.DeletePartialMatch(prometheus.Labels{"breaker": nodeBreakerName(service, node)})
+	}
+}
+
+// 记录上游请求结果，status 为 0 表示未收到上游响应
+func recordUpstreamRequest(service, route string, status int, start time.Time) {
+	code := "error"
+	if status > 0 {
+		code = strconv.Itoa(status)
+		upstreamDuration.WithLabelValues(service, route).Observe(time.Since(start).Seconds())
+	}
+	upstreamRequests.WithLabelValues(service, route, code).Inc()
+}
+
+// 记录一次重试
+func recordUpstreamRetry(service, reason string) {
+	upstreamRetries.WithLabelValues(service, reason).Inc()
+}
+
+// 记录重试预算耗尽
+func recordRetryBudgetExhausted(service string) {
+	retryBudgetExhausted.WithLabelValues(service).Inc()
+}
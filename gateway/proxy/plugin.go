@@ -0,0 +1,277 @@
+package proxy
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"sky_ISService/config"
+	"sky_ISService/utils"
+	"sort"
+	"sync"
+
+	"github.com/mitchellh/mapstructure"
+)
+
+// Plugin 网关插件，至少实现 RequestFilter、ResponseFilter 之一
+// 请求阶段先按 order 执行全局插件，再按配置顺序执行路由插件，响应阶段按相反顺序执行
+type Plugin interface {
+	Name() string
+}
+
+// 内置全局插件的位置，数值小的先执行；访问控制与限流在 API Key 配额计数、合作方随机串记录之前，
+// 被拦截的请求不会消耗配额或随机串
+const (
+	OrderCORS           = 100  // 跨域，预检请求直接响应
+	OrderACL            = 200  // 黑名单、白名单、受限路径
+	OrderAPIKey         = 300  // 机器客户端 API Key 认证
+	OrderSignature      = 400  // 合作方请求验签
+	OrderJWT            = 500  // JWT 认证，已通过 API Key 或验签认证的请求跳过
+	OrderRateLimit      = 600  // 限流，按认证后的调用方计数
+	OrderAPIKeyQuota    = 700  // API Key 配额计数
+	OrderSignatureNonce = 800  // 合作方随机串防重放
+	OrderDefault        = 1000 // gateway.plugins 未配置 order 以及 Use 追加的插件
+)
+
+// Ordered 声明插件在全局插件链中的位置，未实现时使用 OrderDefault
+type Ordered interface {
+	Order() int
+}
+
+// 全局插件及其位置
+type orderedPlugin struct {
+	plugin Plugin
+	order  int
+}
+
+// 插件的位置：优先使用配置的 order，其次是插件声明的位置
+func pluginOrder(plugin Plugin, configured int) int {
+	if configured != 0 {
+		return configured
+	}
+	if ordered, ok := plugin.(Ordered); ok {
+		return ordered.Order()
+	}
+	return OrderDefault
+}
+
+// 按位置排序全局插件，位置相同时保持原有顺序
+func sortPlugins(entries []orderedPlugin) []Plugin {
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].order < entries[j].order
+	})
+	plugins := make([]Plugin, 0, len(entries))
+	for _, entry := range entries {
+		plugins = append(plugins, entry.plugin)
+	}
+	return plugins
+}
+
+// RequestFilter 请求阶段：转发到上游之前执行，返回 false 表示插件已写出响应，终止后续处理
+type RequestFilter interface {
+	Plugin
+	OnRequest(ctx *PluginContext) bool
+}
+
+// ResponseFilter 响应阶段：写出响应头之前执行，可以修改响应头；上游响应、缓存命中以及网关自身返回的错误都会经过
+type ResponseFilter interface {
+	Plugin
+	OnResponse(ctx *PluginContext, status int, header http.Header)
+}
+
+// PluginFactory 根据配置创建插件，settings 为路由表中插件的 config
+type PluginFactory func(settings map[string]interface{}) (Plugin, error)
+
+var (
+	pluginsMu       sync.RWMutex
+	pluginFactories = make(map[string]PluginFactory)
+)
+
+// RegisterPlugin 注册插件，之后可以在 gateway.plugins 或路由的 plugins 中按名称使用；需在创建 Proxy 之前调用
+func RegisterPlugin(name string, factory PluginFactory) {
+	pluginsMu.Lock()
+	defer pluginsMu.Unlock()
+	if _, exists := pluginFactories[name]; exists {
+		panic(fmt.Sprintf("插件 %s 重复注册", name))
+	}
+	pluginFactories[name] = factory
+}
+
+// RegisteredPlugins 返回已注册的插件名
+func RegisteredPlugins() []string {
+	pluginsMu.RLock()
+	defer pluginsMu.RUnlock()
+	names := make([]string, 0, len(pluginFactories))
+	for name := range pluginFactories {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// 按 gateway.plugins 创建全局插件并确定位置
+func newGlobalPlugins(configs []config.PluginConfig) ([]orderedPlugin, error) {
+	plugins, err := NewPlugins(configs)
+	if err != nil {
+		return nil, err
+	}
+	entries := make([]orderedPlugin, 0, len(plugins))
+	for i, plugin := range plugins {
+		entries = append(entries, orderedPlugin{plugin: plugin, order: pluginOrder(plugin, configs[i].Order)})
+	}
+	return entries, nil
+}
+
+// NewPlugins 按配置依次创建插件
+func NewPlugins(configs []config.PluginConfig) ([]Plugin, error) {
+	plugins := make([]Plugin, 0, len(configs))
+	for _, pluginConfig := range configs {
+		pluginsMu.RLock()
+		factory, ok := pluginFactories[pluginConfig.Name]
+		pluginsMu.RUnlock()
+		if !ok {
+			return nil, fmt.Errorf("未知的插件 %s", pluginConfig.Name)
+		}
+		plugin, err := factory(pluginConfig.Config)
+		if err != nil {
+			return nil, fmt.Errorf("插件 %s 配置无效: %v", pluginConfig.Name, err)
+		}
+		plugins = append(plugins, plugin)
+	}
+	return plugins, nil
+}
+
+// DecodePluginConfig 将插件配置解析到结构体（按 mapstructure 标签，支持 1s、5m 形式的时长）
+func DecodePluginConfig(settings map[string]interface{}, target interface{}) error {
+	decoder, err := mapstructure.NewDecoder(&mapstructure.DecoderConfig{
+		DecodeHook:       mapstructure.StringToTimeDurationHookFunc(),
+		WeaklyTypedInput: true,
+		ErrorUnused:      true,
+		Result:           target,
+	})
+	if err != nil {
+		return err
+	}
+	return decoder.Decode(settings)
+}
+
+// PluginContext 一次请求在插件间共享的上下文
+type PluginContext struct {
+	Writer     http.ResponseWriter
+	Request    *http.Request // 插件可以替换（如设置超时），后续处理使用替换后的请求
+	Route      *Route        // 命中的路由，全局插件的请求阶段执行时尚未匹配路由，为 nil
+	ClientIP   string
+	ClientAddr netip.Addr
+	Streaming  bool // 是否为 WebSocket、SSE 长连接
+
+	entered  []Plugin // 已进入的插件，响应阶段按相反顺序执行
+	cleanups []func()
+}
+
+// Reject 以统一的 JSON 格式返回错误，用于请求阶段终止处理：return ctx.Reject(...)
+func (ctx *PluginContext) Reject(code int, message string) bool {
+	writeJSON(ctx.Writer, code, utils.Response{Code: code, Message: message})
+	return false
+}
+
+// Defer 注册请求结束时执行的清理函数
+func (ctx *PluginContext) Defer(fn func()) {
+	ctx.cleanups = append(ctx.cleanups, fn)
+}
+
+// 依次执行插件的请求阶段
+func (ctx *PluginContext) run(plugins []Plugin) bool {
+	for _, plugin := range plugins {
+		ctx.entered = append(ctx.entered, plugin)
+		if filter, ok := plugin.(RequestFilter); ok && !filter.OnRequest(ctx) {
+			return false
+		}
+	}
+	return true
+}
+
+// 按相反顺序执行已进入插件的响应阶段
+func (ctx *PluginContext) respond(status int, header http.Header) {
+	for i := len(ctx.entered) - 1; i >= 0; i-- {
+		if filter, ok := ctx.entered[i].(ResponseFilter); ok {
+			filter.OnResponse(ctx, status, header)
+		}
+	}
+}
+
+// 请求结束，按相反顺序执行清理函数
+func (ctx *PluginContext) finish() {
+	for i := len(ctx.cleanups) - 1; i >= 0; i-- {
+		ctx.cleanups[i]()
+	}
+}
+
+// 在写出响应头之前执行插件响应阶段的 ResponseWriter
+type pluginWriter struct {
+	http.ResponseWriter
+	ctx         *PluginContext
+	wroteHeader bool
+}
+
+func (w *pluginWriter) WriteHeader(status int) {
+	if !w.wroteHeader {
+		w.wroteHeader = true
+		w.ctx.respond(status, w.Header())
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *pluginWriter) Write(b []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *pluginWriter) Flush() {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// Hijack WebSocket 升级：ReverseProxy 已将上游响应头复制到 Header()，劫持后自行写出 101 响应
+func (w *pluginWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hijacker, ok := w.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, errStreamNotHijackable
+	}
+	if !w.wroteHeader {
+		w.wroteHeader = true
+		w.ctx.respond(http.StatusSwitchingProtocols, w.Header())
+	}
+	return hijacker.Hijack()
+}
+
+func (w *pluginWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// Use 追加全局插件，按插件声明的位置（未声明时为 OrderDefault，排在同位置的 gateway.plugins 之后）执行；配置文件重新加载后仍然保留
+func (p *Proxy) Use(plugins ...Plugin) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.extraPlugins = append(p.extraPlugins, plugins...)
+	p.rebuildPlugins()
+}
+
+// Plugins 返回全局插件名
+func (p *Proxy) Plugins() []string {
+	return pluginNames(*p.plugins.Load())
+}
+
+func pluginNames(plugins []Plugin) []string {
+	names := make([]string, 0, len(plugins))
+	for _, plugin := range plugins {
+		names = append(names, plugin.Name())
+	}
+	return names
+}
@@ -0,0 +1,373 @@
+package proxy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"sky_ISService/pkg/identity"
+	"sky_ISService/pkg/middleware"
+	"sky_ISService/pkg/signing"
+	"time"
+)
+
+// 内置插件名
+const (
+	PluginRequestHeaders  = "request_headers"  // 改写转发给上游的请求头
+	PluginResponseHeaders = "response_headers" // 改写（注入）返回给客户端的响应头
+	PluginBodyLimit       = "body_limit"       // 限制请求体大小
+	PluginTimeout         = "timeout"          // 请求超时
+)
+
+func init() {
+	RegisterPlugin(PluginRequestHeaders, func(settings map[string]interface{}) (Plugin, error) {
+		rewrite, err := newHeaderRewrite(settings)
+		if err != nil {
+			return nil, err
+		}
+		return &requestHeadersPlugin{rewrite: rewrite}, nil
+	})
+	RegisterPlugin(PluginResponseHeaders, func(settings map[string]interface{}) (Plugin, error) {
+		rewrite, err := newHeaderRewrite(settings)
+		if err != nil {
+			return nil, err
+		}
+		return &responseHeadersPlugin{rewrite: rewrite}, nil
+	})
+	RegisterPlugin(PluginBodyLimit, func(settings map[string]interface{}) (Plugin, error) {
+		var limit struct {
+			MaxSize int64 `mapstructure:"max_size"` // 最大请求体字节数
+		}
+		if err := DecodePluginConfig(settings, &limit); err != nil {
+			return nil, err
+		}
+		if limit.MaxSize <= 0 {
+			return nil, fmt.Errorf("max_size 必须大于 0")
+		}
+		return &bodyLimitPlugin{maxSize: limit.MaxSize}, nil
+	})
+	RegisterPlugin(PluginTimeout, func(settings map[string]interface{}) (Plugin, error) {
+		var timeout struct {
+			Timeout time.Duration `mapstructure:"timeout"`
+		}
+		if err := DecodePluginConfig(settings, &timeout); err != nil {
+			return nil, err
+		}
+		if timeout.Timeout <= 0 {
+			return nil, fmt.Errorf("timeout 必须大于 0")
+		}
+		return &timeoutPlugin{timeout: timeout.Timeout}, nil
+	})
+}
+
+// 请求头（响应头）改写：依次删除、覆盖、追加
+type headerRewrite struct {
+	Set    map[string]string `mapstructure:"set"`
+	Add    map[string]string `mapstructure:"add"`
+	Remove []string          `mapstructure:"remove"`
+}
+
+func newHeaderRewrite(settings map[string]interface{}) (*headerRewrite, error) {
+	var rewrite headerRewrite
+	if err := DecodePluginConfig(settings, &rewrite); err != nil {
+		return nil, err
+	}
+	if len(rewrite.Set)+len(rewrite.Add)+len(rewrite.Remove) == 0 {
+		return nil, fmt.Errorf("set、add、remove 至少配置一项")
+	}
+	return &rewrite, nil
+}
+
+func (rewrite *headerRewrite) apply(header http.Header) {
+	for _, name := range rewrite.Remove {
+		header.Del(name)
+	}
+	for name, value := range rewrite.Set {
+		header.Set(name, value)
+	}
+	for name, value := range rewrite.Add {
+		header.Add(name, value)
+	}
+}
+
+// 改写转发给上游的请求头
+type requestHeadersPlugin struct {
+	rewrite *headerRewrite
+}
+
+func (pl *requestHeadersPlugin) Name() string { return PluginRequestHeaders }
+
+func (pl *requestHeadersPlugin) OnRequest(ctx *PluginContext) bool {
+	pl.rewrite.apply(ctx.Request.Header)
+	return true
+}
+
+// 改写返回给客户端的响应头
+type responseHeadersPlugin struct {
+	rewrite *headerRewrite
+}
+
+func (pl *responseHeadersPlugin) Name() string { return PluginResponseHeaders }
+
+func (pl *responseHeadersPlugin) OnResponse(ctx *PluginContext, status int, header http.Header) {
+	pl.rewrite.apply(header)
+}
+
+// 限制请求体大小：声明的长度超过上限时直接返回 413，未声明长度（分块传输）时读取超过上限后中止转发
+type bodyLimitPlugin struct {
+	maxSize int64
+}
+
+func (pl *bodyLimitPlugin) Name() string { return PluginBodyLimit }
+
+func (pl *bodyLimitPlugin) OnRequest(ctx *PluginContext) bool {
+	if ctx.Request.ContentLength > pl.maxSize {
+		return ctx.Reject(http.StatusRequestEntityTooLarge, fmt.Sprintf("请求体超过 %d 字节", pl.maxSize))
+	}
+	if ctx.Request.Body != nil && ctx.Request.Body != http.NoBody {
+		ctx.Request.Body = http.MaxBytesReader(ctx.Writer, ctx.Request.Body, pl.maxSize)
+	}
+	return true
+}
+
+// 请求超时（长连接只受空闲超时限制）；路由的 timeout 配置即使用该插件
+type timeoutPlugin struct {
+	timeout time.Duration
+}
+
+func (pl *timeoutPlugin) Name() string { return PluginTimeout }
+
+func (pl *timeoutPlugin) OnRequest(ctx *PluginContext) bool {
+	if ctx.Streaming {
+		return true
+	}
+	timeoutCtx, cancel := context.WithTimeout(ctx.Request.Context(), pl.timeout)
+	ctx.Request = ctx.Request.WithContext(timeoutCtx)
+	ctx.Defer(cancel)
+	return true
+}
+
+// 内置的全局插件，按 Order 排入全局插件链
+func (p *Proxy) builtinPlugins() []Plugin {
+	return []Plugin{
+		&corsPlugin{p: p},
+		&aclPlugin{p: p},
+		&apiKeyPlugin{p: p},
+		&signaturePlugin{p: p},
+		&jwtPlugin{p: p},
+		&rateLimitPlugin{p: p},
+		&apiKeyQuotaPlugin{p: p},
+		&signatureNoncePlugin{p: p},
+	}
+}
+
+// 跨域：按请求命中路由的跨域策略写入响应头，预检请求直接响应
+type corsPlugin struct {
+	p *Proxy
+}
+
+func (pl *corsPlugin) Name() string { return "cors" }
+
+func (pl *corsPlugin) Order() int { return OrderCORS }
+
+func (pl *corsPlugin) OnRequest(ctx *PluginContext) bool {
+	return !pl.p.HandleCORS(ctx.Writer, ctx.Request)
+}
+
+// 访问控制：黑名单、白名单、受限路径（分别受 Proxy.Enable* 开关控制）
+type aclPlugin struct {
+	p *Proxy
+}
+
+func (pl *aclPlugin) Name() string { return "acl" }
+
+func (pl *aclPlugin) Order() int { return OrderACL }
+
+func (pl *aclPlugin) OnRequest(ctx *PluginContext) bool {
+	// 黑名单检查
+	if pl.p.EnableBlacklist {
+		if entry, blocked := pl.p.acl.IsBlacklisted(ctx.ClientAddr); blocked {
+			log.Printf("IP %s 命中黑名单 %s: %s", ctx.ClientIP, entry.CIDR, entry.Reason)
+			http.Error(ctx.Writer, "403 Forbidden - 黑名单", http.StatusForbidden)
+			return false
+		}
+	}
+
+	// 白名单检查
+	if pl.p.EnableWhitelist && !pl.p.acl.IsWhitelisted(ctx.ClientAddr) {
+		http.Error(ctx.Writer, "403 Forbidden - 不在白名单", http.StatusForbidden)
+		return false
+	}
+
+	// 访问策略检查
+	if pl.p.EnableRestrictedRoutes && !pl.p.acl.IsRouteAllowed(ctx.Request.URL.Path, ctx.ClientAddr) {
+		http.Error(ctx.Writer, "403 Forbidden - 受限路径", http.StatusForbidden)
+		return false
+	}
+	return true
+}
+
+// 分布式限流（受 Proxy.EnableRateLimiting 开关控制）
+type rateLimitPlugin struct {
+	p *Proxy
+}
+
+func (pl *rateLimitPlugin) Name() string { return "rate_limit" }
+
+func (pl *rateLimitPlugin) Order() int { return OrderRateLimit }
+
+func (pl *rateLimitPlugin) OnRequest(ctx *PluginContext) bool {
+	if !pl.p.EnableRateLimiting {
+		return true
+	}
+	if result := pl.p.limiter.Load().Allow(ctx.Request, ctx.ClientIP); result != nil {
+		result.WriteHeaders(ctx.Writer)
+		if !result.Allowed {
+			http.Error(ctx.Writer, "429 请求速度过快", http.StatusTooManyRequests)
+			return false
+		}
+	}
+	return true
+}
+
+// 机器客户端 API Key 认证（X-API-Key），作为 JWT 的替代：
+// 携带 API Key 的请求在这里完成校验与授权范围检查，通过后写入调用方身份，JWT 插件随之跳过；配额由 apiKeyQuotaPlugin 在限流之后计数
+type apiKeyPlugin struct {
+	p *Proxy
+}
+
+func (pl *apiKeyPlugin) Name() string { return "api_key" }
+
+func (pl *apiKeyPlugin) Order() int { return OrderAPIKey }
+
+func (pl *apiKeyPlugin) OnRequest(ctx *PluginContext) bool {
+	plaintext := ctx.Request.Header.Get(APIKeyHeader)
+	if plaintext == "" {
+		return true
+	}
+
+	r := ctx.Request
+	key, err := pl.p.apiKeys.Authenticate(plaintext, r.Method, r.URL.Path)
+	if err != nil {
+		return ctx.Reject(apiKeyErrorStatus(err), err.Error())
+	}
+	// 网关转发时据此向上游服务注入签名的身份请求头
+	ctx.Request = r.WithContext(WithAPIKey(identity.WithIdentity(r.Context(), key.Identity()), key))
+	return true
+}
+
+// API Key 配额计数：在访问控制与限流之后执行，被拦截的请求不消耗配额
+type apiKeyQuotaPlugin struct {
+	p *Proxy
+}
+
+func (pl *apiKeyQuotaPlugin) Name() string { return "api_key_quota" }
+
+func (pl *apiKeyQuotaPlugin) Order() int { return OrderAPIKeyQuota }
+
+func (pl *apiKeyQuotaPlugin) OnRequest(ctx *PluginContext) bool {
+	key, ok := APIKeyFromContext(ctx.Request.Context())
+	if !ok {
+		return true
+	}
+	usage, err := pl.p.apiKeys.Consume(ctx.Request.Context(), key)
+	if usage != nil {
+		usage.WriteHeaders(ctx.Writer)
+	}
+	if err != nil {
+		return ctx.Reject(apiKeyErrorStatus(err), err.Error())
+	}
+	return true
+}
+
+func apiKeyErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, ErrAPIKeyScope):
+		return http.StatusForbidden
+	case errors.Is(err, ErrAPIKeyQuota):
+		return http.StatusTooManyRequests
+	default:
+		return http.StatusUnauthorized
+	}
+}
+
+// 合作方开放接口验签（X-App-ID、X-Timestamp、X-Nonce、X-Content-SHA256、X-Signature），作为 JWT 的替代：
+// 携带 X-App-ID 的请求在这里完成验签与授权范围检查，通过后写入调用方身份，JWT 插件随之跳过；随机串由 signatureNoncePlugin 在限流之后记录
+type signaturePlugin struct {
+	p *Proxy
+}
+
+func (pl *signaturePlugin) Name() string { return "signature" }
+
+func (pl *signaturePlugin) Order() int { return OrderSignature }
+
+func (pl *signaturePlugin) OnRequest(ctx *PluginContext) bool {
+	if ctx.Request.Header.Get(signing.AppIDHeader) == "" {
+		return true
+	}
+
+	app, err := pl.p.signatures.Verify(ctx.Request)
+	if err != nil {
+		return ctx.Reject(signatureErrorStatus(err), err.Error())
+	}
+	ctx.Request = ctx.Request.WithContext(withSignedApp(identity.WithIdentity(ctx.Request.Context(), app.Identity()), app))
+	return true
+}
+
+// 合作方随机串防重放：在访问控制与限流之后执行，被拦截的请求不占用合作方的随机串
+type signatureNoncePlugin struct {
+	p *Proxy
+}
+
+func (pl *signatureNoncePlugin) Name() string { return "signature_nonce" }
+
+func (pl *signatureNoncePlugin) Order() int { return OrderSignatureNonce }
+
+func (pl *signatureNoncePlugin) OnRequest(ctx *PluginContext) bool {
+	app, ok := signedAppFromContext(ctx.Request.Context())
+	if !ok {
+		return true
+	}
+	if err := pl.p.signatures.UseNonce(ctx.Request.Context(), app); err != nil {
+		return ctx.Reject(signatureErrorStatus(err), err.Error())
+	}
+	return true
+}
+
+func signatureErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, ErrSigningScope):
+		return http.StatusForbidden
+	case errors.Is(err, ErrSigningBodyTooLong):
+		return http.StatusRequestEntityTooLarge
+	case errors.Is(err, ErrSigningUnavailable):
+		return http.StatusServiceUnavailable
+	default:
+		return http.StatusUnauthorized
+	}
+}
+
+// JWT 认证：路由表中声明无需认证的路由、已通过 API Key 或验签认证的请求跳过
+type jwtPlugin struct {
+	p *Proxy
+}
+
+func (pl *jwtPlugin) Name() string { return "jwt" }
+
+func (pl *jwtPlugin) Order() int { return OrderJWT }
+
+func (pl *jwtPlugin) OnRequest(ctx *PluginContext) bool {
+	if _, ok := identity.FromContext(ctx.Request.Context()); ok {
+		return true
+	}
+	if pl.p.IsPublicRequest(ctx.Request) {
+		return true
+	}
+	r, _, err := middleware.AuthenticateJWT(ctx.Request)
+	if err != nil {
+		return ctx.Reject(http.StatusUnauthorized, err.Error())
+	}
+	ctx.Request = r
+	return true
+}
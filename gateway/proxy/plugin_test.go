@@ -0,0 +1,96 @@
+package proxy
+
+import (
+	"sky_ISService/config"
+	"strings"
+	"testing"
+)
+
+// 只有名称的插件
+type namedPlugin string
+
+func (pl namedPlugin) Name() string { return string(pl) }
+
+// 声明了位置的插件
+type orderedNamedPlugin struct {
+	namedPlugin
+	order int
+}
+
+func (pl orderedNamedPlugin) Order() int { return pl.order }
+
+func TestGlobalPluginOrder(t *testing.T) {
+	headers := map[string]interface{}{"set": map[string]interface{}{"X-Test": "1"}}
+	tests := []struct {
+		name    string
+		configs []config.PluginConfig
+		extra   []Plugin
+		want    string
+	}{
+		{
+			name: "只有内置插件",
+			want: "cors acl api_key signature jwt rate_limit api_key_quota signature_nonce",
+		},
+		{
+			name: "未配置 order 的插件排在内置插件之后",
+			configs: []config.PluginConfig{
+				{Name: PluginRequestHeaders, Config: headers},
+				{Name: PluginResponseHeaders, Config: headers},
+			},
+			want: "cors acl api_key signature jwt rate_limit api_key_quota signature_nonce request_headers response_headers",
+		},
+		{
+			name: "按 order 插入内置插件之间",
+			configs: []config.PluginConfig{
+				{Name: PluginRequestHeaders, Config: headers, Order: OrderACL + 1},
+				{Name: PluginResponseHeaders, Config: headers, Order: 1},
+			},
+			want: "response_headers cors acl request_headers api_key signature jwt rate_limit api_key_quota signature_nonce",
+		},
+		{
+			name:    "Use 追加的插件按声明的位置排序，相同位置排在配置的插件之后",
+			configs: []config.PluginConfig{{Name: PluginRequestHeaders, Config: headers}},
+			extra:   []Plugin{namedPlugin("extra"), orderedNamedPlugin{namedPlugin("early"), OrderCORS}},
+			want:    "cors early acl api_key signature jwt rate_limit api_key_quota signature_nonce request_headers extra",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &Proxy{}
+			configured, err := newGlobalPlugins(tt.configs)
+			if err != nil {
+				t.Fatalf("创建插件失败: %v", err)
+			}
+			p.setPlugins(configured)
+			if len(tt.extra) > 0 {
+				p.Use(tt.extra...)
+			}
+			if got := strings.Join(p.Plugins(), " "); got != tt.want {
+				t.Fatalf("插件顺序为 %q，期望 %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// 认证插件在访问控制之后、限流之前执行，限流按认证后的身份计数；配额、随机串在限流之后记录，被拦截的请求不消耗配额与随机串
+func TestBuiltinPluginsRunAuthBetweenACLAndRateLimit(t *testing.T) {
+	p := &Proxy{}
+	p.setPlugins(nil)
+	position := make(map[string]int)
+	for i, name := range p.Plugins() {
+		position[name] = i
+	}
+	for _, auth := range []string{"api_key", "signature", "jwt"} {
+		if position[auth] < position["acl"] || position[auth] > position["rate_limit"] {
+			t.Fatalf("%s 的位置为 %d，应在 acl(%d) 与 rate_limit(%d) 之间", auth, position[auth], position["acl"], position["rate_limit"])
+		}
+	}
+	for _, write := range []string{"api_key_quota", "signature_nonce"} {
+		if position[write] < position["rate_limit"] {
+			t.Fatalf("%s 应在 rate_limit 之后执行", write)
+		}
+	}
+	if position["cors"] != 0 {
+		t.Fatalf("cors 应最先执行，预检请求不需要认证")
+	}
+}
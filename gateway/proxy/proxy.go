@@ -1,66 +1,158 @@
 package proxy
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
-	"math/rand"
+	"log"
 	"net"
 	"net/http"
 	"net/http/httputil"
 	"net/url"
 	"sky_ISService/config"
+	"sky_ISService/pkg/circuit"
+	"sky_ISService/pkg/identity"
+	"sky_ISService/pkg/tracing"
+	"sky_ISService/shared/cache"
+	"sky_ISService/utils"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
 // WeightedNode 代表一个服务节点
 type WeightedNode struct {
-	addr   string // 服务器地址
-	weight int    // 配置的权重
+	addr    string // 服务器地址
+	weight  int    // 配置的权重
+	version string // 部署版本（灰度分组），为空表示未声明
 }
 
 // Proxy 代理结构体，包含服务节点映射和配置项
 type Proxy struct {
-	services                map[string][]*WeightedNode // 服务节点映射
-	mu                      sync.Mutex                 // 保护并发访问
-	blacklist               map[string]bool            // 黑名单
-	whitelist               map[string]bool            // 白名单
-	restrictedRoutes        map[string][]string        // 受限路径映射（路径 -> 允许的 IP 列表）
-	rateLimit               sync.Map                   // 记录 IP 访问次数
-	EnableBlacklist         bool                       // 是否启用黑名单检查
-	EnableWhitelist         bool                       // 是否启用白名单检查
-	EnableRestrictedRoutes  bool                       // 是否启用受限路径检查
-	EnableRateLimiting      bool                       // 是否启用速率限制
-	RateLimitRequestsPerSec int                        // 每秒允许的请求次数
+	services                map[string][]*WeightedNode  // 服务节点映射
+	staticServices          map[string][]*WeightedNode  // 配置文件中的静态节点（服务发现无实例时回退使用）
+	nodeVersions            map[string]uint64           // 服务名 -> 节点列表版本，每次更新节点时递增
+	mu                      sync.Mutex                  // 保护并发访问
+	routes                  atomic.Pointer[RouteTable]  // 路由表
+	health                  *HealthChecker              // 节点健康检查
+	balancers               map[string]Balancer         // 服务名 -> 负载均衡器
+	breakers                *circuit.Group              // 上游服务（及节点）熔断器
+	retryBudgets            map[string]*retryBudget     // 服务名 -> 重试预算
+	transports              *upstreamTransports         // 上游连接池
+	reverseProxies          sync.Map                    // 节点地址 -> 缓存的反向代理
+	buffers                 *proxyBufferPool            // 转发响应体的缓冲区
+	acl                     *ACL                        // 黑白名单与受限路径
+	limiter                 atomic.Pointer[RateLimiter] // 分布式限流器
+	cache                   *ResponseCache              // 响应缓存
+	streams                 *StreamLimiter              // WebSocket、SSE 长连接管理
+	grpc                    *GRPCTranscoder             // HTTP/JSON 转 gRPC
+	canary                  *Canary                     // 灰度发布规则
+	apiKeys                 *APIKeys                    // 机器客户端 API Key
+	signatures              *SignatureVerifier          // 合作方请求验签
+	plugins                 atomic.Pointer[[]Plugin]    // 全局插件（按执行顺序）
+	configuredPlugins       []orderedPlugin             // gateway.plugins 中的全局插件
+	extraPlugins            []Plugin                    // 通过 Use 追加的全局插件
+	EnableBlacklist         bool                        // 是否启用黑名单检查
+	EnableWhitelist         bool                        // 是否启用白名单检查
+	EnableRestrictedRoutes  bool                        // 是否启用受限路径检查
+	EnableRateLimiting      bool                        // 是否启用速率限制
+	RateLimitRequestsPerSec int                         // 未配置限流策略时，每个 IP 每秒允许的请求次数
 }
 
 // NewProxy 构造函数，初始化代理
-func NewProxy() *Proxy {
+func NewProxy(redisClient *cache.RedisClient) *Proxy {
 	p := &Proxy{
 		services:                make(map[string][]*WeightedNode),
-		blacklist:               make(map[string]bool),
-		whitelist:               make(map[string]bool),
-		restrictedRoutes:        make(map[string][]string),
+		staticServices:          make(map[string][]*WeightedNode),
+		nodeVersions:            make(map[string]uint64),
+		health:                  NewHealthChecker(config.GetConfig().Gateway.HealthCheck),
+		balancers:               make(map[string]Balancer),
+		breakers:                circuit.NewGroup(breakerSettings),
+		retryBudgets:            make(map[string]*retryBudget),
+		transports:              newUpstreamTransports(config.GetConfig().Gateway.Transport),
+		buffers:                 newProxyBufferPool(),
+		acl:                     NewACL(redisClient, config.GetConfig().Gateway.ACL),
+		canary:                  NewCanary(redisClient, config.GetConfig().Gateway.Canary),
+		apiKeys:                 NewAPIKeys(redisClient),
+		signatures:              NewSignatureVerifier(redisClient),
+		cache:                   NewResponseCache(redisClient),
+		streams:                 NewStreamLimiter(config.GetConfig().Gateway.Streaming),
 		EnableBlacklist:         true,
 		EnableWhitelist:         false,
 		EnableRestrictedRoutes:  true,
 		EnableRateLimiting:      true,
 		RateLimitRequestsPerSec: 5, // 默认每秒 5 次请求
 	}
+	p.limiter.Store(NewRateLimiter(redisClient, config.GetConfig().Gateway.RateLimit.Policies, p.RateLimitRequestsPerSec))
+	p.initPlugins()
 	p.initServices()
+	p.initRoutes()
+	p.registerMetrics()
+	p.watchConfig()
 	return p
 }
 
+// 初始化路由表，配置有误时回退到默认路由
+func (p *Proxy) initRoutes() {
+	gatewayConfig := config.GetConfig().Gateway
+	routes, err := NewRouteTable(gatewayConfig.Routes, gatewayConfig.CORS)
+	if err != nil {
+		log.Printf("路由配置无效，使用默认路由: %v", err)
+		routes, _ = NewRouteTable(nil, gatewayConfig.CORS)
+	}
+	p.routes.Store(routes)
+
+	transcoder, err := NewGRPCTranscoder(gatewayConfig.GRPCRoutes)
+	if err != nil {
+		log.Printf("gRPC 转码路由配置无效，使用默认路由: %v", err)
+		transcoder, _ = NewGRPCTranscoder(nil)
+	}
+	p.grpc = transcoder
+}
+
+// 初始化全局插件，配置有误时只使用内置插件
+func (p *Proxy) initPlugins() {
+	plugins, err := newGlobalPlugins(config.GetConfig().Gateway.Plugins)
+	if err != nil {
+		log.Printf("全局插件配置无效，忽略: %v", err)
+	}
+	p.setPlugins(plugins)
+}
+
+// 替换 gateway.plugins 中的全局插件
+func (p *Proxy) setPlugins(configured []orderedPlugin) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.configuredPlugins = configured
+	p.rebuildPlugins()
+}
+
+// 重建全局插件链：内置插件、gateway.plugins 与 Use 追加的插件按位置排序（调用方需持有锁）
+func (p *Proxy) rebuildPlugins() {
+	entries := make([]orderedPlugin, 0, len(p.configuredPlugins)+len(p.extraPlugins)+6)
+	for _, plugin := range p.builtinPlugins() {
+		entries = append(entries, orderedPlugin{plugin: plugin, order: pluginOrder(plugin, 0)})
+	}
+	entries = append(entries, p.configuredPlugins...)
+	for _, plugin := range p.extraPlugins {
+		entries = append(entries, orderedPlugin{plugin: plugin, order: pluginOrder(plugin, 0)})
+	}
+	plugins := sortPlugins(entries)
+	p.plugins.Store(&plugins)
+}
+
 // 初始化服务节点
 func (p *Proxy) initServices() {
 	p.services = make(map[string][]*WeightedNode)
+	// 静态节点与服务实际监听的端口一致：认证服务只监听 port，系统服务监听 port 与 port1
 	p.services["security"] = []*WeightedNode{
 		{addr: fmt.Sprintf("%s:%s", config.GetConfig().Security.Addr, config.GetConfig().Security.Port), weight: config.GetConfig().Security.Weight1},
-		{addr: fmt.Sprintf("%s:%s", config.GetConfig().Security.Addr, config.GetConfig().Security.Port1), weight: config.GetConfig().Security.Weight2},
 	}
 	p.services["system"] = []*WeightedNode{
 		{addr: fmt.Sprintf("%s:%s", config.GetConfig().System.Addr, config.GetConfig().System.Port), weight: config.GetConfig().System.Weight1},
-		{addr: fmt.Sprintf("%s:%s", config.GetConfig().System.Addr, config.GetConfig().System.Port), weight: config.GetConfig().System.Weight2},
+		{addr: fmt.Sprintf("%s:%s", config.GetConfig().System.Addr, config.GetConfig().System.Port1), weight: config.GetConfig().System.Weight2},
 	}
 	//p.services["order"] = []*WeightedNode{
 	//	{addr: "0.0.0.0:8085", weight: 10},
@@ -69,185 +161,312 @@ func (p *Proxy) initServices() {
 	p.services["default"] = []*WeightedNode{
 		{addr: config.GetConfig().Default.Addr, weight: config.GetConfig().Default.Weight},
 	}
-
-	// 添加黑名单示例
-	p.blacklist["192.168.1.100"] = true
-
-	// 添加白名单示例（若为空，则默认所有 IP 可访问 -- 127.0.0.1 或者 IPV6 的 localhost == ::1 也会直接默认通过）
-	p.whitelist["::1"] = true
-	p.whitelist["127.0.0.1"] = true
-	p.whitelist["192.168.10.6"] = true
-
-	// 限制 `/admin` 只允许 `192.168.1.50` 访问
-	p.restrictedRoutes["/admin"] = []string{"192.168.1.50"}
+	for name, nodes := range p.services {
+		p.staticServices[name] = nodes
+	}
 }
 
-// NewHttpReverseProxy 创建一个新的反向代理
-func (p *Proxy) NewHttpReverseProxy(target *url.URL) *httputil.ReverseProxy {
-	return httputil.NewSingleHostReverseProxy(target)
+// ACL 返回网关访问控制列表
+func (p *Proxy) ACL() *ACL {
+	return p.acl
 }
 
-// 根据请求路径选择服务节点
-func (p *Proxy) findService(r *http.Request) *WeightedNode {
-	path := r.URL.Path
-	serviceMap := map[string]string{
-		"/security": "security",
-		"/system":   "system",
-		"/order":    "order",
+// SetServiceNodes 替换指定服务的节点列表（由服务发现调用）
+// 节点为空时回退到配置文件中的静态节点，没有静态节点则移除该服务
+func (p *Proxy) SetServiceNodes(service string, nodes []*WeightedNode) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	// 下线节点的指标随之删除，避免服务发现频繁变化时时间序列无限增长
+	previous := p.services[service]
+	defer func() { forgetRemovedNodes(service, previous, p.services[service]) }()
+	p.nodeVersions[service]++
+
+	if len(nodes) == 0 {
+		if static, ok := p.staticServices[service]; ok {
+			p.services[service] = static
+			log.Printf("服务 %s 无可用实例，回退到静态节点", service)
+			return
+		}
+		delete(p.services, service)
+		p.pruneReverseProxies()
+		log.Printf("服务 %s 已下线", service)
+		return
 	}
 
-	for prefix, service := range serviceMap {
-		if strings.HasPrefix(path, prefix) {
-			return p.getServiceForPath(service)
+	p.services[service] = nodes
+	p.pruneReverseProxies()
+	addrs := make([]string, 0, len(nodes))
+	for _, node := range nodes {
+		if node.version != "" {
+			addrs = append(addrs, fmt.Sprintf("%s(%d,%s)", node.addr, node.weight, node.version))
+			continue
 		}
+		addrs = append(addrs, fmt.Sprintf("%s(%d)", node.addr, node.weight))
 	}
-	return p.getServiceForPath("default")
+	log.Printf("服务 %s 节点更新: %s", service, strings.Join(addrs, ", "))
+}
+
+// NewHttpReverseProxy 创建一个新的反向代理（ServeHTTP 按节点缓存，见 reverseProxyFor）
+func (p *Proxy) NewHttpReverseProxy(target *url.URL) *httputil.ReverseProxy {
+	return httputil.NewSingleHostReverseProxy(target)
 }
 
-// 获取指定路径的服务节点
-func (p *Proxy) getServiceForPath(path string) *WeightedNode {
+// 获取指定服务的节点，version 为请求分配到的灰度版本（空表示稳定版本），跳过 tried 中已尝试过的节点
+func (p *Proxy) getServiceForPath(service, version string, r *http.Request, tried map[string]bool) *WeightedNode {
 	p.mu.Lock()
 	defer p.mu.Unlock()
-	if nodes, ok := p.services[path]; ok && len(nodes) > 0 {
-		return p.getTargetService(nodes)
+	nodes := p.canaryNodes(service, version, p.services[service])
+	if len(tried) > 0 {
+		untried := make([]*WeightedNode, 0, len(nodes))
+		for _, node := range nodes {
+			if !tried[node.addr] {
+				untried = append(untried, node)
+			}
+		}
+		nodes = untried
 	}
-	return nil
+	if len(nodes) == 0 {
+		return nil
+	}
+	return p.getTargetService(service, nodes, r)
 }
 
-// 负载均衡逻辑，使用权重选择服务节点
-func (p *Proxy) getTargetService(nodes []*WeightedNode) *WeightedNode {
-	totalWeight := 0
+// 负载均衡逻辑，按健康状态调整后的权重筛选候选节点（跳过节点熔断器会拒绝的节点），再交给服务配置的均衡器选择
+func (p *Proxy) getTargetService(service string, nodes []*WeightedNode, r *http.Request) *WeightedNode {
+	candidates := make([]*WeightedNode, 0, len(nodes))
+	weights := make([]int, 0, len(nodes))
 	for _, node := range nodes {
-		totalWeight += node.weight
+		if !p.nodeReady(service, node) {
+			continue
+		}
+		if weight := p.health.EffectiveWeight(node); weight > 0 {
+			candidates = append(candidates, node)
+			weights = append(weights, weight)
+		}
 	}
 
-	randWeight := rand.Intn(totalWeight)
-	for _, node := range nodes {
-		if randWeight < node.weight {
-			return node
+	// 所有节点都被摘除时按原始权重选择，避免整个服务不可用
+	if len(candidates) == 0 {
+		for _, node := range nodes {
+			weight := node.weight
+			if weight <= 0 {
+				weight = 1
+			}
+			candidates = append(candidates, node)
+			weights = append(weights, weight)
 		}
-		randWeight -= node.weight
 	}
-	return nodes[len(nodes)-1] // 兜底，防止异常
-}
 
-// 获取真实ip
-func getClientIP(r *http.Request) string {
-	// 检查 X-Forwarded-For 头
-	forwarded := r.Header.Get("X-Forwarded-For")
-	if forwarded != "" {
-		ips := strings.Split(forwarded, ",")
-		return strings.TrimSpace(ips[0]) // 取第一个 IP
+	balancer := p.balancerFor(service)
+	// 一致性哈希按服务的全部节点构建哈希环，节点被摘除或已尝试时只在查找时跳过，避免哈希环随之重建
+	if hashBalancer, ok := balancer.(*consistentHashBalancer); ok {
+		return hashBalancer.PickFrom(p.services[service], p.nodeVersions[service], candidates, r)
 	}
+	return balancer.Pick(candidates, weights, r)
+}
 
-	// 检查 X-Real-IP 头
-	realIP := r.Header.Get("X-Real-IP")
-	if realIP != "" {
-		return realIP
+// 获取服务的负载均衡器（调用方需持有锁）
+func (p *Proxy) balancerFor(service string) Balancer {
+	balancer, ok := p.balancers[service]
+	if !ok {
+		balancer = NewBalancer(config.GetConfig().Gateway.Services[service])
+		p.balancers[service] = balancer
 	}
+	return balancer
+}
 
-	// 默认回退到 RemoteAddr
+// 请求结束时通知负载均衡器
+func (p *Proxy) releaseNode(service string, node *WeightedNode) {
+	p.mu.Lock()
+	balancer := p.balancerFor(service)
+	p.mu.Unlock()
+	balancer.Done(node)
+}
+
+// 请求上下文中保存解析后客户端 IP 的 key
+type clientIPKey struct{}
+
+// 获取真实ip：使用 ServeHTTP 按可信代理链解析的结果，未解析时回退到直连地址
+func getClientIP(r *http.Request) string {
+	if ip, ok := r.Context().Value(clientIPKey{}).(string); ok {
+		return ip
+	}
 	ip, _, err := net.SplitHostPort(r.RemoteAddr)
 	if err != nil {
-		fmt.Println("解析 RemoteAddr 失败:", err)
-		return ""
+		return r.RemoteAddr
 	}
 	return ip
 }
 
 // 处理请求并转发
 func (p *Proxy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
-	clientIP := getClientIP(r)
-
-	// 先添加 CORS 头部（即使后面拦截请求也要带 CORS 头）
-	p.handleCORS(w)
-
-	// 处理 OPTIONS 预检请求（直接返回 200）
-	if r.Method == "OPTIONS" {
-		w.WriteHeader(http.StatusOK)
+	// 之后的匹配与转发都使用规范化后的路径
+	r = withCleanPath(r)
+	// 按可信代理链解析客户端 IP，并通过 X-Real-IP 传给下游服务（覆盖客户端自带的值）
+	clientAddr := utils.ResolveClientIP(r.RemoteAddr, r.Header, utils.TrustedProxies(), utils.TrustedForwardedHeader())
+	clientIP := ""
+	if clientAddr.IsValid() {
+		clientIP = clientAddr.String()
+	}
+	// 请求 ID：经过 TracingMiddleware 时沿用其结果，单独使用代理时在这里读取或生成，并通过响应头返回
+	ctx := r.Context()
+	if tracing.RequestID(ctx) == "" {
+		ctx = tracing.Extract(ctx, tracing.HeaderCarrier(r.Header))
+	}
+	ctx, requestID := tracing.EnsureRequestID(ctx)
+	w.Header().Set(tracing.RequestIDHeader, requestID)
+	r = r.WithContext(context.WithValue(ctx, clientIPKey{}, clientIP))
+	r.Header.Del(utils.RealIPHeader)
+	if clientIP != "" {
+		r.Header.Set(utils.RealIPHeader, clientIP)
+	}
+	// 身份请求头只能由网关注入：删除客户端自带的，转发前（路径改写之后）再写入签名
+	identity.Strip(r.Header)
+
+	// 插件：全局插件（内置的跨域、访问控制、认证、限流，以及 gateway.plugins 与 Use 追加的插件，按 order 排序）在匹配路由之前执行，
+	// 路由插件在匹配路由之后执行；响应阶段在写出响应头之前按相反顺序执行
+	plugins := &PluginContext{Request: r, ClientIP: clientIP, ClientAddr: clientAddr, Streaming: utils.IsStreamingRequest(r)}
+	defer plugins.finish()
+	w = &pluginWriter{ResponseWriter: w, ctx: plugins}
+	plugins.Writer = w
+	if !plugins.run(*p.plugins.Load()) {
 		return
 	}
+	r = plugins.Request
 
-	// 黑名单检查
-	if p.EnableBlacklist && p.blacklist[clientIP] {
-		http.Error(w, "403 Forbidden - 黑名单", http.StatusForbidden)
+	// HTTP/JSON 转 gRPC
+	if grpcRoute, params := p.grpc.Match(r); grpcRoute != nil {
+		p.grpc.Serve(w, r, grpcRoute, params)
 		return
 	}
 
-	// 白名单检查
-	if p.EnableWhitelist && len(p.whitelist) > 0 && !p.whitelist[clientIP] {
-		http.Error(w, "403 Forbidden - 不在白名单", http.StatusForbidden)
+	// 长连接（WebSocket、SSE）：限制每个 IP 的连接数，使用空闲超时代替路由超时
+	streaming := plugins.Streaming
+	if streaming {
+		release, ok := p.streams.Acquire(clientIP)
+		if !ok {
+			http.Error(w, "429 长连接数过多", http.StatusTooManyRequests)
+			return
+		}
+		defer release()
+
+		var stop func()
+		w, r, stop = p.streams.Wrap(w, r)
+		defer stop()
+	}
+
+	// 匹配路由，执行路由插件
+	route := p.MatchRoute(r)
+	plugins.Writer, plugins.Request, plugins.Route = w, r, route
+	if !plugins.run(route.plugins) {
 		return
 	}
+	r = plugins.Request
 
-	// 访问策略检查
-	if p.EnableRestrictedRoutes {
-		if allowedIPs, ok := p.restrictedRoutes[r.URL.Path]; ok {
-			allowed := false
-			for _, ip := range allowedIPs {
-				if ip == clientIP {
-					allowed = true
-					break
-				}
-			}
-			if !allowed {
-				http.Error(w, "403 Forbidden - 受限路径", http.StatusForbidden)
-				return
-			}
+	// 灰度分流：确定请求使用的版本，同一用户（或浏览器）始终落在同一版本
+	version := p.canary.Assign(w, r, route.Service)
+
+	// 响应缓存：命中时直接返回，不再访问上游（长连接、灰度请求不缓存）
+	var cacheReq *cacheRequest
+	if !streaming && version == "" {
+		var served bool
+		if cacheReq, served = p.cache.Lookup(w, r, route); served {
+			return
 		}
 	}
 
-	// 流量限制检查
-	if p.EnableRateLimiting && !p.rateLimitCheck(clientIP) {
-		http.Error(w, "429 请求速度过快", http.StatusTooManyRequests)
+	// 申请服务熔断器的放行并选择目标服务节点（同时确定请求能否重试）
+	call := p.newUpstreamCall(route, version, r, streaming)
+	call.cacheReq = cacheReq
+	defer call.finish()
+	if err := call.begin(); err != nil {
+		writeJSON(w, http.StatusServiceUnavailable, utils.Response{Code: http.StatusServiceUnavailable, Message: "服务熔断中"})
 		return
 	}
-
-	// 选择目标服务
-	route := p.findService(r)
-	if route == nil {
+	node, done, err := call.acquire(r)
+	if err != nil {
+		writeJSON(w, http.StatusServiceUnavailable, utils.Response{Code: http.StatusServiceUnavailable, Message: "服务熔断中"})
+		return
+	}
+	if node == nil {
 		http.NotFound(w, r)
 		return
 	}
-
-	// 代理请求
-	target := &url.URL{
-		Scheme: "http",
-		Host:   route.addr,
+	call.use(node, done)
+
+	// 转发调用段，上游服务的调用段作为其子调用段
+	ctx, span := tracing.StartSpan(r.Context(), "proxy "+route.Service, tracing.SpanKindClient)
+	defer span.End()
+	span.SetAttribute("gateway.route", route.Name)
+	span.SetAttribute("net.peer.name", node.addr)
+	if version != "" {
+		span.SetAttribute("gateway.canary_version", version)
+	}
+	call.span = span
+	r = r.WithContext(context.WithValue(ctx, upstreamCallKey{}, call))
+
+	// 路径改写
+	if upstreamPath := route.UpstreamPath(r.URL.Path); upstreamPath != r.URL.Path {
+		r = r.Clone(r.Context())
+		r.URL.Path = upstreamPath
+		r.URL.RawPath = ""
+	}
+	tracing.Inject(r.Context(), tracing.HeaderCarrier(r.Header))
+	// 签名覆盖请求方法、转发路径、查询串与请求体；已通过认证的请求同时写入用户身份，其余请求只证明经由网关转发
+	id, _ := identity.FromContext(r.Context())
+	if err := identity.Sign(r, id, time.Now()); err != nil {
+		code := http.StatusBadRequest
+		if errors.Is(err, identity.ErrBodyTooLarge) {
+			code = http.StatusRequestEntityTooLarge
+		}
+		call.span.SetHTTPStatus(code)
+		writeJSON(w, code, utils.Response{Code: code, Message: err.Error()})
+		return
 	}
-	proxy := p.NewHttpReverseProxy(target)
-	proxy.ServeHTTP(w, r)
-}
 
-// CORS 处理
-func (p *Proxy) handleCORS(w http.ResponseWriter) {
-	w.Header().Set("Access-Control-Allow-Origin", "*") // 允许所有来源
-	w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-	w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
-	w.Header().Set("Access-Control-Allow-Credentials", "true") // 允许携带认证信息（如果需要）
-	w.WriteHeader(http.StatusOK)
+	// 代理请求：使用节点缓存的反向代理，节点失败统计与重试由 upstreamCall 完成
+	call.start = time.Now()
+	proxy := p.reverseProxyFor(node.addr)
+	proxy.ServeHTTP(w, r)
 }
 
-// 访问限制（流量控制）
-func (p *Proxy) rateLimitCheck(ip string) bool {
-	val, _ := p.rateLimit.LoadOrStore(ip, &sync.Mutex{})
-	mu := val.(*sync.Mutex)
+// 处理上游响应：去掉上游的跨域响应头，记录结果，需要时写入响应缓存
+func (p *Proxy) modifyResponse(resp *http.Response) error {
+	call := upstreamCallFromContext(resp.Request.Context())
+	stripUpstreamCORSHeaders(resp.Header)
+	call.annotate()
+	call.span.SetHTTPStatus(resp.StatusCode)
+	recordUpstreamRequest(call.service, call.route.Name, resp.StatusCode, call.start)
 
-	mu.Lock()
-	defer mu.Unlock()
-
-	// 每秒请求次数
-	windowKey := fmt.Sprintf("%s-%d", ip, time.Now().Unix())
-	if _, exists := p.rateLimit.Load(windowKey); exists {
-		return false
+	if call.cacheReq != nil {
+		return p.cache.Store(resp, call.cacheReq)
 	}
-	p.rateLimit.Store(windowKey, struct{}{})
+	return nil
+}
 
-	// 1秒后清理
-	go func() {
-		time.Sleep(1 * time.Second)
-		p.rateLimit.Delete(windowKey)
-	}()
+// 代理失败时返回统一的 JSON 响应：路由超时返回 504，请求体超过 body_limit 插件的上限返回 413，其余返回 502
+func (p *Proxy) handleProxyError(w http.ResponseWriter, r *http.Request, err error) {
+	call := upstreamCallFromContext(r.Context())
+	log.Printf("代理请求 %s 失败 [%s]: %v", call.node.addr, tracing.RequestID(r.Context()), err)
+	call.annotate()
+	call.span.SetError(err)
+	recordUpstreamRequest(call.service, call.route.Name, 0, call.start)
+
+	code, message := http.StatusBadGateway, "上游服务不可用"
+	var maxBytesErr *http.MaxBytesError
+	switch {
+	case errors.Is(r.Context().Err(), context.DeadlineExceeded):
+		code, message = http.StatusGatewayTimeout, "上游服务响应超时"
+	case errors.As(err, &maxBytesErr):
+		code, message = http.StatusRequestEntityTooLarge, fmt.Sprintf("请求体超过 %d 字节", maxBytesErr.Limit)
+	}
+	call.span.SetHTTPStatus(code)
+	writeJSON(w, code, utils.Response{Code: code, Message: message})
+}
 
-	return true
+// 写出 JSON 响应
+func writeJSON(w http.ResponseWriter, code int, response utils.Response) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(response)
 }
@@ -0,0 +1,22 @@
+package proxy
+
+import (
+	"os"
+	"sky_ISService/config"
+	"testing"
+)
+
+func TestMain(m *testing.M) {
+	config.Use(&config.InitStructureConfig{})
+	os.Exit(m.Run())
+}
+
+// 测试期间使用修改后的配置，测试结束后恢复
+func useTestConfig(t *testing.T, modify func(c *config.InitStructureConfig)) {
+	t.Helper()
+	prev := config.GetConfig()
+	next := *prev
+	modify(&next)
+	config.Use(&next)
+	t.Cleanup(func() { config.Use(prev) })
+}
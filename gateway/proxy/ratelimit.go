@@ -0,0 +1,247 @@
+package proxy
+
+import (
+	"context"
+	"fmt"
+	"github.com/go-redis/redis/v8"
+	"log"
+	"math/rand"
+	"net/http"
+	"sky_ISService/config"
+	"sky_ISService/pkg/identity"
+	"sky_ISService/shared/cache"
+	"strconv"
+	"time"
+)
+
+// 限流维度
+const (
+	RateLimitByIP     = "ip"      // 客户端 IP
+	RateLimitByAPIKey = "api_key" // 认证通过的 API Key（X-API-Key）
+	RateLimitByUser   = "user"    // 网关认证后的用户身份（JWT、API Key、签名应用）
+	RateLimitByRoute  = "route"   // 路由前缀（所有调用方共享额度）
+)
+
+// 限流算法
+const (
+	TokenBucket   = "token_bucket"   // 令牌桶，允许突发
+	SlidingWindow = "sliding_window" // 滑动窗口，严格限制窗口内请求数
+)
+
+const (
+	rateLimitKeyPrefix = "gateway:ratelimit:"
+	rateLimitTimeout   = 200 * time.Millisecond // Redis 限流操作超时，超时放行
+)
+
+// 令牌桶脚本：按流逝时间补充令牌，返回 {是否放行, 剩余令牌, 重试等待毫秒, 桶满所需毫秒}
+var tokenBucketScript = redis.NewScript(`
+local rate = tonumber(ARGV[1])
+local capacity = tonumber(ARGV[2])
+local now = tonumber(ARGV[3])
+local data = redis.call('HMGET', KEYS[1], 'tokens', 'ts')
+local tokens = tonumber(data[1])
+local ts = tonumber(data[2])
+if tokens == nil then
+  tokens = capacity
+  ts = now
+end
+tokens = math.min(capacity, tokens + math.max(0, now - ts) * rate)
+local allowed = 0
+local retry = 0
+if tokens >= 1 then
+  tokens = tokens - 1
+  allowed = 1
+else
+  retry = math.ceil((1 - tokens) / rate)
+end
+redis.call('HSET', KEYS[1], 'tokens', tokens, 'ts', now)
+local reset = math.ceil((capacity - tokens) / rate)
+redis.call('PEXPIRE', KEYS[1], reset + 1000)
+return {allowed, math.floor(tokens), retry, reset}
+`)
+
+// 滑动窗口脚本：有序集合记录窗口内的请求时间，返回 {是否放行, 剩余次数, 重试等待毫秒, 窗口重置毫秒}
+var slidingWindowScript = redis.NewScript(`
+local now = tonumber(ARGV[1])
+local window = tonumber(ARGV[2])
+local limit = tonumber(ARGV[3])
+redis.call('ZREMRANGEBYSCORE', KEYS[1], 0, now - window)
+local count = redis.call('ZCARD', KEYS[1])
+local oldest = redis.call('ZRANGE', KEYS[1], 0, 0, 'WITHSCORES')
+local reset = window
+if oldest[2] then
+  reset = window - (now - tonumber(oldest[2]))
+end
+if count < limit then
+  redis.call('ZADD', KEYS[1], now, ARGV[4])
+  redis.call('PEXPIRE', KEYS[1], window)
+  return {1, limit - count - 1, 0, reset}
+end
+return {0, 0, reset, reset}
+`)
+
+// RateLimitResult 限流结果
+type RateLimitResult struct {
+	Allowed    bool          // 是否放行
+	Policy     string        // 命中的策略
+	Limit      int           // 周期内的请求上限
+	Period     time.Duration // 周期
+	Remaining  int           // 剩余额度
+	Reset      time.Duration // 额度完全恢复所需时间
+	RetryAfter time.Duration // 被拒绝时建议的重试等待时间
+}
+
+// WriteHeaders 写入标准 RateLimit-* 响应头，被拒绝时附带 Retry-After
+func (res *RateLimitResult) WriteHeaders(w http.ResponseWriter) {
+	w.Header().Set("RateLimit-Limit", strconv.Itoa(res.Limit))
+	w.Header().Set("RateLimit-Remaining", strconv.Itoa(res.Remaining))
+	w.Header().Set("RateLimit-Reset", strconv.Itoa(ceilSeconds(res.Reset)))
+	w.Header().Set("RateLimit-Policy", fmt.Sprintf("%d;w=%d", res.Limit, ceilSeconds(res.Period)))
+	if !res.Allowed {
+		w.Header().Set("Retry-After", strconv.Itoa(ceilSeconds(res.RetryAfter)))
+	}
+}
+
+// RateLimiter 基于 Redis 的分布式限流器，多个网关实例共享额度
+type RateLimiter struct {
+	redisClient *cache.RedisClient
+	policies    []config.RateLimitPolicy
+}
+
+// NewRateLimiter 创建限流器，未配置策略时使用按 IP 每秒 defaultPerSecond 次的默认策略
+func NewRateLimiter(redisClient *cache.RedisClient, policies []config.RateLimitPolicy, defaultPerSecond int) *RateLimiter {
+	if len(policies) == 0 {
+		policies = []config.RateLimitPolicy{{Name: "default", KeyBy: RateLimitByIP, Limit: defaultPerSecond}}
+	}
+
+	normalized := make([]config.RateLimitPolicy, 0, len(policies))
+	for i, policy := range policies {
+		if policy.Limit <= 0 {
+			log.Printf("限流策略 %s 未配置 limit，已忽略", policy.Name)
+			continue
+		}
+		if policy.Name == "" {
+			policy.Name = fmt.Sprintf("policy%d", i)
+		}
+		if policy.KeyBy == "" {
+			policy.KeyBy = RateLimitByIP
+		}
+		if policy.Algorithm == "" {
+			policy.Algorithm = TokenBucket
+		}
+		if policy.Period <= 0 {
+			policy.Period = time.Second
+		}
+		if policy.Burst <= 0 {
+			policy.Burst = policy.Limit
+		}
+		normalized = append(normalized, policy)
+	}
+	return &RateLimiter{redisClient: redisClient, policies: normalized}
+}
+
+// 使用新的策略创建限流器（配置文件重新加载时调用），共用 Redis 客户端
+func (l *RateLimiter) withPolicies(policies []config.RateLimitPolicy, defaultPerSecond int) *RateLimiter {
+	return NewRateLimiter(l.redisClient, policies, defaultPerSecond)
+}
+
+// Allow 依次检查所有命中的策略，任一策略拒绝即拒绝；返回剩余额度最少的结果用于响应头
+// Redis 不可用时放行（fail open），避免限流组件拖垮网关
+func (l *RateLimiter) Allow(r *http.Request, clientIP string) *RateLimitResult {
+	if l == nil || l.redisClient == nil {
+		return nil
+	}
+
+	var strictest *RateLimitResult
+	for _, policy := range l.policies {
+		if policy.RoutePrefix != "" && !matchPathPrefix(policy.RoutePrefix, r.URL.Path) {
+			continue
+		}
+
+		result, err := l.check(r.Context(), policy, rateLimitIdentity(policy, r, clientIP))
+		if err != nil {
+			log.Printf("限流策略 %s 检查失败，放行请求: %v", policy.Name, err)
+			continue
+		}
+		if !result.Allowed {
+			return result
+		}
+		if strictest == nil || result.Remaining < strictest.Remaining {
+			strictest = result
+		}
+	}
+	return strictest
+}
+
+// 执行单个策略的限流脚本
+func (l *RateLimiter) check(ctx context.Context, policy config.RateLimitPolicy, identity string) (*RateLimitResult, error) {
+	ctx, cancel := context.WithTimeout(ctx, rateLimitTimeout)
+	defer cancel()
+
+	key := rateLimitKeyPrefix + policy.Name + ":" + identity
+	now := time.Now().UnixMilli()
+	periodMs := policy.Period.Milliseconds()
+
+	var values []interface{}
+	var err error
+	switch policy.Algorithm {
+	case SlidingWindow:
+		member := fmt.Sprintf("%d-%d", now, rand.Int63())
+		values, err = slidingWindowScript.Run(ctx, l.redisClient.Client, []string{key}, now, periodMs, policy.Limit, member).Slice()
+	default:
+		rate := float64(policy.Limit) / float64(periodMs) // 每毫秒补充的令牌数
+		values, err = tokenBucketScript.Run(ctx, l.redisClient.Client, []string{key}, rate, policy.Burst, now).Slice()
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(values) != 4 {
+		return nil, fmt.Errorf("限流脚本返回值异常: %v", values)
+	}
+
+	limit := policy.Limit
+	if policy.Algorithm != SlidingWindow {
+		limit = policy.Burst
+	}
+	return &RateLimitResult{
+		Allowed:    toInt64(values[0]) == 1,
+		Policy:     policy.Name,
+		Limit:      limit,
+		Period:     policy.Period,
+		Remaining:  int(toInt64(values[1])),
+		RetryAfter: time.Duration(toInt64(values[2])) * time.Millisecond,
+		Reset:      time.Duration(toInt64(values[3])) * time.Millisecond,
+	}, nil
+}
+
+// 根据策略维度计算限流标识，只使用网关认证通过的 API Key 与用户身份，未认证时回退到客户端 IP，
+// 避免公开路由上携带随机的 Token 或 Key 即可获得新的计数
+func rateLimitIdentity(policy config.RateLimitPolicy, r *http.Request, clientIP string) string {
+	switch policy.KeyBy {
+	case RateLimitByAPIKey:
+		if key, ok := APIKeyFromContext(r.Context()); ok {
+			return "key:" + key.ID
+		}
+	case RateLimitByUser:
+		if id, ok := identity.FromContext(r.Context()); ok && id.UserID != "" {
+			return "user:" + id.UserID
+		}
+	case RateLimitByRoute:
+		return "route:" + policy.RoutePrefix
+	}
+	return "ip:" + clientIP
+}
+
+// Redis 脚本返回的整数
+func toInt64(value interface{}) int64 {
+	number, _ := value.(int64)
+	return number
+}
+
+// 向上取整到秒
+func ceilSeconds(d time.Duration) int {
+	if d <= 0 {
+		return 0
+	}
+	return int((d + time.Second - 1) / time.Second)
+}
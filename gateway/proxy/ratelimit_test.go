@@ -0,0 +1,164 @@
+package proxy
+
+import (
+	"context"
+	"net/http/httptest"
+	"sky_ISService/config"
+	"sky_ISService/pkg/identity"
+	"sky_ISService/shared/cache"
+	"testing"
+	"time"
+)
+
+// 使用进程内 Redis 的限流器
+func newTestRateLimiter(t *testing.T, policies []config.RateLimitPolicy) *RateLimiter {
+	t.Helper()
+	redisClient, err := cache.NewMemoryRedis()
+	if err != nil {
+		t.Fatalf("启动进程内 Redis 失败: %v", err)
+	}
+	t.Cleanup(func() { _ = redisClient.Close() })
+	return NewRateLimiter(redisClient, policies, 100)
+}
+
+func TestRateLimitScripts(t *testing.T) {
+	tests := []struct {
+		name          string
+		policy        config.RateLimitPolicy
+		requests      int
+		wantAllowed   int
+		wantLimit     int
+		wantRemaining []int // 放行请求依次剩余的额度
+	}{
+		{
+			name:          "令牌桶按突发容量放行",
+			policy:        config.RateLimitPolicy{Name: "bucket", Algorithm: TokenBucket, Limit: 1, Period: time.Second, Burst: 3},
+			requests:      5,
+			wantAllowed:   3,
+			wantLimit:     3,
+			wantRemaining: []int{2, 1, 0},
+		},
+		{
+			name:          "令牌桶未配置突发容量时等于上限",
+			policy:        config.RateLimitPolicy{Name: "bucket", Limit: 2, Period: time.Minute},
+			requests:      3,
+			wantAllowed:   2,
+			wantLimit:     2,
+			wantRemaining: []int{1, 0},
+		},
+		{
+			name:          "滑动窗口严格限制窗口内请求数",
+			policy:        config.RateLimitPolicy{Name: "window", Algorithm: SlidingWindow, Limit: 3, Period: time.Minute, Burst: 10},
+			requests:      5,
+			wantAllowed:   3,
+			wantLimit:     3,
+			wantRemaining: []int{2, 1, 0},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			limiter := newTestRateLimiter(t, []config.RateLimitPolicy{tt.policy})
+			policy := limiter.policies[0]
+
+			allowed := 0
+			for i := 0; i < tt.requests; i++ {
+				result, err := limiter.check(context.Background(), policy, "ip:192.0.2.1")
+				if err != nil {
+					t.Fatalf("执行限流脚本失败: %v", err)
+				}
+				if result.Limit != tt.wantLimit {
+					t.Fatalf("上限为 %d，期望 %d", result.Limit, tt.wantLimit)
+				}
+				if !result.Allowed {
+					if result.RetryAfter <= 0 || result.RetryAfter > policy.Period {
+						t.Fatalf("被拒绝时重试等待 %s 超出周期 %s", result.RetryAfter, policy.Period)
+					}
+					continue
+				}
+				if result.Remaining != tt.wantRemaining[allowed] {
+					t.Fatalf("第 %d 次放行剩余 %d，期望 %d", allowed+1, result.Remaining, tt.wantRemaining[allowed])
+				}
+				allowed++
+			}
+			if allowed != tt.wantAllowed {
+				t.Fatalf("放行 %d 次，期望 %d 次", allowed, tt.wantAllowed)
+			}
+
+			// 不同标识的额度互不影响
+			result, err := limiter.check(context.Background(), policy, "ip:192.0.2.2")
+			if err != nil || !result.Allowed {
+				t.Fatalf("其他标识被拒绝: %+v（%v）", result, err)
+			}
+		})
+	}
+}
+
+func TestRateLimiterAllow(t *testing.T) {
+	limiter := newTestRateLimiter(t, []config.RateLimitPolicy{
+		{Name: "global", Limit: 100, Period: time.Minute},
+		{Name: "orders", RoutePrefix: "/system/api/v1/orders", Limit: 1, Period: time.Minute},
+	})
+
+	tests := []struct {
+		name        string
+		path        string
+		wantAllowed bool
+		wantPolicy  string
+	}{
+		{name: "只命中全局策略", path: "/system/api/v1/users", wantAllowed: true, wantPolicy: "global"},
+		{name: "返回剩余额度最少的策略", path: "/system/api/v1/orders/1", wantAllowed: true, wantPolicy: "orders"},
+		{name: "任一策略拒绝即拒绝", path: "/system/api/v1/orders", wantPolicy: "orders"},
+		{name: "前缀按路径段匹配", path: "/system/api/v1/orders-archive", wantAllowed: true, wantPolicy: "global"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("GET", tt.path, nil)
+			result := limiter.Allow(r, "192.0.2.1")
+			if result == nil {
+				t.Fatal("未返回限流结果")
+			}
+			if result.Allowed != tt.wantAllowed || result.Policy != tt.wantPolicy {
+				t.Fatalf("结果 %v（策略 %s），期望 %v（策略 %s）", result.Allowed, result.Policy, tt.wantAllowed, tt.wantPolicy)
+			}
+		})
+	}
+}
+
+func TestRateLimitIdentity(t *testing.T) {
+	tests := []struct {
+		name   string
+		keyBy  string
+		prefix string
+		key    *APIKey
+		userID string
+		want   string
+	}{
+		{name: "按 IP", keyBy: RateLimitByIP, want: "ip:192.0.2.1"},
+		{name: "按 API Key", keyBy: RateLimitByAPIKey, key: &APIKey{ID: "k1"}, want: "key:k1"},
+		{name: "未认证的 API Key 回退到 IP", keyBy: RateLimitByAPIKey, want: "ip:192.0.2.1"},
+		{name: "按用户", keyBy: RateLimitByUser, userID: "42", want: "user:42"},
+		{name: "未认证的用户回退到 IP", keyBy: RateLimitByUser, want: "ip:192.0.2.1"},
+		{name: "按路由", keyBy: RateLimitByRoute, prefix: "/system", want: "route:/system"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("GET", "/system/api/v1/users", nil)
+			// 未经网关认证的请求头不参与限流标识
+			r.Header.Set(APIKeyHeader, "sk_forged")
+			r.Header.Set("Authorization", "Bearer forged")
+			ctx := r.Context()
+			if tt.key != nil {
+				ctx = WithAPIKey(ctx, tt.key)
+			}
+			if tt.userID != "" {
+				ctx = identity.WithIdentity(ctx, &identity.Identity{UserID: tt.userID})
+			}
+			r = r.WithContext(ctx)
+
+			policy := config.RateLimitPolicy{KeyBy: tt.keyBy, RoutePrefix: tt.prefix}
+			if got := rateLimitIdentity(policy, r, "192.0.2.1"); got != tt.want {
+				t.Fatalf("得到 %s，期望 %s", got, tt.want)
+			}
+		})
+	}
+}
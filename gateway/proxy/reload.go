@@ -0,0 +1,109 @@
+package proxy
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"reflect"
+	"sky_ISService/config"
+)
+
+// 注册网关配置校验并订阅配置变更：路由表（含 CORS）、全局插件、ACL、限流策略、熔断配置、灰度规则、长连接配置在配置文件修改后立即生效，
+// 服务节点、连接池、gRPC 转码路由等仍需重启
+func (p *Proxy) watchConfig() {
+	config.RegisterValidator(ValidateConfig)
+	config.Subscribe(p.applyConfig)
+}
+
+// ValidateConfig 校验配置能否构建出网关组件（路由表、插件、ACL、限流策略、灰度规则），热加载时校验失败的配置不会生效
+func ValidateConfig(c *config.InitStructureConfig) error {
+	gatewayConfig := c.Gateway
+	var errs []error
+	if _, err := NewRouteTable(gatewayConfig.Routes, gatewayConfig.CORS); err != nil {
+		errs = append(errs, fmt.Errorf("路由配置无效: %v", err))
+	}
+	if _, err := NewPlugins(gatewayConfig.Plugins); err != nil {
+		errs = append(errs, fmt.Errorf("全局插件配置无效: %v", err))
+	}
+	for _, value := range append(append([]string(nil), gatewayConfig.ACL.Blacklist...), gatewayConfig.ACL.Whitelist...) {
+		if _, err := compileACLEntry(ACLEntry{Type: ACLBlacklist, CIDR: value}); err != nil {
+			errs = append(errs, fmt.Errorf("ACL 配置无效: %v", err))
+		}
+	}
+	for routePath, values := range gatewayConfig.ACL.RestrictedRoutes {
+		for _, value := range values {
+			if _, err := compileACLEntry(ACLEntry{Type: ACLRoute, CIDR: value, Path: routePath}); err != nil {
+				errs = append(errs, fmt.Errorf("ACL 配置无效: %v", err))
+			}
+		}
+	}
+	for _, policy := range gatewayConfig.RateLimit.Policies {
+		switch policy.KeyBy {
+		case "", RateLimitByIP, RateLimitByAPIKey, RateLimitByUser, RateLimitByRoute:
+		default:
+			errs = append(errs, fmt.Errorf("限流策略 %s 的 key_by 无效: %s", policy.Name, policy.KeyBy))
+		}
+		switch policy.Algorithm {
+		case "", TokenBucket, SlidingWindow:
+		default:
+			errs = append(errs, fmt.Errorf("限流策略 %s 的 algorithm 无效: %s", policy.Name, policy.Algorithm))
+		}
+	}
+	for _, setting := range gatewayConfig.Canary {
+		if _, err := compileCanaryRule(canaryRuleFromConfig(setting)); err != nil {
+			errs = append(errs, fmt.Errorf("灰度规则配置无效: %v", err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// 应用新配置，只重建发生变化的组件；新配置已通过 ValidateConfig 校验
+func (p *Proxy) applyConfig(prev, next *config.InitStructureConfig) {
+	oldGateway, newGateway := prev.Gateway, next.Gateway
+
+	if !reflect.DeepEqual(oldGateway.Routes, newGateway.Routes) || !reflect.DeepEqual(oldGateway.CORS, newGateway.CORS) {
+		routes, err := NewRouteTable(newGateway.Routes, newGateway.CORS)
+		if err != nil {
+			log.Printf("路由配置无效，保留当前路由表: %v", err)
+		} else {
+			p.routes.Store(routes)
+			log.Printf("路由表已更新，共 %d 条路由", len(routes.Routes()))
+		}
+	}
+
+	if !reflect.DeepEqual(oldGateway.Plugins, newGateway.Plugins) {
+		plugins, err := newGlobalPlugins(newGateway.Plugins)
+		if err != nil {
+			log.Printf("全局插件配置无效，保留当前插件: %v", err)
+		} else {
+			p.setPlugins(plugins)
+			log.Printf("全局插件已更新: %v", p.Plugins())
+		}
+	}
+
+	if !reflect.DeepEqual(oldGateway.ACL, newGateway.ACL) {
+		p.acl.SetStatic(newGateway.ACL)
+		log.Printf("ACL 配置已更新")
+	}
+
+	if !reflect.DeepEqual(oldGateway.RateLimit, newGateway.RateLimit) {
+		p.limiter.Store(p.limiter.Load().withPolicies(newGateway.RateLimit.Policies, p.RateLimitRequestsPerSec))
+		log.Printf("限流策略已更新")
+	}
+
+	if !reflect.DeepEqual(oldGateway.CircuitBreaker, newGateway.CircuitBreaker) || !reflect.DeepEqual(oldGateway.Services, newGateway.Services) {
+		p.breakers.Reload()
+		log.Printf("熔断配置已更新")
+	}
+
+	if !reflect.DeepEqual(oldGateway.Canary, newGateway.Canary) {
+		p.canary.SetStatic(newGateway.Canary)
+		log.Printf("灰度规则已更新")
+	}
+
+	if !reflect.DeepEqual(oldGateway.Streaming, newGateway.Streaming) {
+		p.streams.SetSettings(newGateway.Streaming)
+		p.resetReverseProxies() // 缓存的反向代理按新的刷新间隔重新创建
+		log.Printf("长连接配置已更新")
+	}
+}
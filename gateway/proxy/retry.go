@@ -0,0 +1,405 @@
+package proxy
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math/rand"
+	"net/http"
+	"sky_ISService/config"
+	"sky_ISService/pkg/circuit"
+	"sky_ISService/pkg/tracing"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// 重试默认配置
+const (
+	defaultRetryAttempts           = 2
+	defaultRetryBaseBackoff        = 25 * time.Millisecond
+	defaultRetryMaxBackoff         = 250 * time.Millisecond
+	defaultRetryMaxBodySize        = 1 << 20
+	defaultRetryBudgetRatio        = 0.2
+	defaultRetryBudgetMinPerSecond = 3
+	defaultIdempotencyKeyHeader    = "Idempotency-Key"
+	retryBudgetWindow              = 10 // 重试预算的统计窗口（秒）
+	retryDrainLimit                = 4 << 10
+)
+
+// 默认重试的上游响应码
+var defaultRetryOn = []int{http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout}
+
+// 幂等方法，其余方法携带幂等键时才重试
+var idempotentMethods = map[string]bool{
+	http.MethodGet:     true,
+	http.MethodHead:    true,
+	http.MethodOptions: true,
+	http.MethodTrace:   true,
+	http.MethodPut:     true,
+	http.MethodDelete:  true,
+}
+
+// 重试配置：服务级配置覆盖网关默认配置，未配置的项使用默认值
+func retrySettings(service string) config.RetryConfig {
+	settings := config.GetConfig().Gateway.Retry
+	override := config.GetConfig().Gateway.Services[service].Retry
+	if override.Attempts > 0 {
+		settings.Attempts = override.Attempts
+	}
+	if len(override.RetryOn) > 0 {
+		settings.RetryOn = override.RetryOn
+	}
+	if override.BaseBackoff > 0 {
+		settings.BaseBackoff = override.BaseBackoff
+	}
+	if override.MaxBackoff > 0 {
+		settings.MaxBackoff = override.MaxBackoff
+	}
+	if override.MaxBodySize > 0 {
+		settings.MaxBodySize = override.MaxBodySize
+	}
+	if override.BudgetRatio > 0 {
+		settings.BudgetRatio = override.BudgetRatio
+	}
+	if override.BudgetMinPerSecond > 0 {
+		settings.BudgetMinPerSecond = override.BudgetMinPerSecond
+	}
+	if override.IdempotencyKeyHeader != "" {
+		settings.IdempotencyKeyHeader = override.IdempotencyKeyHeader
+	}
+
+	if settings.Attempts <= 0 {
+		settings.Attempts = defaultRetryAttempts
+	}
+	if len(settings.RetryOn) == 0 {
+		settings.RetryOn = defaultRetryOn
+	}
+	if settings.BaseBackoff <= 0 {
+		settings.BaseBackoff = defaultRetryBaseBackoff
+	}
+	if settings.MaxBackoff <= 0 {
+		settings.MaxBackoff = defaultRetryMaxBackoff
+	}
+	if settings.MaxBodySize <= 0 {
+		settings.MaxBodySize = defaultRetryMaxBodySize
+	}
+	if settings.BudgetRatio <= 0 {
+		settings.BudgetRatio = defaultRetryBudgetRatio
+	}
+	if settings.BudgetMinPerSecond <= 0 {
+		settings.BudgetMinPerSecond = defaultRetryBudgetMinPerSecond
+	}
+	if settings.IdempotencyKeyHeader == "" {
+		settings.IdempotencyKeyHeader = defaultIdempotencyKeyHeader
+	}
+	return settings
+}
+
+// 重试预算：按秒分桶统计最近一个窗口内的请求数和重试数，防止上游整体故障时重试把流量放大数倍
+type retryBudget struct {
+	mu      sync.Mutex
+	buckets [retryBudgetWindow]retryBucket
+}
+
+type retryBucket struct {
+	second   int64
+	requests int
+	retries  int
+}
+
+// 当前秒对应的桶（调用方需持有锁）
+func (b *retryBudget) bucket(now int64) *retryBucket {
+	bucket := &b.buckets[now%retryBudgetWindow]
+	if bucket.second != now {
+		*bucket = retryBucket{second: now}
+	}
+	return bucket
+}
+
+// 记录一个请求
+func (b *retryBudget) recordRequest() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.bucket(time.Now().Unix()).requests++
+}
+
+// 申请一次重试，窗口内重试数超过 请求数 * ratio + 每秒保底数 * 窗口秒数 时拒绝
+func (b *retryBudget) withdraw(ratio float64, minPerSecond int) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := time.Now().Unix()
+	requests, retries := 0, 0
+	for _, bucket := range b.buckets {
+		if now-bucket.second < retryBudgetWindow {
+			requests += bucket.requests
+			retries += bucket.retries
+		}
+	}
+	if float64(retries) >= float64(requests)*ratio+float64(minPerSecond*retryBudgetWindow) {
+		return false
+	}
+	b.bucket(now).retries++
+	return true
+}
+
+// 获取服务的重试预算
+func (p *Proxy) retryBudgetFor(service string) *retryBudget {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	budget, ok := p.retryBudgets[service]
+	if !ok {
+		budget = &retryBudget{}
+		p.retryBudgets[service] = budget
+	}
+	return budget
+}
+
+// upstreamCall 一次代理请求对上游的调用，作为 ReverseProxy 的 Transport 使用：
+// 连接失败或上游返回需要重试的响应码时，退避后换一个未尝试过的节点重新发送
+type upstreamCall struct {
+	p         *Proxy
+	service   string
+	version   string
+	settings  config.RetryConfig
+	budget    *retryBudget
+	transport http.RoundTripper
+
+	retryable bool   // 请求能否重试（幂等且请求体已缓冲）
+	body      []byte // 缓冲的请求体
+
+	serviceDone func(circuit.Outcome) // 服务熔断器的结果回调，请求结束时按最后一次尝试的结果记录
+	outcome     circuit.Outcome       // 最后一次尝试的结果
+	tried       map[string]bool       // 已尝试（或节点熔断器拒绝）的节点地址
+	node        *WeightedNode         // 当前节点
+	done        func(circuit.Outcome) // 当前节点的熔断结果回调
+	attempts    int                   // 已发送次数
+
+	route    *Route        // 命中的路由
+	span     *tracing.Span // 转发调用段
+	cacheReq *cacheRequest // 需要缓存响应时不为 nil
+	start    time.Time     // 开始转发的时间
+}
+
+// 请求上下文中保存上游调用的 key
+type upstreamCallKey struct{}
+
+// 取出请求上下文中的上游调用
+func upstreamCallFromContext(ctx context.Context) *upstreamCall {
+	return ctx.Value(upstreamCallKey{}).(*upstreamCall)
+}
+
+// 准备上游调用：判断请求能否重试，能重试时缓冲请求体
+func (p *Proxy) newUpstreamCall(route *Route, version string, r *http.Request, streaming bool) *upstreamCall {
+	call := &upstreamCall{
+		p:         p,
+		service:   route.Service,
+		version:   version,
+		settings:  retrySettings(route.Service),
+		budget:    p.retryBudgetFor(route.Service),
+		transport: p.transports.forRequest(streaming),
+		outcome:   circuit.Ignored,
+		tried:     make(map[string]bool),
+		route:     route,
+	}
+	call.budget.recordRequest()
+
+	if streaming || call.settings.Attempts <= 1 {
+		return call
+	}
+	if !idempotentMethods[r.Method] && r.Header.Get(call.settings.IdempotencyKeyHeader) == "" {
+		return call
+	}
+	call.retryable = call.bufferBody(r)
+	return call
+}
+
+// 缓冲请求体以便重发，超过上限时已读取的部分与剩余部分拼接后照常转发，但不再重试
+func (c *upstreamCall) bufferBody(r *http.Request) bool {
+	if r.Body == nil || r.Body == http.NoBody {
+		return true
+	}
+	limit := c.settings.MaxBodySize
+	if r.ContentLength > limit {
+		return false
+	}
+
+	body, err := io.ReadAll(io.LimitReader(r.Body, limit+1))
+	if err != nil || int64(len(body)) > limit {
+		r.Body = readCloser{io.MultiReader(bytes.NewReader(body), r.Body), r.Body}
+		return false
+	}
+	_ = r.Body.Close()
+	c.body = body
+	r.Body = io.NopCloser(bytes.NewReader(body))
+	r.ContentLength = int64(len(body))
+	return true
+}
+
+// 申请服务熔断器的放行，每个客户端请求只申请一次，重试不会再占用半开状态的试探名额
+func (c *upstreamCall) begin() error {
+	done, err := c.p.allowService(c.service)
+	if err != nil {
+		return err
+	}
+	c.serviceDone = done
+	return nil
+}
+
+// 选择一个未尝试过的节点并申请节点熔断器的放行，节点熔断器拒绝时（如半开状态的试探名额刚被其他请求占满）换一个节点；
+// 没有可用节点时返回 nil，候选节点都被节点熔断器拒绝时返回熔断错误
+func (c *upstreamCall) acquire(r *http.Request) (*WeightedNode, func(circuit.Outcome), error) {
+	var rejected error
+	for {
+		node := c.p.getServiceForPath(c.service, c.version, r, c.tried)
+		if node == nil {
+			recordUpstreamSelection(c.service, nil)
+			return nil, nil, rejected
+		}
+		done, err := c.p.allowNode(c.service, node)
+		if err == nil {
+			recordUpstreamSelection(c.service, node)
+			return node, done, nil
+		}
+		c.p.releaseNode(c.service, node)
+		c.tried[node.addr] = true
+		rejected = err
+	}
+}
+
+// 使用节点发送后续请求
+func (c *upstreamCall) use(node *WeightedNode, done func(circuit.Outcome)) {
+	c.tried[node.addr] = true
+	c.node = node
+	c.done = done
+}
+
+// 请求结束：当前节点未记录结果时不计入熔断统计，并通知负载均衡器；服务熔断器按最后一次尝试的结果记录
+func (c *upstreamCall) finish() {
+	if c.node != nil {
+		c.done(circuit.Ignored)
+		c.p.releaseNode(c.service, c.node)
+		c.node, c.done = nil, nil
+	}
+	if c.serviceDone != nil {
+		c.serviceDone(c.outcome)
+		c.serviceDone = nil
+	}
+}
+
+// 在调用段上记录最终使用的节点和尝试次数
+func (c *upstreamCall) annotate() {
+	c.span.SetAttribute("net.peer.name", c.node.addr)
+	if c.attempts > 1 {
+		c.span.SetAttribute("gateway.attempts", c.attempts)
+	}
+}
+
+// RoundTrip 发送请求，失败时按重试策略换节点重发
+func (c *upstreamCall) RoundTrip(req *http.Request) (*http.Response, error) {
+	for {
+		c.attempts++
+		resp, err := c.transport.RoundTrip(c.attemptRequest(req))
+		c.record(req, resp, err)
+
+		reason, retry := c.shouldRetry(req, resp, err)
+		if !retry {
+			return resp, err
+		}
+		node, done, acquireErr := c.acquire(req)
+		if node == nil || acquireErr != nil {
+			return resp, err
+		}
+		if !c.budget.withdraw(c.settings.BudgetRatio, c.settings.BudgetMinPerSecond) {
+			done(circuit.Ignored)
+			c.p.releaseNode(c.service, node)
+			recordRetryBudgetExhausted(c.service)
+			return resp, err
+		}
+
+		if resp != nil {
+			_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, retryDrainLimit))
+			_ = resp.Body.Close()
+		}
+		log.Printf("上游 %s 节点 %s 请求失败（%s），第 %d 次重试转发到 %s [%s]",
+			c.service, c.node.addr, reason, c.attempts, node.addr, tracing.RequestID(req.Context()))
+		c.p.releaseNode(c.service, c.node)
+		c.use(node, done)
+		recordUpstreamRetry(c.service, reason)
+
+		if !sleepContext(req.Context(), c.backoff(c.attempts)) {
+			return nil, req.Context().Err()
+		}
+	}
+}
+
+// 当前尝试使用的请求：首次直接使用原请求，重试时复制请求并替换节点地址和请求体
+func (c *upstreamCall) attemptRequest(req *http.Request) *http.Request {
+	if c.attempts == 1 {
+		return req
+	}
+	attempt := req.Clone(req.Context())
+	attempt.URL.Host = c.node.addr
+	if req.Body != nil && req.Body != http.NoBody {
+		attempt.Body = io.NopCloser(bytes.NewReader(c.body))
+	}
+	return attempt
+}
+
+// 被动健康检查与熔断统计：根据每次尝试的结果统计节点失败
+func (c *upstreamCall) record(req *http.Request, resp *http.Response, err error) {
+	if err != nil {
+		// 客户端主动取消的请求不计入节点失败，路由超时计入
+		if errors.Is(req.Context().Err(), context.Canceled) {
+			c.outcome = circuit.Ignored
+			c.done(c.outcome)
+			return
+		}
+		c.p.health.RecordFailure(c.node.addr, fmt.Sprintf("代理请求失败: %v", err))
+		c.outcome = circuit.Failure
+		c.done(c.outcome)
+		return
+	}
+
+	if resp.StatusCode >= http.StatusInternalServerError {
+		c.outcome = circuit.Failure
+	} else {
+		c.outcome = circuit.Success
+	}
+	c.done(c.outcome)
+	switch resp.StatusCode {
+	case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		c.p.health.RecordFailure(c.node.addr, fmt.Sprintf("上游返回 %d", resp.StatusCode))
+	default:
+		c.p.health.RecordSuccess(c.node.addr)
+	}
+}
+
+// 是否需要重试，返回重试原因（error 或响应码）
+func (c *upstreamCall) shouldRetry(req *http.Request, resp *http.Response, err error) (string, bool) {
+	if !c.retryable || c.attempts >= c.settings.Attempts || req.Context().Err() != nil {
+		return "", false
+	}
+	if err != nil {
+		return "error", true
+	}
+	for _, status := range c.settings.RetryOn {
+		if resp.StatusCode == status {
+			return strconv.Itoa(status), true
+		}
+	}
+	return "", false
+}
+
+// 第 n 次重试前的退避时间：指数增长，取上限后在 [d/2, d] 内随机抖动，避免多个网关实例同时重试
+func (c *upstreamCall) backoff(retry int) time.Duration {
+	d := c.settings.BaseBackoff << (retry - 1)
+	if d <= 0 || d > c.settings.MaxBackoff {
+		d = c.settings.MaxBackoff
+	}
+	return d/2 + time.Duration(rand.Int63n(int64(d/2)+1))
+}
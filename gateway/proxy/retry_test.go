@@ -0,0 +1,169 @@
+package proxy
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"sky_ISService/config"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRetryBudget(t *testing.T) {
+	tests := []struct {
+		name         string
+		requests     int
+		ratio        float64
+		minPerSecond int
+		want         int // 允许的重试次数
+	}{
+		{name: "没有请求时按每秒保底数", ratio: 0.2, minPerSecond: 1, want: retryBudgetWindow},
+		{name: "按请求数的比例", requests: 100, ratio: 0.2, want: 20},
+		{name: "比例与保底数相加", requests: 50, ratio: 0.2, minPerSecond: 1, want: 10 + retryBudgetWindow},
+		{name: "没有预算", requests: 100},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			budget := &retryBudget{}
+			for i := 0; i < tt.requests; i++ {
+				budget.recordRequest()
+			}
+			allowed := 0
+			for budget.withdraw(tt.ratio, tt.minPerSecond) {
+				allowed++
+				if allowed > tt.want {
+					break
+				}
+			}
+			if allowed != tt.want {
+				t.Fatalf("允许重试 %d 次，期望 %d 次", allowed, tt.want)
+			}
+		})
+	}
+}
+
+func TestRetrySettings(t *testing.T) {
+	useTestConfig(t, func(c *config.InitStructureConfig) {
+		c.Gateway.Retry = config.RetryConfig{Attempts: 3, BaseBackoff: 10 * time.Millisecond}
+		c.Gateway.Services = map[string]config.UpstreamConfig{
+			"system": {Retry: config.RetryConfig{Attempts: 5, RetryOn: []int{http.StatusInternalServerError}, IdempotencyKeyHeader: "X-Idempotency-Key"}},
+		}
+	})
+
+	tests := []struct {
+		service string
+		want    config.RetryConfig
+	}{
+		{service: "auth", want: config.RetryConfig{
+			Attempts: 3, RetryOn: defaultRetryOn, BaseBackoff: 10 * time.Millisecond, MaxBackoff: defaultRetryMaxBackoff,
+			MaxBodySize: defaultRetryMaxBodySize, BudgetRatio: defaultRetryBudgetRatio, BudgetMinPerSecond: defaultRetryBudgetMinPerSecond,
+			IdempotencyKeyHeader: defaultIdempotencyKeyHeader,
+		}},
+		{service: "system", want: config.RetryConfig{
+			Attempts: 5, RetryOn: []int{http.StatusInternalServerError}, BaseBackoff: 10 * time.Millisecond, MaxBackoff: defaultRetryMaxBackoff,
+			MaxBodySize: defaultRetryMaxBodySize, BudgetRatio: defaultRetryBudgetRatio, BudgetMinPerSecond: defaultRetryBudgetMinPerSecond,
+			IdempotencyKeyHeader: "X-Idempotency-Key",
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.service, func(t *testing.T) {
+			if got := retrySettings(tt.service); !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("得到 %+v，期望 %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRetryBackoff(t *testing.T) {
+	call := &upstreamCall{settings: config.RetryConfig{BaseBackoff: 20 * time.Millisecond, MaxBackoff: 50 * time.Millisecond}}
+	tests := []struct {
+		retry int
+		max   time.Duration
+	}{
+		{retry: 1, max: 20 * time.Millisecond},
+		{retry: 2, max: 40 * time.Millisecond},
+		{retry: 3, max: 50 * time.Millisecond},
+		{retry: 64, max: 50 * time.Millisecond}, // 移位溢出时取上限
+	}
+	for _, tt := range tests {
+		for i := 0; i < 100; i++ {
+			if d := call.backoff(tt.retry); d < tt.max/2 || d > tt.max {
+				t.Fatalf("第 %d 次重试退避 %s，期望在 [%s, %s] 内", tt.retry, d, tt.max/2, tt.max)
+			}
+		}
+	}
+}
+
+func TestProxyRetry(t *testing.T) {
+	var failedHits, okHits atomic.Int32
+	failed := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		failedHits.Add(1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	t.Cleanup(failed.Close)
+	ok := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		okHits.Add(1)
+		body, _ := io.ReadAll(r.Body)
+		_, _ = io.WriteString(w, "ok:"+string(body))
+	}))
+	t.Cleanup(ok.Close)
+	failedURL, _ := url.Parse(failed.URL)
+	okURL, _ := url.Parse(ok.URL)
+
+	tests := []struct {
+		name        string
+		method      string
+		body        string
+		header      http.Header
+		wantRetried bool // 落到失败节点的请求是否重试到正常节点
+	}{
+		{name: "幂等方法重试", method: http.MethodGet, wantRetried: true},
+		{name: "重试时重发请求体", method: http.MethodPut, body: "payload", wantRetried: true},
+		{name: "携带幂等键的 POST 重试", method: http.MethodPost, body: "payload", header: http.Header{"Idempotency-Key": {"k1"}}, wantRetried: true},
+		{name: "POST 不重试", method: http.MethodPost, body: "payload"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := newTestProxy(t, okURL)
+			useTestConfig(t, func(c *config.InitStructureConfig) {
+				c.Gateway.Retry = config.RetryConfig{BaseBackoff: time.Millisecond, MaxBackoff: time.Millisecond}
+			})
+			// 失败节点权重远大于正常节点，大多数请求首次落在失败节点上
+			p.SetServiceNodes("system", []*WeightedNode{{addr: failedURL.Host, weight: 100}, {addr: okURL.Host, weight: 1}})
+			failedHits.Store(0)
+			okHits.Store(0)
+
+			const requests = 10
+			for i := 0; i < requests; i++ {
+				r := httptest.NewRequest(tt.method, "/system/orders", strings.NewReader(tt.body))
+				for name, values := range tt.header {
+					r.Header[name] = values
+				}
+				w := httptest.NewRecorder()
+				p.ServeHTTP(w, r)
+
+				if tt.wantRetried {
+					if w.Code != http.StatusOK || w.Body.String() != "ok:"+tt.body {
+						t.Fatalf("重试后响应 %d %q", w.Code, w.Body.String())
+					}
+					continue
+				}
+				if w.Code != http.StatusOK && w.Code != http.StatusServiceUnavailable {
+					t.Fatalf("响应 %d %q", w.Code, w.Body.String())
+				}
+			}
+			// 每个请求最多尝试 2 次（默认 attempts）；不重试时每个请求只发送一次
+			total := int(failedHits.Load() + okHits.Load())
+			if tt.wantRetried && (failedHits.Load() == 0 || okHits.Load() != requests || total > 2*requests) {
+				t.Fatalf("失败节点 %d 次、正常节点 %d 次", failedHits.Load(), okHits.Load())
+			}
+			if !tt.wantRetried && total != requests {
+				t.Fatalf("不可重试的请求发送了 %d 次，期望 %d 次", total, requests)
+			}
+		})
+	}
+}
@@ -0,0 +1,253 @@
+package proxy
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"path"
+	"sky_ISService/config"
+	"sort"
+	"strings"
+)
+
+// 兜底服务，没有路由命中时转发到该服务
+const defaultService = "default"
+
+// Route 编译后的网关路由
+type Route struct {
+	config.RouteConfig
+	methods map[string]bool // 允许的方法，为空表示任意
+	cors    *CORSPolicy     // 跨域策略
+	plugins []Plugin        // 路由插件（timeout 配置对应的超时插件在最前）
+}
+
+// RouteInfo 路由信息（用于调试接口）
+type RouteInfo struct {
+	Name          string   `json:"name"`
+	Prefix        string   `json:"prefix"`
+	Host          string   `json:"host,omitempty"`
+	Methods       []string `json:"methods,omitempty"`
+	Service       string   `json:"service"`
+	StripPrefix   bool     `json:"strip_prefix"`
+	RewritePrefix string   `json:"rewrite_prefix,omitempty"`
+	Timeout       string   `json:"timeout,omitempty"`
+	AuthRequired  bool     `json:"auth_required"`
+	Plugins       []string `json:"plugins,omitempty"`
+}
+
+// RouteTable 路由表，按最长前缀匹配
+type RouteTable struct {
+	routes   []*Route // 按匹配优先级排序
+	fallback *Route   // 没有路由命中时使用的兜底路由
+}
+
+// 未配置路由时使用的默认路由，与原先硬编码的转发规则一致
+func defaultRoutes() []config.RouteConfig {
+	public := false
+	return []config.RouteConfig{
+		{Name: "security-admins", Prefix: "/security/admins", Service: "security", AuthRequired: &public},
+		{Name: "security", Prefix: "/security", Service: "security"},
+		{Name: "system", Prefix: "/system", Service: "system"},
+		{Name: "order", Prefix: "/order", Service: "order"},
+	}
+}
+
+// NewRouteTable 编译路由表，未配置路由时使用默认路由；cors 为网关默认的跨域配置
+func NewRouteTable(configs []config.RouteConfig, cors config.CORSConfig) (*RouteTable, error) {
+	if len(configs) == 0 {
+		configs = defaultRoutes()
+	}
+
+	routes := make([]*Route, 0, len(configs))
+	for i, routeConfig := range configs {
+		if routeConfig.Service == "" {
+			return nil, fmt.Errorf("路由 %d 未配置 service", i)
+		}
+		if routeConfig.Prefix == "" {
+			routeConfig.Prefix = "/"
+		}
+		if !strings.HasPrefix(routeConfig.Prefix, "/") {
+			return nil, fmt.Errorf("路由 %d 的前缀 %s 必须以 / 开头", i, routeConfig.Prefix)
+		}
+		if routeConfig.Prefix != "/" {
+			routeConfig.Prefix = strings.TrimSuffix(routeConfig.Prefix, "/")
+		}
+		if routeConfig.Name == "" {
+			routeConfig.Name = fmt.Sprintf("route%d", i)
+		}
+		routeConfig.Host = strings.ToLower(routeConfig.Host)
+
+		route := &Route{RouteConfig: routeConfig, cors: NewCORSPolicy(cors.Merge(routeConfig.CORS))}
+		if routeConfig.Timeout > 0 {
+			route.plugins = append(route.plugins, &timeoutPlugin{timeout: routeConfig.Timeout})
+		}
+		plugins, err := NewPlugins(routeConfig.Plugins)
+		if err != nil {
+			return nil, fmt.Errorf("路由 %s: %v", routeConfig.Name, err)
+		}
+		route.plugins = append(route.plugins, plugins...)
+		if len(routeConfig.Methods) > 0 {
+			route.methods = make(map[string]bool, len(routeConfig.Methods))
+			for _, method := range routeConfig.Methods {
+				route.methods[strings.ToUpper(method)] = true
+			}
+		}
+		routes = append(routes, route)
+	}
+
+	// 前缀越长越优先；前缀相同时限定了 Host、方法的路由优先
+	sort.SliceStable(routes, func(i, j int) bool {
+		a, b := routes[i], routes[j]
+		if len(a.Prefix) != len(b.Prefix) {
+			return len(a.Prefix) > len(b.Prefix)
+		}
+		if (a.Host != "") != (b.Host != "") {
+			return a.Host != ""
+		}
+		return len(a.methods) > 0 && len(b.methods) == 0
+	})
+	fallback := &Route{
+		RouteConfig: config.RouteConfig{Name: defaultService, Prefix: "/", Service: defaultService},
+		cors:        NewCORSPolicy(cors),
+	}
+	return &RouteTable{routes: routes, fallback: fallback}, nil
+}
+
+// Match 返回请求命中的路由，没有命中时返回 nil
+func (t *RouteTable) Match(r *http.Request) *Route {
+	host := requestHost(r)
+	for _, route := range t.routes {
+		if route.matches(r.Method, host, r.URL.Path) {
+			return route
+		}
+	}
+	return nil
+}
+
+// Routes 返回按匹配优先级排序的路由信息
+func (t *RouteTable) Routes() []RouteInfo {
+	infos := make([]RouteInfo, 0, len(t.routes))
+	for _, route := range t.routes {
+		infos = append(infos, route.Info())
+	}
+	return infos
+}
+
+// Info 返回路由信息
+func (route *Route) Info() RouteInfo {
+	info := RouteInfo{
+		Name:          route.Name,
+		Prefix:        route.Prefix,
+		Host:          route.Host,
+		Methods:       route.Methods,
+		Service:       route.Service,
+		StripPrefix:   route.StripPrefix,
+		RewritePrefix: route.RewritePrefix,
+		AuthRequired:  route.RequiresAuth(),
+		Plugins:       pluginNames(route.plugins),
+	}
+	if route.Timeout > 0 {
+		info.Timeout = route.Timeout.String()
+	}
+	return info
+}
+
+// CORS 路由的跨域策略
+func (route *Route) CORS() *CORSPolicy {
+	return route.cors
+}
+
+// UpstreamPath 计算转发到上游的路径：rewrite_prefix 优先，其次 strip_prefix，否则保持原路径
+func (route *Route) UpstreamPath(requestPath string) string {
+	if route.RewritePrefix == "" && !route.StripPrefix {
+		return requestPath
+	}
+
+	rest := requestPath
+	if route.Prefix != "/" {
+		rest = strings.TrimPrefix(requestPath, route.Prefix)
+	}
+	upstreamPath := strings.TrimSuffix(route.RewritePrefix, "/") + rest
+	if !strings.HasPrefix(upstreamPath, "/") {
+		upstreamPath = "/" + upstreamPath
+	}
+	return upstreamPath
+}
+
+// 判断请求是否命中路由
+func (route *Route) matches(method, host, requestPath string) bool {
+	if route.methods != nil && !route.methods[method] {
+		return false
+	}
+	if route.Host != "" && !matchHost(route.Host, host) {
+		return false
+	}
+	return matchPathPrefix(route.Prefix, requestPath)
+}
+
+// Host 匹配，支持 *.example.com 匹配任意子域名
+func matchHost(pattern, host string) bool {
+	if strings.HasPrefix(pattern, "*.") {
+		return strings.HasSuffix(host, pattern[1:]) && len(host) > len(pattern)-1
+	}
+	return pattern == host
+}
+
+// 规范化请求路径：合并重复的 /，解析 . 与 ..，保留结尾的 /。访问控制、路由、限流与授权范围都按规范化后的路径匹配，
+// 转发的也是该路径，避免 /api//admin、/api/./admin 绕过 /api/admin 的规则；路径不变时返回原请求
+func withCleanPath(r *http.Request) *http.Request {
+	cleaned := cleanPath(r.URL.Path)
+	if cleaned == r.URL.Path {
+		return r
+	}
+	r = r.WithContext(r.Context())
+	u := *r.URL
+	u.Path, u.RawPath = cleaned, ""
+	r.URL = &u
+	return r
+}
+
+func cleanPath(requestPath string) string {
+	if requestPath == "" {
+		return "/"
+	}
+	if requestPath[0] != '/' {
+		requestPath = "/" + requestPath
+	}
+	cleaned := path.Clean(requestPath)
+	if strings.HasSuffix(requestPath, "/") && cleaned != "/" {
+		cleaned += "/"
+	}
+	return cleaned
+}
+
+// 请求的 Host（小写，不含端口）
+func requestHost(r *http.Request) string {
+	host := r.Host
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	return strings.ToLower(host)
+}
+
+// Routes 返回当前生效的路由表信息
+func (p *Proxy) Routes() []RouteInfo {
+	return p.routes.Load().Routes()
+}
+
+// MatchRoute 返回请求命中的路由，没有命中时返回兜底路由
+func (p *Proxy) MatchRoute(r *http.Request) *Route {
+	routes := p.routes.Load()
+	if route := routes.Match(r); route != nil {
+		return route
+	}
+	return routes.fallback
+}
+
+// IsPublicRequest 请求命中的路由是否无需 JWT 认证
+func (p *Proxy) IsPublicRequest(r *http.Request) bool {
+	if grpcRoute, _ := p.grpc.Match(r); grpcRoute != nil {
+		return !grpcRoute.RequiresAuth()
+	}
+	return !p.MatchRoute(r).RequiresAuth()
+}
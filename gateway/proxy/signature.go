@@ -0,0 +1,211 @@
+package proxy
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"sky_ISService/config"
+	"sky_ISService/pkg/identity"
+	"sky_ISService/pkg/signing"
+	"sky_ISService/shared/cache"
+	"time"
+)
+
+const (
+	signingNonceKeyPrefix  = "gateway:signing:nonce:" // 已使用的随机串：<应用 ID>:<随机串>
+	signingNonceTimeout    = 200 * time.Millisecond
+	defaultSigningMaxSkew  = 5 * time.Minute
+	defaultSigningBodySize = 10 << 20
+)
+
+var (
+	ErrSigningUnknownApp  = errors.New("未知的应用")
+	ErrSigningScope       = errors.New("应用无权访问该接口")
+	ErrSigningReplay      = errors.New("重复的请求（nonce 已使用）")
+	ErrSigningBodyTooLong = errors.New("请求体过大")
+	ErrSigningUnavailable = errors.New("暂时无法校验签名")
+)
+
+// SignatureVerifier 合作方请求验签：应用与密钥来自配置文件（修改后热加载），随机串记录在 Redis 中防止重放；
+// 只有签名有效且未被访问控制、限流拦截的请求才会记录随机串
+type SignatureVerifier struct {
+	redisClient *cache.RedisClient
+}
+
+// NewSignatureVerifier 创建验签器
+func NewSignatureVerifier(redisClient *cache.RedisClient) *SignatureVerifier {
+	return &SignatureVerifier{redisClient: redisClient}
+}
+
+// SignedApp 通过验签的应用
+type SignedApp struct {
+	AppID string
+	Name  string
+	Roles []string
+
+	nonce    string        // 请求的随机串，由 UseNonce 记录
+	nonceTTL time.Duration // 随机串的保留时间
+}
+
+// Identity 转发给上游服务的调用方身份
+func (a *SignedApp) Identity() *identity.Identity {
+	return &identity.Identity{UserID: "app:" + a.AppID, Username: a.Name, Roles: a.Roles}
+}
+
+// 上下文中保存通过验签的应用的 key
+type signedAppContextKey struct{}
+
+// 将通过验签的应用保存到上下文
+func withSignedApp(ctx context.Context, app *SignedApp) context.Context {
+	return context.WithValue(ctx, signedAppContextKey{}, app)
+}
+
+// 读取上下文中通过验签的应用
+func signedAppFromContext(ctx context.Context) (*SignedApp, bool) {
+	app, ok := ctx.Value(signedAppContextKey{}).(*SignedApp)
+	return app, ok
+}
+
+// Verify 校验请求签名与授权范围，不记录随机串，请求体读取后会被还原；
+// 通过验签的请求在访问控制与限流之后调用 UseNonce 记录随机串
+func (v *SignatureVerifier) Verify(r *http.Request) (*SignedApp, error) {
+	app, err := v.verify(r)
+	if err != nil {
+		appID := r.Header.Get(signing.AppIDHeader)
+		if errors.Is(err, ErrSigningUnknownApp) {
+			appID = "unknown" // 避免任意应用 ID 产生大量指标序列
+		}
+		signedRequests.WithLabelValues(appID, signingResult(err)).Inc()
+	}
+	return app, err
+}
+
+func (v *SignatureVerifier) verify(r *http.Request) (*SignedApp, error) {
+	settings := config.GetConfig().Gateway.Signing
+	appID := r.Header.Get(signing.AppIDHeader)
+	var app *config.SigningAppConfig
+	for i := range settings.Apps {
+		if settings.Apps[i].AppID == appID {
+			app = &settings.Apps[i]
+			break
+		}
+	}
+	if app == nil || app.Disabled {
+		return nil, ErrSigningUnknownApp
+	}
+
+	maxBodySize := settings.MaxBodySize
+	if maxBodySize <= 0 {
+		maxBodySize = defaultSigningBodySize
+	}
+	body, err := readSignedBody(r, maxBodySize)
+	if err != nil {
+		return nil, err
+	}
+
+	maxSkew := settings.MaxSkew
+	if maxSkew <= 0 {
+		maxSkew = defaultSigningMaxSkew
+	}
+	if err := signing.Verify(r, body, app.Secret, time.Now(), maxSkew); err != nil {
+		return nil, err
+	}
+	if !allowsPrefix(app.Prefixes, r.URL.Path) {
+		return nil, ErrSigningScope
+	}
+	return &SignedApp{
+		AppID:    app.AppID,
+		Name:     app.Name,
+		Roles:    app.Roles,
+		nonce:    r.Header.Get(signing.NonceHeader),
+		nonceTTL: 2 * maxSkew,
+	}, nil
+}
+
+// UseNonce 记录通过验签的请求的随机串，时间窗口内已使用过时返回 ErrSigningReplay
+// Redis 不可用时拒绝请求（fail closed），无法确认随机串是否已使用
+func (v *SignatureVerifier) UseNonce(ctx context.Context, app *SignedApp) error {
+	err := v.useNonce(ctx, app.AppID, app.nonce, app.nonceTTL)
+	signedRequests.WithLabelValues(app.AppID, signingResult(err)).Inc()
+	return err
+}
+
+// 记录随机串，时间窗口内已使用过时返回 ErrSigningReplay
+func (v *SignatureVerifier) useNonce(ctx context.Context, appID, nonce string, ttl time.Duration) error {
+	if v.redisClient == nil {
+		return ErrSigningUnavailable
+	}
+	ctx, cancel := context.WithTimeout(ctx, signingNonceTimeout)
+	defer cancel()
+	stored, err := v.redisClient.Client.SetNX(ctx, signingNonceKeyPrefix+appID+":"+nonce, 1, ttl).Result()
+	if err != nil {
+		log.Printf("记录应用 %s 的 nonce 失败，拒绝请求: %v", appID, err)
+		return ErrSigningUnavailable
+	}
+	if !stored {
+		return ErrSigningReplay
+	}
+	return nil
+}
+
+// 读取完整的请求体用于计算摘要，并替换为可重复读取的副本
+func readSignedBody(r *http.Request, maxBodySize int64) ([]byte, error) {
+	if r.Body == nil || r.Body == http.NoBody {
+		return nil, nil
+	}
+	if r.ContentLength > maxBodySize {
+		return nil, ErrSigningBodyTooLong
+	}
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxBodySize+1))
+	r.Body.Close()
+	if err != nil {
+		return nil, fmt.Errorf("读取请求体失败: %v", err)
+	}
+	if int64(len(body)) > maxBodySize {
+		return nil, ErrSigningBodyTooLong
+	}
+	r.Body = io.NopCloser(bytes.NewReader(body))
+	return body, nil
+}
+
+// 路径是否在任一前缀下，没有前缀表示不限制
+func allowsPrefix(prefixes []string, requestPath string) bool {
+	if len(prefixes) == 0 {
+		return true
+	}
+	for _, prefix := range prefixes {
+		if matchPathPrefix(prefix, requestPath) {
+			return true
+		}
+	}
+	return false
+}
+
+// 指标中的验签结果
+func signingResult(err error) string {
+	switch {
+	case err == nil:
+		return "ok"
+	case errors.Is(err, ErrSigningUnknownApp):
+		return "unknown_app"
+	case errors.Is(err, signing.ErrExpired):
+		return "expired"
+	case errors.Is(err, ErrSigningReplay):
+		return "replay"
+	case errors.Is(err, ErrSigningScope):
+		return "scope"
+	case errors.Is(err, ErrSigningUnavailable):
+		return "unavailable"
+	default:
+		return "invalid"
+	}
+}
+
+// Signatures 返回合作方请求验签器
+func (p *Proxy) Signatures() *SignatureVerifier {
+	return p.signatures
+}
@@ -0,0 +1,118 @@
+package proxy
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sky_ISService/config"
+	"sky_ISService/pkg/signing"
+	"sky_ISService/shared/cache"
+	"strings"
+	"testing"
+	"time"
+)
+
+// 配置合作方应用，返回使用进程内 Redis 的验签器
+func newTestSignatureVerifier(t *testing.T) *SignatureVerifier {
+	t.Helper()
+	useTestConfig(t, func(c *config.InitStructureConfig) {
+		c.Gateway.Signing = config.SigningConfig{
+			MaxBodySize: 64,
+			Apps: []config.SigningAppConfig{
+				{AppID: "erp", Name: "ERP", Secret: "erp-secret", Prefixes: []string{"/system/api/v1/orders"}, Roles: []string{"partner"}},
+				{AppID: "old", Secret: "old-secret", Disabled: true},
+			},
+		}
+	})
+	redisClient, err := cache.NewMemoryRedis()
+	if err != nil {
+		t.Fatalf("启动进程内 Redis 失败: %v", err)
+	}
+	t.Cleanup(func() { _ = redisClient.Close() })
+	return NewSignatureVerifier(redisClient)
+}
+
+// 合作方签名的请求
+func newSignedRequest(t *testing.T, appID, secret, method, target, body string) *http.Request {
+	t.Helper()
+	var reader io.Reader
+	if body != "" {
+		reader = strings.NewReader(body)
+	}
+	r := httptest.NewRequest(method, target, reader)
+	if err := signing.Sign(r, appID, secret, time.Now()); err != nil {
+		t.Fatalf("签名失败: %v", err)
+	}
+	return r
+}
+
+func TestSignatureVerify(t *testing.T) {
+	verifier := newTestSignatureVerifier(t)
+	tests := []struct {
+		name    string
+		appID   string
+		secret  string
+		target  string
+		body    string
+		tamper  func(r *http.Request)
+		wantErr error
+	}{
+		{name: "有效", appID: "erp", secret: "erp-secret", target: "/system/api/v1/orders?page=1", body: `{"id":1}`},
+		{name: "未知应用", appID: "crm", secret: "crm-secret", target: "/system/api/v1/orders", wantErr: ErrSigningUnknownApp},
+		{name: "已停用", appID: "old", secret: "old-secret", target: "/system/api/v1/orders", wantErr: ErrSigningUnknownApp},
+		{name: "密钥错误", appID: "erp", secret: "wrong", target: "/system/api/v1/orders", wantErr: signing.ErrSignature},
+		{name: "前缀按路径段匹配", appID: "erp", secret: "erp-secret", target: "/system/api/v1/orders-export", wantErr: ErrSigningScope},
+		{name: "请求体过大", appID: "erp", secret: "erp-secret", target: "/system/api/v1/orders", body: strings.Repeat("x", 65), wantErr: ErrSigningBodyTooLong},
+		{name: "篡改请求体", appID: "erp", secret: "erp-secret", target: "/system/api/v1/orders", body: `{"id":1}`, wantErr: signing.ErrContentHash,
+			tamper: func(r *http.Request) { r.Body = io.NopCloser(strings.NewReader(`{"id":2}`)) }},
+		{name: "篡改查询串", appID: "erp", secret: "erp-secret", target: "/system/api/v1/orders?page=1", wantErr: signing.ErrSignature,
+			tamper: func(r *http.Request) { r.URL.RawQuery = "page=2" }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := newSignedRequest(t, tt.appID, tt.secret, http.MethodPost, tt.target, tt.body)
+			if tt.tamper != nil {
+				tt.tamper(r)
+			}
+			app, err := verifier.Verify(r)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Verify 返回 %v，期望 %v", err, tt.wantErr)
+			}
+			if err == nil && (app.AppID != "erp" || app.Identity().UserID != "app:erp") {
+				t.Fatalf("Verify 返回 %+v", app)
+			}
+		})
+	}
+}
+
+// 验签不记录随机串，UseNonce 记录后同一随机串的请求视为重放
+func TestSignatureNonceReplay(t *testing.T) {
+	verifier := newTestSignatureVerifier(t)
+	r := newSignedRequest(t, "erp", "erp-secret", http.MethodGet, "/system/api/v1/orders", "")
+	replay := r.Clone(r.Context())
+
+	first, err := verifier.Verify(r)
+	if err != nil {
+		t.Fatalf("Verify 返回 %v", err)
+	}
+	second, err := verifier.Verify(replay)
+	if err != nil {
+		t.Fatalf("未记录随机串时重复验签返回 %v", err)
+	}
+	if err := verifier.UseNonce(context.Background(), first); err != nil {
+		t.Fatalf("UseNonce 返回 %v", err)
+	}
+	if err := verifier.UseNonce(context.Background(), second); !errors.Is(err, ErrSigningReplay) {
+		t.Fatalf("重放请求返回 %v，期望 ErrSigningReplay", err)
+	}
+}
+
+// Redis 不可用时拒绝请求
+func TestSignatureNonceWithoutRedis(t *testing.T) {
+	verifier := NewSignatureVerifier(nil)
+	if err := verifier.UseNonce(context.Background(), &SignedApp{AppID: "erp", nonce: "n", nonceTTL: time.Minute}); !errors.Is(err, ErrSigningUnavailable) {
+		t.Fatalf("UseNonce 返回 %v，期望 ErrSigningUnavailable", err)
+	}
+}
@@ -0,0 +1,146 @@
+package proxy
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"sky_ISService/config"
+	"sync"
+	"time"
+)
+
+var errStreamNotHijackable = errors.New("ResponseWriter 不支持 Hijack")
+
+// StreamLimiter 长连接（WebSocket、SSE）管理：每个客户端 IP 的连接数限制与空闲超时
+type StreamLimiter struct {
+	mu       sync.Mutex
+	settings config.StreamingConfig
+	conns    map[string]int // 客户端 IP -> 当前长连接数
+}
+
+// NewStreamLimiter 创建长连接管理器，未配置的项使用默认值
+func NewStreamLimiter(settings config.StreamingConfig) *StreamLimiter {
+	return &StreamLimiter{settings: withStreamDefaults(settings), conns: make(map[string]int)}
+}
+
+// 未配置的项使用默认值
+func withStreamDefaults(settings config.StreamingConfig) config.StreamingConfig {
+	if settings.IdleTimeout <= 0 {
+		settings.IdleTimeout = 5 * time.Minute
+	}
+	if settings.MaxConnsPerIP <= 0 {
+		settings.MaxConnsPerIP = 20
+	}
+	return settings
+}
+
+// SetSettings 更新配置（热加载），已建立的长连接沿用原来的空闲超时
+func (l *StreamLimiter) SetSettings(settings config.StreamingConfig) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.settings = withStreamDefaults(settings)
+}
+
+// Acquire 占用一个长连接名额，超过限制时返回 false；成功时返回的 release 必须在连接结束时调用
+func (l *StreamLimiter) Acquire(clientIP string) (release func(), ok bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.conns[clientIP] >= l.settings.MaxConnsPerIP {
+		return nil, false
+	}
+	l.conns[clientIP]++
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			l.mu.Lock()
+			defer l.mu.Unlock()
+			if l.conns[clientIP] <= 1 {
+				delete(l.conns, clientIP)
+				return
+			}
+			l.conns[clientIP]--
+		})
+	}, true
+}
+
+// Wrap 包装 ResponseWriter：SSE 超过空闲时间没有输出时取消请求，WebSocket 劫持后的连接双向空闲时关闭
+func (l *StreamLimiter) Wrap(w http.ResponseWriter, r *http.Request) (http.ResponseWriter, *http.Request, func()) {
+	l.mu.Lock()
+	idle := l.settings.IdleTimeout
+	l.mu.Unlock()
+
+	ctx, cancel := context.WithCancel(r.Context())
+	writer := &streamWriter{
+		ResponseWriter: w,
+		idle:           idle,
+		timer:          time.AfterFunc(idle, cancel),
+	}
+	return writer, r.WithContext(ctx), func() {
+		writer.timer.Stop()
+		cancel()
+	}
+}
+
+// FlushInterval 普通响应的刷新间隔
+func (l *StreamLimiter) FlushInterval() time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.settings.FlushInterval
+}
+
+// 带空闲超时的 ResponseWriter
+type streamWriter struct {
+	http.ResponseWriter
+	idle  time.Duration
+	timer *time.Timer
+}
+
+func (w *streamWriter) Write(b []byte) (int, error) {
+	w.timer.Reset(w.idle)
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *streamWriter) Flush() {
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// Hijack WebSocket 升级时由 ReverseProxy 调用，之后的空闲超时交给连接自身的读写截止时间
+func (w *streamWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hijacker, ok := w.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, errStreamNotHijackable
+	}
+	conn, brw, err := hijacker.Hijack()
+	if err != nil {
+		return nil, nil, err
+	}
+	w.timer.Stop()
+	idle := &idleConn{Conn: conn, idle: w.idle}
+	idle.touch()
+	return idle, brw, nil
+}
+
+// 每次读写都顺延截止时间的连接，双向都没有数据超过 idle 时读写返回超时错误
+type idleConn struct {
+	net.Conn
+	idle time.Duration
+}
+
+func (c *idleConn) Read(b []byte) (int, error) {
+	c.touch()
+	return c.Conn.Read(b)
+}
+
+func (c *idleConn) Write(b []byte) (int, error) {
+	c.touch()
+	return c.Conn.Write(b)
+}
+
+func (c *idleConn) touch() {
+	_ = c.Conn.SetDeadline(time.Now().Add(c.idle))
+}
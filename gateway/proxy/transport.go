@@ -0,0 +1,204 @@
+package proxy
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"sky_ISService/config"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"golang.org/x/net/http2"
+)
+
+// 上游连接池默认配置
+const (
+	defaultMaxIdleConns          = 1024
+	defaultMaxIdleConnsPerHost   = 128
+	defaultIdleConnTimeout       = 90 * time.Second
+	defaultDialTimeout           = 5 * time.Second
+	defaultKeepAlive             = 30 * time.Second
+	defaultTLSHandshakeTimeout   = 5 * time.Second
+	defaultResponseHeaderTimeout = 30 * time.Second
+	h2cReadIdleTimeout           = 30 * time.Second // h2c 连接空闲多久后发送 PING 检测
+	h2cPingTimeout               = 15 * time.Second
+	proxyBufferSize              = 32 << 10 // 转发响应体时使用的缓冲区大小
+)
+
+// upstreamTransports 所有上游节点共用的连接池
+type upstreamTransports struct {
+	http1     *http.Transport  // HTTP/1.1，WebSocket 升级与 SSE 使用
+	h2c       *http2.Transport // 明文 HTTP/2，未启用时为 nil
+	inProcess sync.Map         // 进程内节点地址 -> http.Handler（见 MountInProcess）
+	mounted   atomic.Bool      // 是否挂载了进程内节点
+}
+
+// 按配置创建连接池，未配置的项使用默认值
+func newUpstreamTransports(settings config.TransportConfig) *upstreamTransports {
+	if settings.MaxIdleConns <= 0 {
+		settings.MaxIdleConns = defaultMaxIdleConns
+	}
+	if settings.MaxIdleConnsPerHost <= 0 {
+		settings.MaxIdleConnsPerHost = defaultMaxIdleConnsPerHost
+	}
+	if settings.IdleConnTimeout <= 0 {
+		settings.IdleConnTimeout = defaultIdleConnTimeout
+	}
+	if settings.DialTimeout <= 0 {
+		settings.DialTimeout = defaultDialTimeout
+	}
+	if settings.KeepAlive <= 0 {
+		settings.KeepAlive = defaultKeepAlive
+	}
+	if settings.TLSHandshakeTimeout <= 0 {
+		settings.TLSHandshakeTimeout = defaultTLSHandshakeTimeout
+	}
+	if settings.ResponseHeaderTimeout <= 0 {
+		settings.ResponseHeaderTimeout = defaultResponseHeaderTimeout
+	}
+
+	dialer := &net.Dialer{
+		Timeout:   settings.DialTimeout,
+		KeepAlive: settings.KeepAlive,
+	}
+	transports := &upstreamTransports{
+		http1: &http.Transport{
+			Proxy:                 http.ProxyFromEnvironment,
+			DialContext:           dialer.DialContext,
+			MaxIdleConns:          settings.MaxIdleConns,
+			MaxIdleConnsPerHost:   settings.MaxIdleConnsPerHost,
+			MaxConnsPerHost:       settings.MaxConnsPerHost,
+			IdleConnTimeout:       settings.IdleConnTimeout,
+			TLSHandshakeTimeout:   settings.TLSHandshakeTimeout,
+			ResponseHeaderTimeout: settings.ResponseHeaderTimeout,
+			ExpectContinueTimeout: time.Second,
+			ForceAttemptHTTP2:     true, // https 上游通过 ALPN 协商 HTTP/2
+		},
+	}
+	if settings.H2C {
+		transports.h2c = &http2.Transport{
+			AllowHTTP: true,
+			// 明文 HTTP/2（prior knowledge）：直接建立 TCP 连接，不进行 TLS 握手
+			DialTLSContext: func(ctx context.Context, network, addr string, _ *tls.Config) (net.Conn, error) {
+				return dialer.DialContext(ctx, network, addr)
+			},
+			IdleConnTimeout: settings.IdleConnTimeout,
+			ReadIdleTimeout: h2cReadIdleTimeout,
+			PingTimeout:     h2cPingTimeout,
+		}
+	}
+	return transports
+}
+
+// 请求使用的连接池：长连接（需要协议升级或逐条刷新）始终使用 HTTP/1.1；挂载了进程内节点时先匹配进程内节点
+func (t *upstreamTransports) forRequest(streaming bool) http.RoundTripper {
+	var transport http.RoundTripper = t.http1
+	if t.h2c != nil && !streaming {
+		transport = t.h2c
+	}
+	if t.mounted.Load() {
+		return &inProcessTransport{handlers: &t.inProcess, next: transport}
+	}
+	return transport
+}
+
+// 关闭所有空闲连接
+func (t *upstreamTransports) closeIdleConnections() {
+	t.http1.CloseIdleConnections()
+	if t.h2c != nil {
+		t.h2c.CloseIdleConnections()
+	}
+}
+
+// 转发响应体使用的缓冲区池（实现 httputil.BufferPool）。
+// 池中保存 *[]byte，避免存取切片时装箱分配；Get 取出缓冲区后回收空的指针，Put 时复用，稳定运行时不再分配
+type proxyBufferPool struct {
+	buffers  sync.Pool // *[]byte，指向可用的缓冲区
+	pointers sync.Pool // *[]byte，已取出缓冲区的空指针
+}
+
+func newProxyBufferPool() *proxyBufferPool {
+	return &proxyBufferPool{buffers: sync.Pool{New: func() interface{} {
+		buf := make([]byte, proxyBufferSize)
+		return &buf
+	}}}
+}
+
+func (b *proxyBufferPool) Get() []byte {
+	ptr := b.buffers.Get().(*[]byte)
+	buf := *ptr
+	*ptr = nil
+	b.pointers.Put(ptr)
+	return buf
+}
+
+func (b *proxyBufferPool) Put(buf []byte) {
+	// 只回收本池分配的缓冲区
+	if cap(buf) != proxyBufferSize {
+		return
+	}
+	ptr, _ := b.pointers.Get().(*[]byte)
+	if ptr == nil {
+		ptr = new([]byte)
+	}
+	*ptr = buf[:proxyBufferSize]
+	b.buffers.Put(ptr)
+}
+
+// 缓存的反向代理共用的 Transport，从请求上下文中取出本次请求的上游调用
+type callTransport struct{}
+
+func (callTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	return upstreamCallFromContext(req.Context()).RoundTrip(req)
+}
+
+// 获取节点的反向代理，首次使用时创建并缓存；请求相关的状态通过请求上下文中的 upstreamCall 传递
+func (p *Proxy) reverseProxyFor(addr string) *httputil.ReverseProxy {
+	if cached, ok := p.reverseProxies.Load(addr); ok {
+		return cached.(*httputil.ReverseProxy)
+	}
+
+	proxy := p.NewHttpReverseProxy(&url.URL{Scheme: "http", Host: addr})
+	proxy.Transport = callTransport{}
+	proxy.BufferPool = p.buffers
+	proxy.FlushInterval = p.streams.FlushInterval() // SSE 等流式响应 ReverseProxy 会立即刷新；配置变化后缓存会被清空，按新值重新创建
+	proxy.ModifyResponse = p.modifyResponse
+	proxy.ErrorHandler = p.handleProxyError
+	cached, _ := p.reverseProxies.LoadOrStore(addr, proxy)
+	return cached.(*httputil.ReverseProxy)
+}
+
+// 清空缓存的反向代理，刷新间隔等配置变化后按新配置重新创建
+func (p *Proxy) resetReverseProxies() {
+	p.reverseProxies.Range(func(key, _ interface{}) bool {
+		p.reverseProxies.Delete(key)
+		return true
+	})
+}
+
+// 移除已下线节点的反向代理（调用方需持有锁）
+func (p *Proxy) pruneReverseProxies() {
+	active := make(map[string]bool)
+	for _, services := range []map[string][]*WeightedNode{p.services, p.staticServices} {
+		for _, nodes := range services {
+			for _, node := range nodes {
+				active[node.addr] = true
+			}
+		}
+	}
+	p.reverseProxies.Range(func(key, _ interface{}) bool {
+		if !active[key.(string)] {
+			p.reverseProxies.Delete(key)
+		}
+		return true
+	})
+}
+
+// CloseIdleConnections 关闭上游连接池中的空闲连接
+func (p *Proxy) CloseIdleConnections() {
+	p.transports.closeIdleConnections()
+}
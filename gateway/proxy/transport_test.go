@@ -0,0 +1,129 @@
+package proxy
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sky_ISService/config"
+	"sky_ISService/shared/cache"
+	"strings"
+	"testing"
+	"time"
+)
+
+// 返回 1KB 响应体的上游服务
+func newBenchmarkUpstream(b *testing.B) *url.URL {
+	body := strings.Repeat("x", 1<<10)
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, body)
+	}))
+	b.Cleanup(upstream.Close)
+	target, _ := url.Parse(upstream.URL)
+	return target
+}
+
+// 转发到 target 的网关：/system 为无需认证的路由，system 服务只有 target 一个节点，关闭访问控制与限流
+func newTestProxy(tb testing.TB, target *url.URL) *Proxy {
+	public := false
+	prev := config.GetConfig()
+	next := *prev
+	next.Identity.Secret = "test-secret"
+	next.Gateway.Routes = []config.RouteConfig{{Name: "system", Prefix: "/system", Service: "system", AuthRequired: &public}}
+	config.Use(&next)
+	tb.Cleanup(func() { config.Use(prev) })
+
+	redisClient, err := cache.NewMemoryRedis()
+	if err != nil {
+		tb.Fatalf("启动进程内 Redis 失败: %v", err)
+	}
+	tb.Cleanup(func() { _ = redisClient.Close() })
+	p := NewProxy(redisClient)
+	p.EnableBlacklist, p.EnableRestrictedRoutes, p.EnableRateLimiting = false, false, false
+	p.SetServiceNodes("system", []*WeightedNode{{addr: target.Host, weight: 1}})
+	tb.Cleanup(p.CloseIdleConnections)
+	return p
+}
+
+// 并发转发 GET 请求，检查每个响应都成功
+func runProxyBenchmark(b *testing.B, serve func(w http.ResponseWriter, r *http.Request)) {
+	b.ReportAllocs()
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			w := httptest.NewRecorder()
+			serve(w, httptest.NewRequest(http.MethodGet, "/system/menu", nil))
+			if w.Code != http.StatusOK {
+				b.Errorf("转发失败: %d %s", w.Code, w.Body.String())
+				return
+			}
+		}
+	})
+}
+
+// 优化前：同样经过 Proxy.ServeHTTP，但每个请求重新创建反向代理，使用默认连接池（每个上游最多 2 个空闲连接）
+func BenchmarkProxyServeHTTPPerRequestReverseProxy(b *testing.B) {
+	target := newBenchmarkUpstream(b)
+	p := newTestProxy(b, target)
+	p.transports.http1 = http.DefaultTransport.(*http.Transport).Clone()
+	runProxyBenchmark(b, func(w http.ResponseWriter, r *http.Request) {
+		p.resetReverseProxies()
+		p.ServeHTTP(w, r)
+	})
+}
+
+// 优化后：Proxy.ServeHTTP 使用按节点缓存的反向代理、网关的上游连接池与缓冲区池
+func BenchmarkProxyServeHTTP(b *testing.B) {
+	target := newBenchmarkUpstream(b)
+	p := newTestProxy(b, target)
+	runProxyBenchmark(b, p.ServeHTTP)
+}
+
+// 缓冲区池存取不应分配内存
+func BenchmarkProxyBufferPool(b *testing.B) {
+	pool := newProxyBufferPool()
+	pool.Put(pool.Get())
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		pool.Put(pool.Get())
+	}
+}
+
+func TestProxyBufferPoolReusesBuffers(t *testing.T) {
+	pool := newProxyBufferPool()
+	buf := pool.Get()
+	if len(buf) != proxyBufferSize {
+		t.Fatalf("缓冲区大小为 %d，期望 %d", len(buf), proxyBufferSize)
+	}
+	pool.Put(buf)
+	// 热身后存取不再分配（sync.Pool 在 GC 时可能清空，这里只检查平均值）
+	allocs := testing.AllocsPerRun(100, func() {
+		pool.Put(pool.Get())
+	})
+	if allocs > 0.1 {
+		t.Fatalf("每次存取平均分配 %.2f 次", allocs)
+	}
+
+	// 其他大小的缓冲区不会被回收
+	pool.Put(make([]byte, 16))
+	if got := pool.Get(); len(got) != proxyBufferSize {
+		t.Fatalf("取出了不属于池的缓冲区，大小为 %d", len(got))
+	}
+}
+
+// 热加载修改刷新间隔后，缓存的反向代理按新值重新创建
+func TestReverseProxyForUsesReloadedFlushInterval(t *testing.T) {
+	p := &Proxy{buffers: newProxyBufferPool(), streams: NewStreamLimiter(config.StreamingConfig{FlushInterval: time.Second})}
+	if got := p.reverseProxyFor("127.0.0.1:8081").FlushInterval; got != time.Second {
+		t.Fatalf("刷新间隔为 %v，期望 1s", got)
+	}
+
+	prev, next := &config.InitStructureConfig{}, &config.InitStructureConfig{}
+	prev.Gateway.Streaming.FlushInterval = time.Second
+	next.Gateway.Streaming.FlushInterval = -1
+	p.applyConfig(prev, next)
+	if got := p.reverseProxyFor("127.0.0.1:8081").FlushInterval; got != -1 {
+		t.Fatalf("热加载后刷新间隔为 %v，期望 -1", got)
+	}
+}
@@ -2,13 +2,31 @@ package router
 
 import (
 	"github.com/gin-gonic/gin"
+	"sky_ISService/gateway/controller"
 	"sky_ISService/gateway/proxy"
+	"sky_ISService/pkg/metrics"
+	"sky_ISService/pkg/supervisor"
 )
 
-// NewRouter 创建一个新的 Gin 引擎实例，并将代理功能集成进去
-func NewRouter(p *proxy.Proxy) *gin.Engine {
+// NewRouter 创建一个新的 Gin 引擎实例，并将代理功能集成进去。
+// 全局中间件需在注册路由之前添加，Gin 的路由只使用注册时已添加的中间件
+func NewRouter(p *proxy.Proxy, s *supervisor.Supervisor, middlewares ...gin.HandlerFunc) *gin.Engine {
 	r := gin.Default()
 
+	// Prometheus 监控指标，不经过全局中间件（无需认证，抓取请求也不计入请求指标）
+	r.GET(metrics.Path, gin.WrapH(metrics.Handler()))
+
+	r.Use(middlewares...)
+
+	// 网关自身的管理接口
+	controller.NewHealthController(p).HealthControllerRoutes(r)
+	controller.NewACLController(p).ACLControllerRoutes(r)
+	controller.NewRouteController(p).RouteControllerRoutes(r)
+	controller.NewCacheController(p).CacheControllerRoutes(r)
+	controller.NewCanaryController(p).CanaryControllerRoutes(r)
+	controller.NewAPIKeyController(p).APIKeyControllerRoutes(r)
+	controller.NewSupervisorController(s).SupervisorControllerRoutes(r)
+
 	// 使用动态路径来代理请求
 	r.NoRoute(func(c *gin.Context) {
 		p.ServeHTTP(c.Writer, c.Request)
@@ -4,10 +4,11 @@ import (
 	"github.com/gin-gonic/gin"
 	ginSwaggerFiles "github.com/swaggo/files"
 	ginSwagger "github.com/swaggo/gin-swagger"
+	"sky_ISService/pkg/middleware"
 )
 
 // InitSwagger 用于初始化 Swagger 配置
 func InitSwagger(r *gin.Engine) {
-	// 注册 Swagger UI 路由
-	r.GET("/swagger/*any", ginSwagger.WrapHandler(ginSwaggerFiles.Handler))
+	// 注册 Swagger UI 路由（除 index.html 外需要 JWT 认证）
+	r.GET("/swagger/*any", middleware.JWTAuthMiddleware(), ginSwagger.WrapHandler(ginSwaggerFiles.Handler))
 }
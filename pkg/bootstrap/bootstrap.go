@@ -0,0 +1,177 @@
+package bootstrap
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/hashicorp/consul/api"
+	"go.uber.org/fx"
+	"gorm.io/gorm"
+	"log"
+	"net"
+	"net/http"
+	"sky_ISService/config"
+	"sky_ISService/pkg/initialize"
+	"sky_ISService/pkg/metrics"
+	"sky_ISService/pkg/middleware"
+	"sky_ISService/pkg/shutdown"
+	"sky_ISService/pkg/tracing"
+	"sky_ISService/shared/elasticsearch"
+	postgres "sky_ISService/shared/postgresql"
+	consul "sky_ISService/shared/registerservice"
+	"sky_ISService/utils"
+	"strconv"
+)
+
+// Run 初始化链路追踪并启动 fx 应用，收到 SIGINT/SIGTERM 后按注册的逆序关闭；启动或关闭失败时返回错误
+func Run(serviceName string, opts ...fx.Option) error {
+	if err := tracing.Init(serviceName, config.GetConfig().Tracing); err != nil {
+		return fmt.Errorf("链路追踪初始化失败: %v", err)
+	}
+	defer tracing.Shutdown()
+
+	app := fx.New(opts...)
+	if err := app.Err(); err != nil {
+		return err
+	}
+	startCtx, cancel := context.WithTimeout(context.Background(), app.StartTimeout())
+	defer cancel()
+	if err := app.Start(startCtx); err != nil {
+		return fmt.Errorf("服务 %s 启动失败: %v", serviceName, err)
+	}
+
+	signal := <-app.Wait()
+	log.Printf("收到信号 %s，服务 %s 开始关闭", signal.Signal, serviceName)
+	stopCtx, cancel := context.WithTimeout(context.Background(), app.StopTimeout())
+	defer cancel()
+	return app.Stop(stopCtx)
+}
+
+// Infrastructure 初始化 Elasticsearch、Redis 和 RabbitMQ 客户端并提供到容器中，应用关闭时关闭 Redis、RabbitMQ 连接
+func Infrastructure() (fx.Option, error) {
+	esClient, redisClient, rmqClient, err := initialize.InitServices()
+	if err != nil {
+		return nil, fmt.Errorf("服务初始化失败: %v", err)
+	}
+	return fx.Options(
+		fx.Supply(esClient, redisClient, rmqClient),
+		fx.Invoke(shutdown.CloseServices),
+	), nil
+}
+
+// Database 提供服务的 PostgreSQL 连接（database.<serviceName> 配置）
+func Database(serviceName string) fx.Option {
+	return fx.Provide(func() (*gorm.DB, error) {
+		db, err := postgres.InitPostgresConfig(serviceName)
+		if err != nil {
+			return nil, fmt.Errorf("PostgreSQL 初始化失败: %v", err)
+		}
+		return db, nil
+	})
+}
+
+// HealthPath 服务的健康检查路径，与网关默认的探测路径（gateway.health_check.path）一致
+const HealthPath = "/health"
+
+// Engine 提供服务的 Gin 引擎，注册各服务通用的中间件：请求 ID 与链路追踪、请求指标、网关注入的调用方身份、数据库、请求日志
+func Engine(serviceName string) fx.Option {
+	return fx.Provide(NewEngine(serviceName))
+}
+
+// NewEngine 返回创建服务 Gin 引擎的构造函数（中间件同 Engine），用于需要额外 fx 注解的场景
+func NewEngine(serviceName string) func(db *gorm.DB, elasticClient *elasticsearch.ElasticsearchClient) *gin.Engine {
+	return func(db *gorm.DB, elasticClient *elasticsearch.ElasticsearchClient) *gin.Engine {
+		r := gin.Default()
+		r.Use(middleware.TracingMiddleware())
+		r.Use(middleware.MetricsMiddleware(nil))
+		// 健康检查与指标接口由网关探测、Prometheus 直接抓取，不经过网关，不需要调用方身份
+		r.GET(HealthPath, func(c *gin.Context) {
+			utils.Success(c, gin.H{"service": serviceName})
+		})
+		r.GET(metrics.Path, gin.WrapH(metrics.Handler()))
+		r.Use(middleware.IdentityMiddleware(serviceName)) // 网关注入的调用方身份
+		r.Use(middleware.DBMiddleware(db))
+		r.Use(middleware.LoggerMiddleware(serviceName, elasticClient))
+		return r
+	}
+}
+
+// Consul 提供 Consul 客户端
+func Consul() fx.Option {
+	return fx.Provide(func() (*api.Client, error) {
+		client, err := consul.InitConsul()
+		if err != nil {
+			return nil, fmt.Errorf("Consul 初始化失败: %v", err)
+		}
+		return client, nil
+	})
+}
+
+// Instance 注册到 Consul 的服务实例（一个监听端口）
+type Instance struct {
+	Port   string
+	Weight int
+}
+
+// Register 启动时将服务的每个监听端口注册为一个 Consul 实例（附带端口健康检查），网关按 weight 元数据加权、按 version 元数据划分灰度分组；
+// 关闭时注销实例。需放在 HTTPServer 之后：启动时端口开始监听后才注册，关闭时先注销再停止监听
+func Register(serviceName, addr, version string, instances ...Instance) fx.Option {
+	return fx.Invoke(func(lc fx.Lifecycle, client *api.Client) {
+		var registered []string
+		lc.Append(fx.Hook{
+			OnStart: func(ctx context.Context) error {
+				for _, instance := range instances {
+					port, err := strconv.Atoi(instance.Port)
+					if err != nil {
+						return fmt.Errorf("无效的服务端口 %s: %v", instance.Port, err)
+					}
+					serviceID := fmt.Sprintf("%s-%d", serviceName, port)
+					meta := map[string]string{consul.MetaWeight: strconv.Itoa(instance.Weight)}
+					if version != "" {
+						meta[consul.MetaVersion] = version
+					}
+					if err := consul.RegisterServiceConsulWithMeta(client, serviceName, serviceID, addr, port, meta); err != nil {
+						return fmt.Errorf("服务注册失败: %v", err)
+					}
+					registered = append(registered, serviceID)
+				}
+				return nil
+			},
+			OnStop: func(ctx context.Context) error {
+				var errs []error
+				for _, serviceID := range registered {
+					if err := consul.DeregisterServiceConsul(client, serviceID); err != nil {
+						errs = append(errs, err)
+					}
+				}
+				return errors.Join(errs...)
+			},
+		})
+	})
+}
+
+// HTTPServer 启动时在每个地址上监听并提供 Gin 引擎，端口被占用时启动失败；关闭时停止接收新请求并等待处理中的请求完成
+func HTTPServer(addrs ...string) fx.Option {
+	return fx.Invoke(func(lc fx.Lifecycle, r *gin.Engine) {
+		for _, addr := range addrs {
+			server := &http.Server{Addr: addr, Handler: r}
+			lc.Append(fx.Hook{
+				OnStart: func(ctx context.Context) error {
+					listener, err := net.Listen("tcp", server.Addr)
+					if err != nil {
+						return fmt.Errorf("监听 %s 失败: %v", server.Addr, err)
+					}
+					log.Printf("HTTP 服务开始监听 %s", server.Addr)
+					go func() {
+						if err := server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+							log.Printf("HTTP 服务 %s 异常退出: %v", server.Addr, err)
+						}
+					}()
+					return nil
+				},
+				OnStop: server.Shutdown,
+			})
+		}
+	})
+}
@@ -0,0 +1,61 @@
+package bootstrap
+
+import (
+	"fmt"
+	"go.uber.org/fx"
+	"gorm.io/gorm"
+	"sky_ISService/config"
+	"sky_ISService/pkg/metrics"
+	"sky_ISService/pkg/shutdown"
+	"sky_ISService/shared/cache"
+	"sky_ISService/shared/elasticsearch"
+	"sky_ISService/shared/mq"
+	postgres "sky_ISService/shared/postgresql"
+	"sky_ISService/shared/sqlite"
+)
+
+// 开发模式默认的 SQLite 数据库文件
+const defaultDevSQLitePath = "data/sky-dev.db"
+
+// DevInfrastructure 提供进程内的 Elasticsearch、Redis 和 RabbitMQ 替代实现（开发模式），不连接外部服务；应用关闭时释放
+func DevInfrastructure() (fx.Option, error) {
+	redisClient, err := cache.NewMemoryRedis()
+	if err != nil {
+		return nil, err
+	}
+	rmqClient := mq.NewMemoryRabbitMQ()
+
+	// 注册 Redis 连接池监控指标
+	metrics.RegisterRedis(redisClient)
+
+	return fx.Options(
+		fx.Supply(elasticsearch.NewMemoryElasticsearch(), redisClient, rmqClient),
+		fx.Invoke(shutdown.CloseServices),
+	), nil
+}
+
+// DevDatabase 打开开发模式下服务的数据库：dev.database 为 sqlite（默认）时所有服务共用 dev.sqlite_path 文件，
+// 为 postgres 时使用 database.<serviceName> 的 PostgreSQL 配置
+func DevDatabase(serviceName string) (*gorm.DB, error) {
+	devConfig := config.GetConfig().Dev
+	switch devConfig.Database {
+	case "", "sqlite":
+		path := devConfig.SQLitePath
+		if path == "" {
+			path = defaultDevSQLitePath
+		}
+		db, err := sqlite.InitSQLite(serviceName, path)
+		if err != nil {
+			return nil, fmt.Errorf("SQLite 初始化失败: %v", err)
+		}
+		return db, nil
+	case "postgres":
+		db, err := postgres.InitPostgresConfig(serviceName)
+		if err != nil {
+			return nil, fmt.Errorf("PostgreSQL 初始化失败: %v", err)
+		}
+		return db, nil
+	default:
+		return nil, fmt.Errorf("dev.database 无效: %s", devConfig.Database)
+	}
+}
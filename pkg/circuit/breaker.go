@@ -0,0 +1,334 @@
+package circuit
+
+import (
+	"errors"
+	"sky_ISService/config"
+	"sort"
+	"sync"
+	"time"
+)
+
+// State 熔断器状态
+type State string
+
+const (
+	StateClosed   State = "closed"    // 正常放行，统计失败率
+	StateOpen     State = "open"      // 熔断，拒绝所有请求
+	StateHalfOpen State = "half_open" // 半开，放行少量试探请求
+)
+
+// Outcome 请求结果
+type Outcome int
+
+const (
+	Success Outcome = iota // 成功
+	Failure                // 失败，计入失败率
+	Ignored                // 不计入统计（如客户端主动取消）
+)
+
+// 滚动窗口的分桶数
+const windowBuckets = 10
+
+var (
+	ErrOpen            = errors.New("熔断器已打开")
+	ErrTooManyRequests = errors.New("熔断器半开状态试探请求已满")
+)
+
+// 滚动窗口中的一个分桶
+type bucket struct {
+	start    time.Time
+	requests int
+	failures int
+}
+
+// Snapshot 熔断器状态快照
+type Snapshot struct {
+	Name         string     `json:"name"`
+	State        State      `json:"state"`
+	Requests     int        `json:"requests"`      // 当前窗口内的请求数
+	Failures     int        `json:"failures"`      // 当前窗口内的失败数
+	FailureRatio float64    `json:"failure_ratio"` // 当前窗口内的失败率
+	OpenedAt     *time.Time `json:"opened_at,omitempty"`
+	Rejected     int64      `json:"rejected"` // 累计拒绝的请求数
+}
+
+// Breaker 熔断器：关闭状态下按滚动窗口统计失败率，达到阈值后打开；
+// 打开一段时间后进入半开状态，放行有限的试探请求，全部成功则关闭，任一失败重新打开
+type Breaker struct {
+	name          string
+	settings      config.CircuitBreakerConfig
+	onStateChange func(name string, from, to State)
+
+	mu               sync.Mutex
+	state            State
+	generation       uint64 // 每次状态变化递增，忽略跨状态完成的请求结果
+	buckets          [windowBuckets]bucket
+	openedAt         time.Time
+	halfOpenInflight int
+	halfOpenSuccess  int
+	rejected         int64
+}
+
+// NewBreaker 创建熔断器，未配置的项使用默认值
+func NewBreaker(name string, settings config.CircuitBreakerConfig) *Breaker {
+	return &Breaker{name: name, settings: withDefaults(settings), state: StateClosed}
+}
+
+// 未配置的项使用默认值
+func withDefaults(settings config.CircuitBreakerConfig) config.CircuitBreakerConfig {
+	if settings.FailureRatio <= 0 || settings.FailureRatio > 1 {
+		settings.FailureRatio = 0.5
+	}
+	if settings.MinRequests <= 0 {
+		settings.MinRequests = 20
+	}
+	if settings.Window <= 0 {
+		settings.Window = 10 * time.Second
+	}
+	if settings.OpenTimeout <= 0 {
+		settings.OpenTimeout = 10 * time.Second
+	}
+	if settings.HalfOpenRequests <= 0 {
+		settings.HalfOpenRequests = 3
+	}
+	return settings
+}
+
+// SetSettings 更新配置（配置文件重新加载后调用），未配置的项使用默认值；当前状态与窗口内的统计保留
+func (b *Breaker) SetSettings(settings config.CircuitBreakerConfig) {
+	settings = withDefaults(settings)
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.settings = settings
+}
+
+// Name 熔断器名称
+func (b *Breaker) Name() string {
+	return b.name
+}
+
+// Allow 判断是否放行请求，放行时返回的 done 必须在请求结束时调用且只调用一次
+func (b *Breaker) Allow() (done func(Outcome), err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := time.Now()
+	b.refreshLocked(now)
+	switch b.state {
+	case StateOpen:
+		b.rejected++
+		return nil, ErrOpen
+	case StateHalfOpen:
+		if b.halfOpenInflight >= b.settings.HalfOpenRequests {
+			b.rejected++
+			return nil, ErrTooManyRequests
+		}
+		b.halfOpenInflight++
+	}
+
+	generation := b.generation
+	var once sync.Once
+	return func(outcome Outcome) {
+		once.Do(func() { b.done(generation, outcome) })
+	}, nil
+}
+
+// Ready 当前是否会放行请求：打开状态、半开状态下试探请求已满时返回 false。只做判断，不占用试探名额
+func (b *Breaker) Ready() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.refreshLocked(time.Now())
+	switch b.state {
+	case StateOpen:
+		return false
+	case StateHalfOpen:
+		return b.halfOpenInflight < b.settings.HalfOpenRequests
+	default:
+		return true
+	}
+}
+
+// State 当前状态
+func (b *Breaker) State() State {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.refreshLocked(time.Now())
+	return b.state
+}
+
+// Snapshot 返回状态快照
+func (b *Breaker) Snapshot() Snapshot {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := time.Now()
+	b.refreshLocked(now)
+	requests, failures := b.countsLocked(now)
+	snapshot := Snapshot{
+		Name:     b.name,
+		State:    b.state,
+		Requests: requests,
+		Failures: failures,
+		Rejected: b.rejected,
+	}
+	if requests > 0 {
+		snapshot.FailureRatio = float64(failures) / float64(requests)
+	}
+	if b.state != StateClosed {
+		openedAt := b.openedAt
+		snapshot.OpenedAt = &openedAt
+	}
+	return snapshot
+}
+
+// 记录请求结果
+func (b *Breaker) done(generation uint64, outcome Outcome) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	// 请求期间状态已经变化，结果不再有参考意义
+	if generation != b.generation {
+		return
+	}
+
+	now := time.Now()
+	switch b.state {
+	case StateClosed:
+		if outcome == Ignored {
+			return
+		}
+		current := b.bucketLocked(now)
+		current.requests++
+		if outcome == Failure {
+			current.failures++
+			requests, failures := b.countsLocked(now)
+			if requests >= b.settings.MinRequests && float64(failures)/float64(requests) >= b.settings.FailureRatio {
+				b.setStateLocked(StateOpen, now)
+			}
+		}
+	case StateHalfOpen:
+		b.halfOpenInflight--
+		switch outcome {
+		case Failure:
+			b.setStateLocked(StateOpen, now)
+		case Success:
+			b.halfOpenSuccess++
+			if b.halfOpenSuccess >= b.settings.HalfOpenRequests {
+				b.setStateLocked(StateClosed, now)
+			}
+		}
+	}
+}
+
+// 打开状态到期后转为半开（调用方需持有锁）
+func (b *Breaker) refreshLocked(now time.Time) {
+	if b.state == StateOpen && now.Sub(b.openedAt) >= b.settings.OpenTimeout {
+		b.setStateLocked(StateHalfOpen, now)
+	}
+}
+
+// 切换状态并重置统计（调用方需持有锁）
+func (b *Breaker) setStateLocked(state State, now time.Time) {
+	from := b.state
+	b.state = state
+	b.generation++
+	b.halfOpenInflight = 0
+	b.halfOpenSuccess = 0
+	switch state {
+	case StateOpen:
+		b.openedAt = now
+	case StateClosed:
+		b.buckets = [windowBuckets]bucket{}
+	}
+	if b.onStateChange != nil {
+		// 回调可能较慢，不阻塞请求路径
+		go b.onStateChange(b.name, from, state)
+	}
+}
+
+// 当前时间所在的分桶，过期的分桶会被重置（调用方需持有锁）
+func (b *Breaker) bucketLocked(now time.Time) *bucket {
+	width := b.settings.Window / windowBuckets
+	start := now.Truncate(width)
+	current := &b.buckets[(start.UnixNano()/int64(width))%windowBuckets]
+	if !current.start.Equal(start) {
+		*current = bucket{start: start}
+	}
+	return current
+}
+
+// 统计窗口内的请求数和失败数（调用方需持有锁）
+func (b *Breaker) countsLocked(now time.Time) (requests, failures int) {
+	for _, item := range b.buckets {
+		if now.Sub(item.start) < b.settings.Window {
+			requests += item.requests
+			failures += item.failures
+		}
+	}
+	return requests, failures
+}
+
+// Group 按名称管理一组熔断器，首次使用时按 settings 创建，配置变化后通过 Reload 更新
+type Group struct {
+	settings      func(name string) config.CircuitBreakerConfig
+	onStateChange func(name string, from, to State)
+
+	mu       sync.Mutex
+	breakers map[string]*Breaker
+}
+
+// NewGroup 创建熔断器组
+func NewGroup(settings func(name string) config.CircuitBreakerConfig) *Group {
+	return &Group{settings: settings, breakers: make(map[string]*Breaker)}
+}
+
+// OnStateChange 设置状态变化回调，需在使用熔断器之前调用
+func (g *Group) OnStateChange(fn func(name string, from, to State)) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.onStateChange = fn
+}
+
+// Get 获取（必要时创建）指定名称的熔断器
+func (g *Group) Get(name string) *Breaker {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	breaker, ok := g.breakers[name]
+	if !ok {
+		breaker = NewBreaker(name, g.settings(name))
+		breaker.onStateChange = g.onStateChange
+		g.breakers[name] = breaker
+	}
+	return breaker
+}
+
+// Reload 按 settings 重新读取所有已创建熔断器的配置（配置文件重新加载后调用），熔断器的状态与统计保留
+func (g *Group) Reload() {
+	g.mu.Lock()
+	breakers := make([]*Breaker, 0, len(g.breakers))
+	for _, breaker := range g.breakers {
+		breakers = append(breakers, breaker)
+	}
+	g.mu.Unlock()
+
+	for _, breaker := range breakers {
+		breaker.SetSettings(g.settings(breaker.name))
+	}
+}
+
+// Snapshots 返回所有熔断器的状态快照，按名称排序
+func (g *Group) Snapshots() []Snapshot {
+	g.mu.Lock()
+	breakers := make([]*Breaker, 0, len(g.breakers))
+	for _, breaker := range g.breakers {
+		breakers = append(breakers, breaker)
+	}
+	g.mu.Unlock()
+
+	snapshots := make([]Snapshot, 0, len(breakers))
+	for _, breaker := range breakers {
+		snapshots = append(snapshots, breaker.Snapshot())
+	}
+	sort.Slice(snapshots, func(i, j int) bool { return snapshots[i].Name < snapshots[j].Name })
+	return snapshots
+}
@@ -0,0 +1,199 @@
+package circuit
+
+import (
+	"errors"
+	"sky_ISService/config"
+	"testing"
+	"time"
+)
+
+const testOpenTimeout = 30 * time.Millisecond
+
+// 测试用的熔断配置：至少 4 个请求、失败率 50% 打开，半开放行 2 个试探请求
+func testSettings() config.CircuitBreakerConfig {
+	return config.CircuitBreakerConfig{
+		FailureRatio:     0.5,
+		MinRequests:      4,
+		Window:           time.Minute,
+		OpenTimeout:      testOpenTimeout,
+		HalfOpenRequests: 2,
+	}
+}
+
+// 熔断器上的一步操作：等待后发起请求，立即以 outcome 结束（hold 为 true 时保持请求未结束）
+type step struct {
+	wait      time.Duration
+	outcome   Outcome
+	hold      bool
+	wantErr   error
+	wantState State
+}
+
+func TestBreakerTransitions(t *testing.T) {
+	ok := step{outcome: Success}
+	fail := step{outcome: Failure}
+	tests := []struct {
+		name  string
+		steps []step
+	}{
+		{name: "请求数不足时不打开", steps: []step{
+			{outcome: Failure, wantState: StateClosed},
+			{outcome: Failure, wantState: StateClosed},
+			{outcome: Failure, wantState: StateClosed},
+		}},
+		{name: "失败率达到阈值后打开", steps: []step{
+			ok, ok, fail,
+			{outcome: Failure, wantState: StateOpen},
+			{wantErr: ErrOpen, wantState: StateOpen},
+		}},
+		{name: "失败率低于阈值保持关闭", steps: []step{
+			ok, ok, ok, fail,
+			{outcome: Failure, wantState: StateClosed},
+		}},
+		{name: "忽略的结果不计入统计", steps: []step{
+			fail, fail, fail,
+			{outcome: Ignored, wantState: StateClosed},
+			{outcome: Ignored, wantState: StateClosed},
+		}},
+		{name: "打开超时后半开，试探全部成功后关闭", steps: []step{
+			fail, fail, fail, fail,
+			{wait: testOpenTimeout, outcome: Success, wantState: StateHalfOpen},
+			{outcome: Success, wantState: StateClosed},
+			// 关闭后统计重新开始
+			{outcome: Failure, wantState: StateClosed},
+		}},
+		{name: "半开试探失败重新打开", steps: []step{
+			fail, fail, fail, fail,
+			{wait: testOpenTimeout, outcome: Failure, wantState: StateOpen},
+			{wantErr: ErrOpen, wantState: StateOpen},
+		}},
+		{name: "半开试探请求已满时拒绝", steps: []step{
+			fail, fail, fail, fail,
+			{wait: testOpenTimeout, hold: true, wantState: StateHalfOpen},
+			{hold: true, wantState: StateHalfOpen},
+			{wantErr: ErrTooManyRequests, wantState: StateHalfOpen},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			breaker := NewBreaker("system", testSettings())
+			for i, s := range tt.steps {
+				time.Sleep(s.wait)
+				done, err := breaker.Allow()
+				if !errors.Is(err, s.wantErr) {
+					t.Fatalf("第 %d 步: 得到错误 %v，期望 %v", i+1, err, s.wantErr)
+				}
+				if err == nil && !s.hold {
+					done(s.outcome)
+				}
+				if s.wantState != "" && breaker.State() != s.wantState {
+					t.Fatalf("第 %d 步: 状态为 %s，期望 %s", i+1, breaker.State(), s.wantState)
+				}
+			}
+		})
+	}
+}
+
+func TestBreakerIgnoresStaleOutcome(t *testing.T) {
+	breaker := NewBreaker("system", testSettings())
+	// 打开前发出的请求在熔断器状态变化后才结束
+	stale, err := breaker.Allow()
+	if err != nil {
+		t.Fatalf("关闭状态拒绝请求: %v", err)
+	}
+	for i := 0; i < 4; i++ {
+		done, _ := breaker.Allow()
+		done(Failure)
+	}
+	time.Sleep(testOpenTimeout)
+	if breaker.State() != StateHalfOpen {
+		t.Fatalf("状态为 %s，期望半开", breaker.State())
+	}
+	stale(Failure)
+	if breaker.State() != StateHalfOpen {
+		t.Fatal("状态变化前发出的请求结果影响了半开状态")
+	}
+
+	// done 多次调用只记录一次
+	for i := 0; i < 2; i++ {
+		done, err := breaker.Allow()
+		if err != nil {
+			t.Fatalf("半开状态拒绝试探请求: %v", err)
+		}
+		if i == 0 {
+			done(Success)
+			done(Success)
+			if breaker.State() != StateHalfOpen {
+				t.Fatal("重复调用 done 被计为多次成功")
+			}
+			continue
+		}
+		done(Success)
+	}
+	if breaker.State() != StateClosed {
+		t.Fatalf("状态为 %s，期望关闭", breaker.State())
+	}
+}
+
+func TestBreakerReady(t *testing.T) {
+	breaker := NewBreaker("system", testSettings())
+	if !breaker.Ready() {
+		t.Fatal("关闭状态应当就绪")
+	}
+	for i := 0; i < 4; i++ {
+		done, _ := breaker.Allow()
+		done(Failure)
+	}
+	if breaker.Ready() {
+		t.Fatal("打开状态不应就绪")
+	}
+
+	time.Sleep(testOpenTimeout)
+	for i := 0; i < 2; i++ {
+		// Ready 不占用试探名额
+		if !breaker.Ready() {
+			t.Fatalf("半开状态第 %d 个试探请求前应当就绪", i+1)
+		}
+		if _, err := breaker.Allow(); err != nil {
+			t.Fatalf("半开状态拒绝试探请求: %v", err)
+		}
+	}
+	if breaker.Ready() {
+		t.Fatal("半开状态试探请求已满时不应就绪")
+	}
+	if snapshot := breaker.Snapshot(); snapshot.Rejected != 0 {
+		t.Fatalf("Ready 计入了拒绝数: %d", snapshot.Rejected)
+	}
+}
+
+func TestGroupReload(t *testing.T) {
+	settings := testSettings()
+	group := NewGroup(func(name string) config.CircuitBreakerConfig { return settings })
+	breaker := group.Get("system")
+	if group.Get("system") != breaker {
+		t.Fatal("同名熔断器应当复用")
+	}
+
+	// 重新加载后按新配置判断，已有的统计保留
+	for i := 0; i < 2; i++ {
+		done, _ := breaker.Allow()
+		done(Failure)
+	}
+	settings.MinRequests = 2
+	group.Reload()
+	done, _ := breaker.Allow()
+	done(Failure)
+	if breaker.State() != StateOpen {
+		t.Fatalf("重新加载配置后状态为 %s，期望打开", breaker.State())
+	}
+
+	// 未配置的项使用默认值
+	settings = config.CircuitBreakerConfig{}
+	group.Reload()
+	breaker.mu.Lock()
+	got := breaker.settings
+	breaker.mu.Unlock()
+	if got != withDefaults(config.CircuitBreakerConfig{}) {
+		t.Fatalf("未配置的项未使用默认值: %+v", got)
+	}
+}
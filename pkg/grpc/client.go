@@ -4,6 +4,8 @@ import (
 	"fmt"
 	"google.golang.org/grpc"
 	"log"
+	"sky_ISService/pkg/metrics"
+	"sky_ISService/pkg/tracing"
 	"sync"
 )
 
@@ -21,7 +23,7 @@ var (
 func NewGRpcClient(host string, port int) *GRpcClient {
 	once.Do(func() {
 		address := fmt.Sprintf("%s:%d", host, port)
-		conn, err := grpc.Dial(address, grpc.WithInsecure())
+		conn, err := grpc.Dial(address, grpc.WithInsecure(), grpc.WithChainUnaryInterceptor(tracing.UnaryClientInterceptor(), metrics.UnaryClientInterceptor()))
 		if err != nil {
 			log.Fatalf("无法连接 gRPC 服务器: %v", err)
 		}
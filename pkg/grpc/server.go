@@ -6,6 +6,8 @@ import (
 	"google.golang.org/grpc/reflection"
 	"log"
 	"net"
+	"sky_ISService/pkg/metrics"
+	"sky_ISService/pkg/tracing"
 	"sky_ISService/proto/system"
 )
 
@@ -17,7 +19,7 @@ type GRpcServer struct {
 
 // NewGRpcServer 创建一个新的 gRPC 服务实例
 func NewGRpcServer(systemUserService system.SystemServiceServer) *GRpcServer {
-	grpcServer := grpc.NewServer()
+	grpcServer := grpc.NewServer(grpc.ChainUnaryInterceptor(tracing.UnaryServerInterceptor(), metrics.UnaryServerInterceptor()))
 	system.RegisterSystemServiceServer(grpcServer, systemUserService)
 	reflection.Register(grpcServer)
 
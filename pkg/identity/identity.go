@@ -0,0 +1,286 @@
+package identity
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sky_ISService/config"
+	"sky_ISService/pkg/signing"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 网关注入的身份请求头，客户端自带的同名请求头会被网关删除
+const (
+	UserIDHeader    = "X-User-ID"
+	UsernameHeader  = "X-Username"
+	RolesHeader     = "X-User-Roles" // 多个角色以逗号分隔
+	TokenIDHeader   = "X-Token-ID"   // JWT 的 jti
+	TimestampHeader = "X-Identity-Timestamp"
+	NonceHeader     = "X-Identity-Nonce" // 每次转发唯一的随机串，服务在签名有效期内拒绝重复的随机串
+	SignatureHeader = "X-Identity-Signature"
+)
+
+// AdminRole 管理员角色：安全服务为管理员签发的 JWT 携带该角色，网关管理接口据此校验
+const AdminRole = "admin"
+
+const (
+	defaultMaxSkew     = time.Minute // 签名时间默认允许的最大偏差
+	defaultMaxBodySize = 10 << 20    // 参与签名的请求体默认上限 10MB
+)
+
+// 身份请求头
+var headers = []string{UserIDHeader, UsernameHeader, RolesHeader, TokenIDHeader, TimestampHeader, NonceHeader, SignatureHeader}
+
+var (
+	ErrMissing      = errors.New("缺少身份签名")
+	ErrSignature    = errors.New("身份签名无效")
+	ErrExpired      = errors.New("身份签名已过期")
+	ErrReplay       = errors.New("身份签名重复使用")
+	ErrBodyTooLarge = errors.New("请求体超过签名允许的大小")
+)
+
+// Identity 网关认证后的调用方身份
+type Identity struct {
+	UserID   string   `json:"user_id"`
+	Username string   `json:"username,omitempty"`
+	Roles    []string `json:"roles,omitempty"`
+	TokenID  string   `json:"token_id,omitempty"`
+}
+
+// HasRole 是否拥有指定角色
+func (id *Identity) HasRole(role string) bool {
+	for _, r := range id.Roles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
+// FromClaims 从 JWT Claims 中读取身份（sub_id、username、role、jti）
+func FromClaims(claims map[string]interface{}) *Identity {
+	id := &Identity{
+		UserID:   claimString(claims, "sub_id"),
+		Username: claimString(claims, "username"),
+		TokenID:  claimString(claims, "jti"),
+	}
+	for _, role := range strings.Split(claimString(claims, "role"), ",") {
+		if role = strings.TrimSpace(role); role != "" {
+			id.Roles = append(id.Roles, role)
+		}
+	}
+	return id
+}
+
+func claimString(claims map[string]interface{}, key string) string {
+	value, ok := claims[key]
+	if !ok || value == nil {
+		return ""
+	}
+	return fmt.Sprint(value)
+}
+
+// Strip 删除请求中的身份请求头，网关在转发前调用，防止客户端伪造身份
+func Strip(h http.Header) {
+	for _, name := range headers {
+		h.Del(name)
+	}
+}
+
+// Sign 写入身份请求头及签名，签名覆盖请求方法、路径、查询串与请求体，需在路径改写之后调用。
+// id 为 nil 时（网关中无需认证的路由）只写入时间戳、随机串与签名，服务据此确认请求来自网关。
+// 请求体读取后会被还原，超过 identity.max_body_size 时返回 ErrBodyTooLarge
+func Sign(r *http.Request, id *Identity, now time.Time) error {
+	Strip(r.Header)
+	body, err := readBody(r)
+	if err != nil {
+		return err
+	}
+	nonce, err := signing.NewNonce()
+	if err != nil {
+		return err
+	}
+	if id == nil {
+		id = &Identity{}
+	}
+	timestamp := strconv.FormatInt(now.Unix(), 10)
+	if id.UserID != "" {
+		r.Header.Set(UserIDHeader, id.UserID)
+	}
+	if id.Username != "" {
+		r.Header.Set(UsernameHeader, id.Username)
+	}
+	if len(id.Roles) > 0 {
+		r.Header.Set(RolesHeader, strings.Join(id.Roles, ","))
+	}
+	if id.TokenID != "" {
+		r.Header.Set(TokenIDHeader, id.TokenID)
+	}
+	r.Header.Set(TimestampHeader, timestamp)
+	r.Header.Set(NonceHeader, nonce)
+	r.Header.Set(SignatureHeader, signature(r, id, timestamp, nonce, signing.ContentHash(body)))
+	return nil
+}
+
+// Verify 校验身份请求头，请求未携带签名时返回 ErrMissing；签名有效但没有用户身份时返回 nil。
+// 请求体读取后会被还原；同一随机串在签名有效期内只能使用一次
+func Verify(r *http.Request, now time.Time) (*Identity, error) {
+	h := r.Header
+	sig := h.Get(SignatureHeader)
+	if sig == "" {
+		return nil, ErrMissing
+	}
+	body, err := readBody(r)
+	if err != nil {
+		return nil, err
+	}
+
+	id := &Identity{
+		UserID:   h.Get(UserIDHeader),
+		Username: h.Get(UsernameHeader),
+		TokenID:  h.Get(TokenIDHeader),
+	}
+	if roles := h.Get(RolesHeader); roles != "" {
+		id.Roles = strings.Split(roles, ",")
+	}
+	timestamp, nonce := h.Get(TimestampHeader), h.Get(NonceHeader)
+	if nonce == "" || !hmac.Equal([]byte(sig), []byte(signature(r, id, timestamp, nonce, signing.ContentHash(body)))) {
+		return nil, ErrSignature
+	}
+
+	signedAt, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return nil, ErrSignature
+	}
+	skew := now.Sub(time.Unix(signedAt, 0))
+	if skew < 0 {
+		skew = -skew
+	}
+	if skew > maxSkew() {
+		return nil, ErrExpired
+	}
+	if !nonces.use(nonce, time.Unix(signedAt, 0).Add(maxSkew()), now) {
+		return nil, ErrReplay
+	}
+	if id.UserID == "" {
+		return nil, nil
+	}
+	return id, nil
+}
+
+// 签名：HMAC-SHA256(请求方法、路径（转义后）、按参数名排序的查询串、用户 ID、用户名、角色、令牌 ID、时间戳、随机串、请求体摘要，以换行分隔)
+func signature(r *http.Request, id *Identity, timestamp, nonce, contentHash string) string {
+	mac := hmac.New(sha256.New, secret())
+	mac.Write([]byte(strings.Join([]string{
+		strings.ToUpper(r.Method),
+		r.URL.EscapedPath(),
+		r.URL.Query().Encode(),
+		id.UserID,
+		id.Username,
+		strings.Join(id.Roles, ","),
+		id.TokenID,
+		timestamp,
+		nonce,
+		contentHash,
+	}, "\n")))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// 读取请求体并还原，超过上限时返回 ErrBodyTooLarge
+func readBody(r *http.Request) ([]byte, error) {
+	if r.Body == nil || r.Body == http.NoBody {
+		return nil, nil
+	}
+	limit := maxBodySize()
+	if r.ContentLength > limit {
+		return nil, ErrBodyTooLarge
+	}
+	body, err := io.ReadAll(io.LimitReader(r.Body, limit+1))
+	_ = r.Body.Close()
+	var maxBytesErr *http.MaxBytesError
+	if int64(len(body)) > limit || errors.As(err, &maxBytesErr) {
+		return nil, ErrBodyTooLarge
+	}
+	if err != nil {
+		return nil, fmt.Errorf("读取请求体失败: %v", err)
+	}
+	r.Body = io.NopCloser(bytes.NewReader(body))
+	r.ContentLength = int64(len(body))
+	r.GetBody = func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(body)), nil
+	}
+	return body, nil
+}
+
+// 签名密钥，未单独配置时使用 JWT 密钥
+func secret() []byte {
+	if s := config.GetConfig().Identity.Secret; s != "" {
+		return []byte(s)
+	}
+	return []byte(config.GetConfig().JWTSecret.Secret)
+}
+
+func maxSkew() time.Duration {
+	if skew := config.GetConfig().Identity.MaxSkew; skew > 0 {
+		return skew
+	}
+	return defaultMaxSkew
+}
+
+func maxBodySize() int64 {
+	if size := config.GetConfig().Identity.MaxBodySize; size > 0 {
+		return size
+	}
+	return defaultMaxBodySize
+}
+
+// 已使用的随机串，保留到签名过期为止
+var nonces = &nonceCache{expires: make(map[string]time.Time)}
+
+type nonceCache struct {
+	mu      sync.Mutex
+	expires map[string]time.Time
+	swept   time.Time // 上次清理过期随机串的时间
+}
+
+// 记录随机串，已在有效期内使用过时返回 false
+func (c *nonceCache) use(nonce string, expiresAt, now time.Time) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if now.Sub(c.swept) > defaultMaxSkew {
+		for n, exp := range c.expires {
+			if now.After(exp) {
+				delete(c.expires, n)
+			}
+		}
+		c.swept = now
+	}
+	if exp, ok := c.expires[nonce]; ok && !now.After(exp) {
+		return false
+	}
+	c.expires[nonce] = expiresAt
+	return true
+}
+
+// 上下文中保存身份的 key
+type contextKey struct{}
+
+// WithIdentity 将身份保存到上下文
+func WithIdentity(ctx context.Context, id *Identity) context.Context {
+	return context.WithValue(ctx, contextKey{}, id)
+}
+
+// FromContext 读取上下文中的身份，未认证的请求返回 false
+func FromContext(ctx context.Context) (*Identity, bool) {
+	id, ok := ctx.Value(contextKey{}).(*Identity)
+	return id, ok
+}
@@ -0,0 +1,134 @@
+package identity
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sky_ISService/config"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMain(m *testing.M) {
+	cfg := &config.InitStructureConfig{}
+	cfg.Identity.Secret = "identity-test-secret"
+	cfg.Identity.MaxBodySize = 64
+	config.Use(cfg)
+	m.Run()
+}
+
+// 网关签名后的请求
+func signedRequest(t *testing.T, method, target, body string, id *Identity, now time.Time) *http.Request {
+	t.Helper()
+	var reader io.Reader
+	if body != "" {
+		reader = strings.NewReader(body)
+	}
+	r := httptest.NewRequest(method, target, reader)
+	if err := Sign(r, id, now); err != nil {
+		t.Fatalf("签名失败: %v", err)
+	}
+	return r
+}
+
+func TestVerify(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	user := &Identity{UserID: "42", Username: "alice", Roles: []string{AdminRole, "ops"}, TokenID: "jti-1"}
+
+	tests := []struct {
+		name    string
+		id      *Identity
+		tamper  func(r *http.Request)
+		at      time.Time
+		wantErr error
+		wantID  bool
+	}{
+		{name: "有效签名", id: user, at: now, wantID: true},
+		{name: "匿名请求", id: nil, at: now},
+		{name: "查询参数顺序不影响签名", id: user, at: now, wantID: true,
+			tamper: func(r *http.Request) { r.URL.RawQuery = "b=2&a=1" }},
+		{name: "篡改方法", id: user, at: now, wantErr: ErrSignature,
+			tamper: func(r *http.Request) { r.Method = http.MethodDelete }},
+		{name: "篡改路径", id: user, at: now, wantErr: ErrSignature,
+			tamper: func(r *http.Request) { r.URL.Path = "/system/admins" }},
+		{name: "篡改查询串", id: user, at: now, wantErr: ErrSignature,
+			tamper: func(r *http.Request) { r.URL.RawQuery = "a=1&b=3" }},
+		{name: "篡改请求体", id: user, at: now, wantErr: ErrSignature,
+			tamper: func(r *http.Request) { r.Body = io.NopCloser(strings.NewReader(`{"x":2}`)) }},
+		{name: "篡改角色", id: user, at: now, wantErr: ErrSignature,
+			tamper: func(r *http.Request) { r.Header.Set(RolesHeader, AdminRole) }},
+		{name: "匿名请求伪造用户", id: nil, at: now, wantErr: ErrSignature,
+			tamper: func(r *http.Request) { r.Header.Set(UserIDHeader, "1") }},
+		{name: "替换随机串", id: user, at: now, wantErr: ErrSignature,
+			tamper: func(r *http.Request) { r.Header.Set(NonceHeader, "00000000000000000000000000000000") }},
+		{name: "缺少签名", id: user, at: now, wantErr: ErrMissing,
+			tamper: func(r *http.Request) { r.Header.Del(SignatureHeader) }},
+		{name: "超过允许的时间偏差", id: user, at: now.Add(2 * time.Minute), wantErr: ErrExpired},
+		{name: "签名时间在未来", id: user, at: now.Add(-2 * time.Minute), wantErr: ErrExpired},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := signedRequest(t, http.MethodPost, "/system/menu?a=1&b=2", `{"x":1}`, tt.id, now)
+			if tt.tamper != nil {
+				tt.tamper(r)
+			}
+			got, err := Verify(r, tt.at)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Verify 返回 %v，期望 %v", err, tt.wantErr)
+			}
+			if (got != nil) != tt.wantID {
+				t.Fatalf("Verify 返回身份 %+v", got)
+			}
+			if tt.wantID && (got.UserID != user.UserID || !got.HasRole(AdminRole) || got.TokenID != user.TokenID) {
+				t.Fatalf("身份为 %+v，期望 %+v", got, user)
+			}
+		})
+	}
+}
+
+func TestVerifyRestoresBody(t *testing.T) {
+	now := time.Now()
+	r := signedRequest(t, http.MethodPut, "/system/menu", `{"name":"menu"}`, nil, now)
+	if _, err := Verify(r, now); err != nil {
+		t.Fatalf("Verify 返回 %v", err)
+	}
+	body, _ := io.ReadAll(r.Body)
+	if string(body) != `{"name":"menu"}` {
+		t.Fatalf("校验后请求体为 %q", body)
+	}
+}
+
+func TestVerifyRejectsReplay(t *testing.T) {
+	now := time.Now()
+	r := signedRequest(t, http.MethodGet, "/system/menu", "", &Identity{UserID: "1"}, now)
+	replay := r.Clone(r.Context())
+	if _, err := Verify(r, now); err != nil {
+		t.Fatalf("首次校验返回 %v", err)
+	}
+	if _, err := Verify(replay, now.Add(time.Second)); !errors.Is(err, ErrReplay) {
+		t.Fatalf("重放请求返回 %v，期望 ErrReplay", err)
+	}
+}
+
+func TestSignRejectsLargeBody(t *testing.T) {
+	r := httptest.NewRequest(http.MethodPost, "/system/menu", strings.NewReader(strings.Repeat("x", 65)))
+	if err := Sign(r, nil, time.Now()); !errors.Is(err, ErrBodyTooLarge) {
+		t.Fatalf("Sign 返回 %v，期望 ErrBodyTooLarge", err)
+	}
+	// 未声明长度的请求体同样受限
+	r = httptest.NewRequest(http.MethodPost, "/system/menu", nil)
+	r.Body = io.NopCloser(strings.NewReader(strings.Repeat("x", 65)))
+	r.ContentLength = -1
+	if err := Sign(r, nil, time.Now()); !errors.Is(err, ErrBodyTooLarge) {
+		t.Fatalf("Sign 返回 %v，期望 ErrBodyTooLarge", err)
+	}
+}
+
+func TestFromClaims(t *testing.T) {
+	id := FromClaims(map[string]interface{}{"sub_id": 7, "username": "bob", "role": "admin, ops ,", "jti": "t1"})
+	if id.UserID != "7" || id.Username != "bob" || id.TokenID != "t1" || strings.Join(id.Roles, "|") != "admin|ops" {
+		t.Fatalf("FromClaims 返回 %+v", id)
+	}
+}
@@ -1,7 +1,8 @@
 package initialize
 
 import (
-	"log"
+	"fmt"
+	"sky_ISService/pkg/metrics"
 	"sky_ISService/shared/cache"
 	"sky_ISService/shared/elasticsearch"
 	"sky_ISService/shared/mq"
@@ -16,23 +17,24 @@ func InitServices() (*elasticsearch.ElasticsearchClient, *cache.RedisClient, *mq
 	// Elasticsearch 初始化
 	esClient, err := elasticsearch.InitElasticsearchConfig()
 	if err != nil {
-		log.Fatalf("Elasticsearch 初始化失败: %v", err)
-		return nil, nil, nil, err
+		return nil, nil, nil, fmt.Errorf("Elasticsearch 初始化失败: %v", err)
 	}
 
 	// Redis 初始化
 	redisClient, err := cache.InitRedisConfig()
 	if err != nil {
-		log.Fatalf("Redis 初始化失败: %v", err)
-		return nil, nil, nil, err
+		return nil, nil, nil, fmt.Errorf("Redis 初始化失败: %v", err)
 	}
 
 	// RabbitMQ 初始化
 	rmqClient, err := mq.InitRabbitMQ()
 	if err != nil {
-		log.Fatalf("RabbitMQ 初始化失败: %v", err)
-		return nil, nil, nil, err
+		return nil, nil, nil, fmt.Errorf("RabbitMQ 初始化失败: %v", err)
 	}
 
+	// 注册 Redis 连接池、RabbitMQ 通道池监控指标
+	metrics.RegisterRedis(redisClient)
+	metrics.RegisterRabbitMQ(rmqClient)
+
 	return esClient, redisClient, rmqClient, nil
 }
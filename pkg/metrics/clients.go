@@ -0,0 +1,86 @@
+package metrics
+
+import (
+	"sky_ISService/shared/cache"
+	"sky_ISService/shared/mq"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// RegisterRabbitMQ 注册 RabbitMQ 通道池指标
+func RegisterRabbitMQ(client *mq.RabbitMQClient) {
+	if client == nil {
+		return
+	}
+	Register(prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "rabbitmq",
+		Name:      "channel_pool_size",
+		Help:      "RabbitMQ 通道池容量",
+	}, func() float64 {
+		return float64(cap(client.ChannelPool))
+	}))
+	Register(prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "rabbitmq",
+		Name:      "channel_pool_idle",
+		Help:      "RabbitMQ 通道池中空闲的通道数",
+	}, func() float64 {
+		return float64(len(client.ChannelPool))
+	}))
+	Register(prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "rabbitmq",
+		Name:      "channel_pool_in_use",
+		Help:      "RabbitMQ 通道池中正在使用的通道数",
+	}, func() float64 {
+		return float64(cap(client.ChannelPool) - len(client.ChannelPool))
+	}))
+}
+
+// RegisterRedis 注册 Redis 连接池指标
+func RegisterRedis(client *cache.RedisClient) {
+	if client == nil || client.Client == nil {
+		return
+	}
+	Register(&redisCollector{client: client})
+}
+
+var (
+	redisHits = prometheus.NewDesc(prometheus.BuildFQName(namespace, "redis_pool", "hits_total"),
+		"从连接池取到空闲连接的次数", nil, nil)
+	redisMisses = prometheus.NewDesc(prometheus.BuildFQName(namespace, "redis_pool", "misses_total"),
+		"连接池没有空闲连接、需要新建连接的次数", nil, nil)
+	redisTimeouts = prometheus.NewDesc(prometheus.BuildFQName(namespace, "redis_pool", "timeouts_total"),
+		"等待连接池超时的次数", nil, nil)
+	redisStale = prometheus.NewDesc(prometheus.BuildFQName(namespace, "redis_pool", "stale_connections_total"),
+		"被移除的过期连接数", nil, nil)
+	redisTotal = prometheus.NewDesc(prometheus.BuildFQName(namespace, "redis_pool", "connections"),
+		"连接池中的连接数", nil, nil)
+	redisIdle = prometheus.NewDesc(prometheus.BuildFQName(namespace, "redis_pool", "idle_connections"),
+		"连接池中的空闲连接数", nil, nil)
+)
+
+// Redis 连接池采集器，采集时读取 PoolStats
+type redisCollector struct {
+	client *cache.RedisClient
+}
+
+func (c *redisCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- redisHits
+	ch <- redisMisses
+	ch <- redisTimeouts
+	ch <- redisStale
+	ch <- redisTotal
+	ch <- redisIdle
+}
+
+func (c *redisCollector) Collect(ch chan<- prometheus.Metric) {
+	stats := c.client.Client.PoolStats()
+	ch <- prometheus.MustNewConstMetric(redisHits, prometheus.CounterValue, float64(stats.Hits))
+	ch <- prometheus.MustNewConstMetric(redisMisses, prometheus.CounterValue, float64(stats.Misses))
+	ch <- prometheus.MustNewConstMetric(redisTimeouts, prometheus.CounterValue, float64(stats.Timeouts))
+	ch <- prometheus.MustNewConstMetric(redisStale, prometheus.CounterValue, float64(stats.StaleConns))
+	ch <- prometheus.MustNewConstMetric(redisTotal, prometheus.GaugeValue, float64(stats.TotalConns))
+	ch <- prometheus.MustNewConstMetric(redisIdle, prometheus.GaugeValue, float64(stats.IdleConns))
+}
@@ -0,0 +1,98 @@
+package metrics
+
+import (
+	"errors"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"gorm.io/gorm"
+)
+
+const gormStartKey = "metrics:start"
+
+var (
+	gormDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "gorm",
+		Name:      "query_duration_seconds",
+		Help:      "GORM 语句耗时",
+		Buckets:   []float64{.001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5},
+	}, []string{"operation", "table"})
+
+	gormErrors = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "gorm",
+		Name:      "query_errors_total",
+		Help:      "GORM 语句失败数（不含记录不存在）",
+	}, []string{"operation", "table"})
+)
+
+// GormPlugin GORM 插件：按操作类型和表统计语句耗时与失败数
+type GormPlugin struct{}
+
+// Name 插件名
+func (GormPlugin) Name() string {
+	return "metrics"
+}
+
+// Initialize 在各类操作前后注册回调
+func (GormPlugin) Initialize(db *gorm.DB) error {
+	callback := db.Callback()
+	return errors.Join(
+		callback.Create().Before("gorm:create").Register("metrics:before_create", gormBefore),
+		callback.Create().After("gorm:create").Register("metrics:after_create", gormAfter("create")),
+		callback.Query().Before("gorm:query").Register("metrics:before_query", gormBefore),
+		callback.Query().After("gorm:query").Register("metrics:after_query", gormAfter("query")),
+		callback.Update().Before("gorm:update").Register("metrics:before_update", gormBefore),
+		callback.Update().After("gorm:update").Register("metrics:after_update", gormAfter("update")),
+		callback.Delete().Before("gorm:delete").Register("metrics:before_delete", gormBefore),
+		callback.Delete().After("gorm:delete").Register("metrics:after_delete", gormAfter("delete")),
+		callback.Row().Before("gorm:row").Register("metrics:before_row", gormBefore),
+		callback.Row().After("gorm:row").Register("metrics:after_row", gormAfter("row")),
+		callback.Raw().Before("gorm:raw").Register("metrics:before_raw", gormBefore),
+		callback.Raw().After("gorm:raw").Register("metrics:after_raw", gormAfter("raw")),
+	)
+}
+
+func gormBefore(db *gorm.DB) {
+	db.InstanceSet(gormStartKey, time.Now())
+}
+
+func gormAfter(operation string) func(db *gorm.DB) {
+	return func(db *gorm.DB) {
+		value, ok := db.InstanceGet(gormStartKey)
+		if !ok {
+			return
+		}
+		start, ok := value.(time.Time)
+		if !ok {
+			return
+		}
+		table := db.Statement.Table
+		if table == "" {
+			table = "unknown"
+		}
+		gormDuration.WithLabelValues(operation, table).Observe(time.Since(start).Seconds())
+		if db.Error != nil && !errors.Is(db.Error, gorm.ErrRecordNotFound) {
+			gormErrors.WithLabelValues(operation, table).Inc()
+		}
+	}
+}
+
+// RegisterDB 注册 GORM 插件和连接池指标
+// @param db *gorm.DB: 数据库实例
+// @param name string: 数据库名（连接池指标的 db_name 标签）
+// @return error: 插件注册失败时返回错误
+func RegisterDB(db *gorm.DB, name string) error {
+	if err := db.Use(GormPlugin{}); err != nil {
+		return err
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		return err
+	}
+	Register(collectors.NewDBStatsCollector(sqlDB, name))
+	return nil
+}
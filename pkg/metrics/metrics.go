@@ -0,0 +1,31 @@
+package metrics
+
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// 指标名前缀
+const namespace = "sky"
+
+// Path 指标接口路径
+const Path = "/metrics"
+
+// Handler 返回 Prometheus 指标接口（包含 Go 运行时与进程指标）
+func Handler() http.Handler {
+	return promhttp.Handler()
+}
+
+// Register 注册采集器，重复注册时忽略（同一进程多次初始化客户端的情况）
+func Register(collector prometheus.Collector) {
+	if err := prometheus.Register(collector); err != nil {
+		var registered prometheus.AlreadyRegisteredError
+		if !errors.As(err, &registered) {
+			log.Printf("注册监控指标失败: %v", err)
+		}
+	}
+}
@@ -0,0 +1,45 @@
+package middleware
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	"log"
+	"net/http"
+	"sky_ISService/config"
+	"sky_ISService/pkg/identity"
+	"sky_ISService/utils"
+	"time"
+)
+
+// IdentityMiddleware 服务端中间件：校验网关注入的身份请求头，通过后将身份存入请求上下文
+// 与网关 JWT 验证一致，同时设置 user_id、role 上下文键；网关中无需认证的路由没有用户身份，照常放行。
+// 默认拒绝未携带网关签名的请求，服务在 identity.allow_unsigned 中时照常放行
+func IdentityMiddleware(serviceName string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := identity.Verify(c.Request, time.Now())
+		if errors.Is(err, identity.ErrMissing) && !config.GetConfig().Identity.SignatureRequired(serviceName) {
+			c.Next()
+			return
+		}
+		if errors.Is(err, identity.ErrBodyTooLarge) {
+			utils.Error(c, http.StatusRequestEntityTooLarge, err.Error())
+			c.Abort()
+			return
+		}
+		if err != nil {
+			log.Printf("身份校验失败 %s %s: %v", c.Request.Method, c.Request.URL.Path, err)
+			utils.Error(c, http.StatusUnauthorized, err.Error())
+			c.Abort()
+			return
+		}
+		if id == nil {
+			c.Next()
+			return
+		}
+
+		c.Request = c.Request.WithContext(identity.WithIdentity(c.Request.Context(), id))
+		c.Set("user_id", id.UserID)
+		c.Set("role", c.Request.Header.Get(identity.RolesHeader))
+		c.Next()
+	}
+}
@@ -1,62 +1,111 @@
 package middleware
 
 import (
+	"errors"
+	"fmt"
 	"log"
 	"net/http"
+	"sky_ISService/pkg/identity"
 	"sky_ISService/utils"
 	"strings"
 
+	"github.com/dgrijalva/jwt-go"
 	"github.com/gin-gonic/gin"
 )
 
-func JWTAuthMiddleware() gin.HandlerFunc {
-	return func(c *gin.Context) {
-		// 定义不需要 token 验证的路径
-		noAuthPaths := []string{
-			"/swagger/index.html",
-		}
+// ErrNoToken 请求未携带 Token
+var ErrNoToken = errors.New("未提供 Token")
 
-		// 检查请求路径是否在不需要验证的路径列表中
-		for _, path := range noAuthPaths {
-			if c.Request.URL.Path == path {
+// JWTAuthMiddleware JWT 验证中间件，任一 skipper 返回 true 时跳过验证
+func JWTAuthMiddleware(skippers ...func(c *gin.Context) bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		for _, skip := range skippers {
+			if skip(c) {
 				c.Next()
 				return
 			}
 		}
 
-		// 如果是以 /security/admins/ 开头的路径，放行
-		if strings.HasPrefix(c.Request.URL.Path, "/security/admins/") {
-			c.Next()
-			return
-		}
-
-		// 从 Header 获取 Token
-		tokenString := c.GetHeader("Authorization")
-		if tokenString == "" || !strings.HasPrefix(tokenString, "Bearer ") {
-			// 未提供 Token
-			utils.Error(c, http.StatusUnauthorized, "未提供 Token")
-			c.Abort()
-			return
-		}
-
-		// 去除前缀 "Bearer "
-		tokenString = strings.TrimPrefix(tokenString, "Bearer ")
-
-		// 解析 Token
-		claims, err := utils.ParseToken(tokenString)
+		r, claims, err := AuthenticateJWT(c.Request)
 		if err != nil {
-			// Token 无效
-			log.Println("Error parsing token:", err)
-			utils.Error(c, http.StatusUnauthorized, "无效的 Token: "+err.Error())
+			utils.Error(c, http.StatusUnauthorized, err.Error())
 			c.Abort()
 			return
 		}
+		c.Request = r
 
 		// 将用户信息存入上下文
-		c.Set("user_id", claims["sub_id"]) // "sub_id" 是用户 ID
-		c.Set("role", claims["role"])
+		if claims != nil {
+			c.Set("user_id", claims["sub_id"]) // "sub_id" 是用户 ID
+			c.Set("role", claims["role"])
+		}
 
 		// 继续处理请求
 		c.Next()
 	}
 }
+
+// AuthenticateJWT 校验请求携带的 JWT，通过后返回带有调用方身份的请求（网关转发时据此向上游服务注入签名的身份请求头）与 Claims；
+// 无需验证的路径返回原请求与 nil。网关的 JWT 插件与 JWTAuthMiddleware 共用
+func AuthenticateJWT(r *http.Request) (*http.Request, jwt.MapClaims, error) {
+	// 定义不需要 token 验证的路径
+	noAuthPaths := []string{
+		"/swagger/index.html",
+	}
+
+	// 检查请求路径是否在不需要验证的路径列表中
+	for _, path := range noAuthPaths {
+		if r.URL.Path == path {
+			return r, nil, nil
+		}
+	}
+
+	// 如果是以 /security/admins/ 开头的路径，放行
+	if strings.HasPrefix(r.URL.Path, "/security/admins/") {
+		return r, nil, nil
+	}
+
+	// 从 Header 获取 Token
+	tokenString := r.Header.Get("Authorization")
+
+	// 浏览器的 WebSocket、EventSource 无法自定义请求头，这两类请求允许通过 access_token 查询参数传递 Token
+	fromQuery := false
+	if tokenString == "" && utils.IsStreamingRequest(r) {
+		if token := r.URL.Query().Get("access_token"); token != "" {
+			tokenString = "Bearer " + token
+			fromQuery = true
+		}
+	}
+	if tokenString == "" || !strings.HasPrefix(tokenString, "Bearer ") {
+		// 未提供 Token
+		return nil, nil, ErrNoToken
+	}
+
+	// 去除前缀 "Bearer "
+	tokenString = strings.TrimPrefix(tokenString, "Bearer ")
+
+	// 解析 Token
+	claims, err := utils.ParseToken(tokenString)
+	if err != nil {
+		// Token 无效
+		log.Println("Error parsing token:", err)
+		return nil, nil, fmt.Errorf("无效的 Token: %v", err)
+	}
+
+	r = r.WithContext(identity.WithIdentity(r.Context(), identity.FromClaims(claims)))
+
+	// 查询参数中的 Token 通过校验后改放到请求头，并从 URL 中移除，避免转发给上游服务或写入访问日志
+	if fromQuery {
+		r.Header.Set("Authorization", "Bearer "+tokenString)
+		stripAccessToken(r)
+	}
+	return r, claims, nil
+}
+
+// 从请求 URL 中移除 access_token 查询参数
+func stripAccessToken(r *http.Request) {
+	query := r.URL.Query()
+	query.Del("access_token")
+	r.URL.RawQuery = query.Encode()
+	r.RequestURI = r.URL.RequestURI()
+}
@@ -3,6 +3,7 @@ package middleware
 import (
 	"fmt"
 	"github.com/gin-gonic/gin"
+	"sky_ISService/pkg/tracing"
 	"sky_ISService/shared/elasticsearch"
 	"time"
 )
@@ -29,6 +30,14 @@ func LoggerMiddleware(indexName string, client *elasticsearch.ElasticsearchClien
 		// 获取请求的状态码
 		status := c.Writer.Status()
 
+		// 获取请求 ID 和链路 ID（由 TracingMiddleware 写入），用于关联网关和各服务的日志
+		ctx := c.Request.Context()
+		requestID := tracing.RequestID(ctx)
+		traceID := ""
+		if sc := tracing.SpanContextFromContext(ctx); sc.IsValid() {
+			traceID = sc.TraceID.String()
+		}
+
 		// 记录到 Elasticsearch（或任何你需要记录的内容）
 		logData := map[string]interface{}{
 			"方法":     method,
@@ -37,6 +46,8 @@ func LoggerMiddleware(indexName string, client *elasticsearch.ElasticsearchClien
 			"响应状态":   status,
 			"耗时":     duration.String(),
 			"时间":     time.Now().Format(time.RFC3339),
+			"请求 ID":  requestID,
+			"链路 ID":  traceID,
 		}
 
 		// 将日志文档插入到 Elasticsearch
@@ -0,0 +1,30 @@
+package middleware
+
+import (
+	"github.com/gin-gonic/gin"
+	"sky_ISService/pkg/metrics"
+)
+
+// MetricsMiddleware Gin 中间件：按路由模板和状态码统计请求数与耗时
+// routeName 用于没有匹配到 gin 路由的请求（如网关代理的请求），返回路由名；为 nil 时统一记为 unmatched
+func MetricsMiddleware(routeName func(c *gin.Context) string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		// 指标接口本身不计入统计
+		if c.Request.URL.Path == metrics.Path {
+			c.Next()
+			return
+		}
+
+		done := metrics.HTTPRequestStarted()
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+			if routeName != nil {
+				route = routeName(c)
+			}
+		}
+		done(c.Request.Method, route, c.Writer.Status())
+	}
+}
@@ -8,6 +8,9 @@ import (
 )
 
 // RetryMiddleware 失败时进行重试 (重试机制中间件)
+//
+// Deprecated: 再次调用 c.Next() 时响应已经写出，无法真正重试。网关转发的请求由代理层按 gateway.retry 配置重试
+// （只重试幂等请求，每次换一个节点），服务内部调用请在客户端实现重试。
 func RetryMiddleware(maxRetries int, retryDelay time.Duration) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		for i := 0; i < maxRetries; i++ {
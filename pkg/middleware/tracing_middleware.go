@@ -0,0 +1,39 @@
+package middleware
+
+import (
+	"github.com/gin-gonic/gin"
+	"sky_ISService/pkg/tracing"
+)
+
+// RequestIDKey gin.Context 中保存请求 ID 的键
+const RequestIDKey = "request_id"
+
+// TracingMiddleware Gin 中间件：沿用上游传入的请求 ID 和链路信息（没有时生成），为每个请求创建调用段
+// 请求 ID 写入响应头 X-Request-ID，业务代码通过 c.Request.Context() 继续向 gRPC、消息队列传递
+func TracingMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := tracing.Extract(c.Request.Context(), tracing.HeaderCarrier(c.Request.Header))
+		ctx, requestID := tracing.EnsureRequestID(ctx)
+
+		name := c.FullPath()
+		if name == "" {
+			name = c.Request.URL.Path
+		}
+		ctx, span := tracing.StartSpan(ctx, c.Request.Method+" "+name, tracing.SpanKindServer)
+		span.SetAttribute("http.method", c.Request.Method)
+		span.SetAttribute("http.target", c.Request.URL.Path)
+
+		c.Request = c.Request.WithContext(ctx)
+		c.Request.Header.Set(tracing.RequestIDHeader, requestID)
+		c.Header(tracing.RequestIDHeader, requestID)
+		c.Set(RequestIDKey, requestID)
+
+		c.Next()
+
+		span.SetHTTPStatus(c.Writer.Status())
+		if len(c.Errors) > 0 {
+			span.SetError(c.Errors.Last())
+		}
+		span.End()
+	}
+}
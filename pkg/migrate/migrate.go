@@ -0,0 +1,178 @@
+package migrate
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Migration 一次数据库结构变更，Version 在同一服务内唯一，按升序执行
+type Migration struct {
+	Version int64
+	Name    string
+	Up      func(tx *gorm.DB) error
+	Down    func(tx *gorm.DB) error // 为空时不支持回滚
+}
+
+// SchemaMigration 已执行的迁移记录，映射到 schema_migrations 表；多个服务共用一个数据库时按服务区分
+type SchemaMigration struct {
+	Service   string    `gorm:"type:varchar(64);primaryKey" json:"service"`
+	Version   int64     `gorm:"primaryKey;autoIncrement:false" json:"version"`
+	Name      string    `gorm:"type:varchar(255);not null" json:"name"`
+	AppliedAt time.Time `gorm:"not null" json:"applied_at"`
+}
+
+// TableName 迁移记录表名
+func (SchemaMigration) TableName() string {
+	return "schema_migrations"
+}
+
+// Status 迁移状态
+type Status struct {
+	Version   int64      `json:"version"`
+	Name      string     `json:"name"`
+	AppliedAt *time.Time `json:"applied_at,omitempty"` // 为空表示未执行
+}
+
+// Migrator 执行单个服务的数据库迁移，已执行的版本记录在 schema_migrations 表
+type Migrator struct {
+	db         *gorm.DB
+	service    string
+	migrations []Migration
+}
+
+// New 创建 Migrator，迁移版本重复或缺少 Up 时返回错误
+func New(db *gorm.DB, service string, migrations []Migration) (*Migrator, error) {
+	sorted := append([]Migration(nil), migrations...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Version < sorted[j].Version })
+	for i, migration := range sorted {
+		if migration.Up == nil {
+			return nil, fmt.Errorf("迁移 %d_%s 缺少 Up", migration.Version, migration.Name)
+		}
+		if i > 0 && sorted[i-1].Version == migration.Version {
+			return nil, fmt.Errorf("迁移版本 %d 重复", migration.Version)
+		}
+	}
+	if err := db.AutoMigrate(&SchemaMigration{}); err != nil {
+		return nil, fmt.Errorf("无法创建迁移记录表: %v", err)
+	}
+	return &Migrator{db: db, service: service, migrations: sorted}, nil
+}
+
+// Run 执行服务所有未执行的迁移，服务启动时调用
+func Run(db *gorm.DB, service string, migrations []Migration) error {
+	migrator, err := New(db, service, migrations)
+	if err != nil {
+		return err
+	}
+	_, err = migrator.Up()
+	return err
+}
+
+// Up 按版本顺序执行所有未执行的迁移，返回本次执行的迁移；每个迁移在独立事务中执行，失败时停止
+func (m *Migrator) Up() ([]Migration, error) {
+	applied, err := m.applied()
+	if err != nil {
+		return nil, err
+	}
+	var done []Migration
+	for _, migration := range m.migrations {
+		if _, ok := applied[migration.Version]; ok {
+			continue
+		}
+		err := m.db.Transaction(func(tx *gorm.DB) error {
+			if err := migration.Up(tx); err != nil {
+				return err
+			}
+			return tx.Create(&SchemaMigration{Service: m.service, Version: migration.Version, Name: migration.Name, AppliedAt: time.Now()}).Error
+		})
+		if err != nil {
+			return done, fmt.Errorf("执行迁移 %d_%s 失败: %v", migration.Version, migration.Name, err)
+		}
+		log.Printf("[%s] 已执行迁移 %d_%s", m.service, migration.Version, migration.Name)
+		done = append(done, migration)
+	}
+	return done, nil
+}
+
+// Down 按版本倒序回滚最近执行的 steps 个迁移，返回本次回滚的迁移
+func (m *Migrator) Down(steps int) ([]Migration, error) {
+	applied, err := m.applied()
+	if err != nil {
+		return nil, err
+	}
+	var done []Migration
+	for i := len(m.migrations) - 1; i >= 0 && len(done) < steps; i-- {
+		migration := m.migrations[i]
+		if _, ok := applied[migration.Version]; !ok {
+			continue
+		}
+		if migration.Down == nil {
+			return done, fmt.Errorf("迁移 %d_%s 不支持回滚", migration.Version, migration.Name)
+		}
+		err := m.db.Transaction(func(tx *gorm.DB) error {
+			if err := migration.Down(tx); err != nil {
+				return err
+			}
+			return tx.Where("service = ? AND version = ?", m.service, migration.Version).Delete(&SchemaMigration{}).Error
+		})
+		if err != nil {
+			return done, fmt.Errorf("回滚迁移 %d_%s 失败: %v", migration.Version, migration.Name, err)
+		}
+		log.Printf("[%s] 已回滚迁移 %d_%s", m.service, migration.Version, migration.Name)
+		done = append(done, migration)
+	}
+	return done, nil
+}
+
+// Status 返回所有迁移的执行状态，按版本排序
+func (m *Migrator) Status() ([]Status, error) {
+	applied, err := m.applied()
+	if err != nil {
+		return nil, err
+	}
+	statuses := make([]Status, 0, len(m.migrations))
+	for _, migration := range m.migrations {
+		status := Status{Version: migration.Version, Name: migration.Name}
+		if record, ok := applied[migration.Version]; ok {
+			appliedAt := record.AppliedAt
+			status.AppliedAt = &appliedAt
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses, nil
+}
+
+func (m *Migrator) applied() (map[int64]SchemaMigration, error) {
+	var records []SchemaMigration
+	if err := m.db.Where("service = ?", m.service).Find(&records).Error; err != nil {
+		return nil, fmt.Errorf("无法读取迁移记录: %v", err)
+	}
+	applied := make(map[int64]SchemaMigration, len(records))
+	for _, record := range records {
+		applied[record.Version] = record
+	}
+	return applied, nil
+}
+
+// CreateTables 返回创建（或补全）模型对应数据表的迁移函数
+func CreateTables(models ...interface{}) func(tx *gorm.DB) error {
+	return func(tx *gorm.DB) error {
+		return tx.AutoMigrate(models...)
+	}
+}
+
+// DropTables 返回删除模型对应数据表的迁移函数，按传入顺序删除
+func DropTables(models ...interface{}) func(tx *gorm.DB) error {
+	return func(tx *gorm.DB) error {
+		for _, model := range models {
+			if err := tx.Migrator().DropTable(model); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
@@ -0,0 +1,119 @@
+package serve
+
+import (
+	"context"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/fx"
+	"google.golang.org/grpc/test/bufconn"
+	"gorm.io/gorm"
+	"log"
+	"sky_ISService/config"
+	"sky_ISService/gateway/proxy"
+	"sky_ISService/pkg/bootstrap"
+	"sky_ISService/pkg/supervisor"
+	systemProto "sky_ISService/proto/system"
+	moduleAuth "sky_ISService/services/auth/module"
+	"sky_ISService/services/security/module"
+	systemGRPC "sky_ISService/services/system/grpc"
+	moduleSystem "sky_ISService/services/system/module"
+	systemRepository "sky_ISService/services/system/repository"
+)
+
+// 开发模式：网关与认证服务、系统服务、用户认证服务运行在同一进程，只监听网关端口。
+// 服务的 Gin 引擎挂载到网关代理，请求在进程内转发；系统服务的 gRPC 接口运行在内存连接上；
+// Redis、RabbitMQ、Elasticsearch 使用进程内实现，不连接 Consul；数据库默认使用 SQLite（dev.database）
+func dev() (fx.Option, error) {
+	infrastructure, err := bootstrap.DevInfrastructure()
+	if err != nil {
+		return nil, err
+	}
+	serverConfig := config.GetConfig().Server
+
+	return fx.Options(
+		infrastructure,
+
+		// 不托管子服务进程，网关的子服务状态接口返回空列表
+		fx.Supply(supervisor.New(nil, 0)),
+
+		// 提供 Proxy 代理实例，服务挂载为进程内节点，不使用服务发现
+		fx.Provide(proxy.NewProxy),
+
+		// 系统服务的 gRPC 接口：认证服务与网关 gRPC 转码通过内存连接调用
+		fx.Provide(func(lc fx.Lifecycle) *bufconn.Listener {
+			listener := systemGRPC.NewInProcessListener()
+			lc.Append(fx.Hook{
+				OnStart: func(ctx context.Context) error {
+					go func() {
+						if err := systemGRPC.StartInProcessGRPCServer(listener); err != nil {
+							log.Printf("gRPC 服务异常退出: %v", err)
+						}
+					}()
+					return nil
+				},
+				OnStop: systemGRPC.GracefulShutdown,
+			})
+			return listener
+		}),
+		fx.Provide(func(listener *bufconn.Listener) (systemProto.SystemServiceClient, error) {
+			return systemGRPC.NewInProcessSystemClient(listener)
+		}),
+		fx.Invoke(func(p *proxy.Proxy, listener *bufconn.Listener) {
+			p.GRPCTranscoder().UseDialer("system.SystemService", systemGRPC.InProcessDialer(listener))
+		}),
+
+		devService("security", module.SecurityModule),
+		devService("system", moduleSystem.SystemModules,
+			// 写入初始数据（已存在时跳过），新数据库创建管理员后即可登录
+			fx.Invoke(func(db *gorm.DB) error {
+				if config.GetConfig().Dev.NoSeed {
+					return nil
+				}
+				if err := systemRepository.Seed(db); err != nil {
+					return fmt.Errorf("写入初始数据失败: %v", err)
+				}
+				return nil
+			}),
+		),
+		devService("auth", moduleAuth.AuthModule),
+
+		// 启动网关后台任务
+		gatewayTasks,
+
+		// 监听配置文件，修改并通过校验后热加载路由（含 CORS）、全局插件、ACL、限流策略、灰度规则与日志级别
+		fx.Invoke(config.WatchConfig),
+
+		// 网关 Gin 引擎只在网关模块内可见，与各服务的引擎区分
+		fx.Module("gateway",
+			fx.Provide(fx.Private, gatewayEngine),
+			bootstrap.HTTPServer(fmt.Sprintf("%s:%s", serverConfig.Host, serverConfig.Port)),
+		),
+	), nil
+}
+
+// 开发模式下的服务：数据库连接与 Gin 引擎只在服务模块内可见，引擎挂载到网关代理，由网关在进程内转发；应用关闭时关闭数据库连接
+func devService(serviceName string, options ...fx.Option) fx.Option {
+	return fx.Module(serviceName,
+		fx.Provide(fx.Private, func(lc fx.Lifecycle) (*gorm.DB, error) {
+			db, err := bootstrap.DevDatabase(serviceName)
+			if err != nil {
+				return nil, err
+			}
+			lc.Append(fx.Hook{
+				OnStop: func(ctx context.Context) error {
+					sqlDB, err := db.DB()
+					if err != nil {
+						return err
+					}
+					return sqlDB.Close()
+				},
+			})
+			return db, nil
+		}),
+		fx.Provide(fx.Private, bootstrap.NewEngine(serviceName)),
+		fx.Options(options...),
+		fx.Invoke(func(p *proxy.Proxy, r *gin.Engine) {
+			p.MountInProcess(serviceName, r)
+		}),
+	)
+}
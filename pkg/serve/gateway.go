@@ -0,0 +1,145 @@
+package serve
+
+import (
+	"context"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/hashicorp/consul/api"
+	"go.uber.org/fx"
+	"sky_ISService/config"
+	"sky_ISService/gateway/proxy"
+	"sky_ISService/gateway/router"
+	"sky_ISService/gateway/swagger"
+	"sky_ISService/pkg/bootstrap"
+	"sky_ISService/pkg/middleware"
+	"sky_ISService/pkg/supervisor"
+	"sky_ISService/shared/mq"
+	consul "sky_ISService/shared/registerservice"
+	"time"
+)
+
+// 网关：反向代理与管理接口，并托管认证服务、系统服务进程
+func gateway() (fx.Option, error) {
+	infrastructure, err := bootstrap.Infrastructure()
+	if err != nil {
+		return nil, err
+	}
+
+	// 子服务进程托管：启动时等待子服务就绪，关闭时转发终止信号
+	sup := supervisor.NewFromConfig(config.GetConfig())
+
+	return fx.Options(
+		// 等待子服务就绪、子服务退出的时间可配置，启动与关闭超时需覆盖这段时间
+		fx.StartTimeout(sup.ReadyTimeout()+15*time.Second),
+		fx.StopTimeout(sup.StopTimeout()+15*time.Second),
+
+		infrastructure,
+		bootstrap.Consul(),
+
+		// 提供子服务进程管理
+		fx.Supply(sup),
+
+		// 提供 Proxy 代理实例
+		fx.Provide(proxy.NewProxy),
+
+		// 提供 Gin 引擎
+		fx.Provide(
+			func(p *proxy.Proxy, sup *supervisor.Supervisor, consulClient *api.Client) (*gin.Engine, error) {
+				r := gatewayEngine(p, sup)
+
+				// 注册服务到 Consul
+				serviceName := "gateway"
+				serviceID := fmt.Sprintf("%s-id", serviceName)
+				address := "127.0.0.1" // 服务的 IP 地址
+				port := 8080           // 服务的端口
+				if err := consul.RegisterServiceConsul(consulClient, serviceName, serviceID, address, port); err != nil {
+					return nil, fmt.Errorf("服务注册失败: %v", err)
+				}
+
+				return r, nil
+			},
+		),
+
+		// 监听 Consul 服务目录，动态更新代理节点
+		fx.Invoke(func(lc fx.Lifecycle, p *proxy.Proxy, consulClient *api.Client) {
+			discoveryConfig := config.GetConfig().Gateway.Discovery
+			catalog := proxy.NewConsulCatalog(consulClient, discoveryConfig.WaitTime)
+			discovery := proxy.NewDiscovery(p, catalog, discoveryConfig.Exclude)
+			lc.Append(fx.Hook{
+				OnStart: func(ctx context.Context) error {
+					discovery.Start()
+					return nil
+				},
+				OnStop: func(ctx context.Context) error {
+					discovery.Stop()
+					return nil
+				},
+			})
+		}),
+
+		// 启动网关后台任务
+		gatewayTasks,
+
+		// 监听配置文件，修改并通过校验后热加载路由（含 CORS）、全局插件、ACL、限流策略、灰度规则与日志级别
+		fx.Invoke(config.WatchConfig),
+
+		// 启动子服务并等待就绪，子服务异常退出后按重启策略自动重启；网关关闭时向子服务转发终止信号
+		fx.Invoke(func(lc fx.Lifecycle, sup *supervisor.Supervisor) {
+			lc.Append(fx.Hook{
+				OnStart: sup.Start,
+				OnStop:  sup.Stop,
+			})
+		}),
+
+		// 子服务就绪后启动 Gin 引擎；关闭时先停止接收请求，再关闭子服务
+		bootstrap.HTTPServer(fmt.Sprintf("%s:%s", config.GetConfig().Server.Host, config.GetConfig().Server.Port)),
+	), nil
+}
+
+// 创建网关 Gin 引擎：管理接口、中间件与 Swagger，未匹配的请求交给代理转发（跨域、认证、访问控制、限流由代理的插件链处理）
+func gatewayEngine(p *proxy.Proxy, sup *supervisor.Supervisor) *gin.Engine {
+	r := router.NewRouter(p, sup,
+		// 注册中间件（熔断由代理按上游服务分别处理）
+		middleware.TracingMiddleware(), // 请求 ID 与链路追踪，需最先执行，被拦截的请求也带有请求 ID
+		// 请求指标，代理的请求按网关路由名统计
+		middleware.MetricsMiddleware(func(c *gin.Context) string {
+			return p.MatchRoute(c.Request).Name
+		}),
+		middleware.RecoveryMiddleware(),
+		middleware.ErrorHandlingMiddleware(), // 全局抓错中间件
+		// 网关管理接口的跨域响应头；代理的请求（包括预检请求）由 CORS、认证等内置插件处理
+		func(c *gin.Context) {
+			if c.FullPath() != "" {
+				p.HandleCORS(c.Writer, c.Request)
+			}
+		},
+	)
+
+	// 初始化 Swagger
+	swagger.InitSwagger(r)
+	return r
+}
+
+// 启动网关后台任务：上游节点主动健康探测、ACL、灰度规则与 API Key 多实例同步、缓存失效消息消费
+var gatewayTasks = fx.Invoke(func(lc fx.Lifecycle, p *proxy.Proxy, mqClient *mq.RabbitMQClient) {
+	lc.Append(fx.Hook{
+		OnStart: func(ctx context.Context) error {
+			p.StartHealthCheck()
+			p.ACL().Start()
+			p.Canary().Start()
+			p.APIKeys().Start()
+			p.ResponseCache().Start(mqClient)
+			return nil
+		},
+		OnStop: func(ctx context.Context) error {
+			p.StopHealthCheck()
+			p.ACL().Stop()
+			p.Canary().Stop()
+			p.APIKeys().Stop()
+			p.ResponseCache().Stop()
+			p.GRPCTranscoder().Close()
+			p.CloseIdleConnections()
+			return nil
+		},
+	})
+})
@@ -0,0 +1,41 @@
+package serve
+
+import (
+	"fmt"
+	"go.uber.org/fx"
+	"sky_ISService/pkg/bootstrap"
+	"sort"
+	"strings"
+)
+
+// 可启动的服务：服务名 -> 组装 fx 应用
+var services = map[string]func() (fx.Option, error){
+	"gateway":  gateway,
+	"security": security,
+	"system":   system,
+	"auth":     auth,
+	"dev":      dev, // 开发模式：网关与所有服务运行在同一进程
+}
+
+// Names 返回可启动的服务名
+func Names() []string {
+	names := make([]string, 0, len(services))
+	for name := range services {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Run 启动指定服务，直到收到 SIGINT/SIGTERM
+func Run(name string) error {
+	build, ok := services[name]
+	if !ok {
+		return fmt.Errorf("未知服务: %s（可选: %s）", name, strings.Join(Names(), ", "))
+	}
+	options, err := build()
+	if err != nil {
+		return err
+	}
+	return bootstrap.Run(name, options)
+}
@@ -0,0 +1,110 @@
+package serve
+
+import (
+	"context"
+	"fmt"
+	"go.uber.org/fx"
+	"log"
+	"sky_ISService/config"
+	"sky_ISService/pkg/bootstrap"
+	authGRPC "sky_ISService/services/auth/grpc"
+	moduleAuth "sky_ISService/services/auth/module"
+	securityGRPC "sky_ISService/services/security/grpc"
+	"sky_ISService/services/security/module"
+	systemGRPC "sky_ISService/services/system/grpc"
+	moduleSystem "sky_ISService/services/system/module"
+)
+
+// 认证服务：管理员登录、邮箱验证码
+func security() (fx.Option, error) {
+	infrastructure, err := bootstrap.Infrastructure()
+	if err != nil {
+		return nil, err
+	}
+	securityConfig := config.GetConfig().Security
+	return fx.Options(
+		infrastructure,
+		bootstrap.Database("security"),
+		// 调用系统服务校验管理员身份
+		fx.Provide(securityGRPC.NewSecurityToSystemClient),
+		bootstrap.Engine("security"),
+		module.SecurityModule,
+
+		// 监听配置文件，修改并通过校验后热加载（身份签名等按请求读取的配置、日志级别）
+		fx.Invoke(config.WatchConfig),
+		bootstrap.HTTPServer(fmt.Sprintf("%s:%s", securityConfig.Host, securityConfig.Port)),
+
+		// 端口开始监听后注册到 Consul，网关通过服务发现获取节点；关闭时先注销
+		bootstrap.Consul(),
+		bootstrap.Register("security", securityConfig.Addr, securityConfig.Version,
+			bootstrap.Instance{Port: securityConfig.Port, Weight: securityConfig.Weight1}),
+	), nil
+}
+
+// 系统服务：管理员、角色、菜单，并提供 gRPC 接口
+func system() (fx.Option, error) {
+	infrastructure, err := bootstrap.Infrastructure()
+	if err != nil {
+		return nil, err
+	}
+	systemConfig := config.GetConfig().System
+	return fx.Options(
+		infrastructure,
+		bootstrap.Database("system"),
+		bootstrap.Engine("system"),
+		moduleSystem.SystemModules,
+
+		// 启动 gRPC 服务，关闭时等待处理中的调用完成
+		fx.Invoke(func(lc fx.Lifecycle) {
+			lc.Append(fx.Hook{
+				OnStart: func(ctx context.Context) error {
+					go func() {
+						if err := systemGRPC.StartSystemGRPCServer(); err != nil {
+							log.Printf("gRPC 服务异常退出: %v", err)
+						}
+					}()
+					return nil
+				},
+				OnStop: systemGRPC.GracefulShutdown,
+			})
+		}),
+
+		// 监听配置文件，修改并通过校验后热加载（身份签名等按请求读取的配置、日志级别）
+		fx.Invoke(config.WatchConfig),
+		bootstrap.HTTPServer(
+			fmt.Sprintf("%s:%s", systemConfig.Host, systemConfig.Port),
+			fmt.Sprintf("%s:%s", systemConfig.Host, systemConfig.Port1),
+		),
+
+		// 每个监听端口注册为一个实例，网关按 weight 元数据加权
+		bootstrap.Consul(),
+		bootstrap.Register("system", systemConfig.Addr, systemConfig.Version,
+			bootstrap.Instance{Port: systemConfig.Port, Weight: systemConfig.Weight1},
+			bootstrap.Instance{Port: systemConfig.Port1, Weight: systemConfig.Weight2}),
+	), nil
+}
+
+// 用户认证服务：前台用户注册、登录
+func auth() (fx.Option, error) {
+	infrastructure, err := bootstrap.Infrastructure()
+	if err != nil {
+		return nil, err
+	}
+	authConfig := config.GetConfig().Auth
+	return fx.Options(
+		infrastructure,
+		bootstrap.Database("auth"),
+		// 调用系统服务
+		fx.Provide(authGRPC.NewSystemClient),
+		bootstrap.Engine("auth"),
+		moduleAuth.AuthModule,
+
+		// 监听配置文件，修改并通过校验后热加载（身份签名等按请求读取的配置、日志级别）
+		fx.Invoke(config.WatchConfig),
+		bootstrap.HTTPServer(fmt.Sprintf("%s:%s", authConfig.Host, authConfig.Port)),
+
+		bootstrap.Consul(),
+		bootstrap.Register("auth", authConfig.Addr, authConfig.Version,
+			bootstrap.Instance{Port: authConfig.Port, Weight: authConfig.Weight1}),
+	), nil
+}
@@ -0,0 +1,42 @@
+package signing
+
+import (
+	"net/http"
+	"time"
+)
+
+// Transport 为每个请求签名的 http.RoundTripper，供合作方接入、内部工具与测试使用
+// 重定向、重试发出的请求会重新签名（使用新的随机串）
+type Transport struct {
+	AppID  string
+	Secret string
+	Base   http.RoundTripper // 为 nil 时使用 http.DefaultTransport
+}
+
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	// RoundTripper 不能修改传入的请求
+	signed := req.Clone(req.Context())
+	if err := Sign(signed, t.AppID, t.Secret, time.Now()); err != nil {
+		if req.Body != nil {
+			req.Body.Close()
+		}
+		return nil, err
+	}
+
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return base.RoundTrip(signed)
+}
+
+// NewClient 创建自动签名的 HTTP 客户端
+//
+//	client := signing.NewClient("partner-a", secret)
+//	resp, err := client.Post("https://gateway/system/api/v1/orders", "application/json", body)
+func NewClient(appID, secret string) *http.Client {
+	return &http.Client{
+		Transport: &Transport{AppID: appID, Secret: secret},
+		Timeout:   30 * time.Second,
+	}
+}
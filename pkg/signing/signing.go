@@ -0,0 +1,159 @@
+package signing
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 签名请求头
+const (
+	AppIDHeader       = "X-App-ID"
+	TimestampHeader   = "X-Timestamp" // Unix 秒
+	NonceHeader       = "X-Nonce"     // 每个请求唯一的随机串，时间窗口内重复使用视为重放
+	ContentHashHeader = "X-Content-SHA256"
+	SignatureHeader   = "X-Signature"
+)
+
+// 随机串长度限制
+const (
+	minNonceLength = 16
+	maxNonceLength = 64
+)
+
+var (
+	ErrMissing     = errors.New("缺少签名请求头")
+	ErrNonce       = errors.New("nonce 长度必须在 16~64 之间")
+	ErrExpired     = errors.New("签名时间超出允许范围")
+	ErrContentHash = errors.New("请求体摘要不匹配")
+	ErrSignature   = errors.New("签名无效")
+)
+
+// ContentHash 请求体的 SHA-256（十六进制小写），没有请求体时为空串的摘要
+func ContentHash(body []byte) string {
+	sum := sha256.Sum256(body)
+	return hex.EncodeToString(sum[:])
+}
+
+// StringToSign 待签名字符串，各部分以换行分隔：
+// 方法、路径（转义后）、按参数名排序的查询串、应用 ID、时间戳、随机串、请求体摘要
+func StringToSign(r *http.Request, appID, timestamp, nonce, contentHash string) string {
+	return strings.Join([]string{
+		strings.ToUpper(r.Method),
+		r.URL.EscapedPath(),
+		r.URL.Query().Encode(),
+		appID,
+		timestamp,
+		nonce,
+		contentHash,
+	}, "\n")
+}
+
+// Signature HMAC-SHA256(密钥, 待签名字符串)，十六进制小写
+func Signature(secret, stringToSign string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(stringToSign))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Sign 读取请求体计算摘要并写入签名请求头，请求体读取后会被还原
+func Sign(r *http.Request, appID, secret string, now time.Time) error {
+	body, err := readBody(r)
+	if err != nil {
+		return err
+	}
+	nonce, err := NewNonce()
+	if err != nil {
+		return err
+	}
+
+	timestamp := strconv.FormatInt(now.Unix(), 10)
+	contentHash := ContentHash(body)
+	r.Header.Set(AppIDHeader, appID)
+	r.Header.Set(TimestampHeader, timestamp)
+	r.Header.Set(NonceHeader, nonce)
+	r.Header.Set(ContentHashHeader, contentHash)
+	r.Header.Set(SignatureHeader, Signature(secret, StringToSign(r, appID, timestamp, nonce, contentHash)))
+	return nil
+}
+
+// Verify 校验签名请求头：时间戳偏差、请求体摘要与签名；随机串是否重复由调用方记录判断
+// body 为完整的请求体
+func Verify(r *http.Request, body []byte, secret string, now time.Time, maxSkew time.Duration) error {
+	appID := r.Header.Get(AppIDHeader)
+	timestamp := r.Header.Get(TimestampHeader)
+	nonce := r.Header.Get(NonceHeader)
+	signature := r.Header.Get(SignatureHeader)
+	if appID == "" || timestamp == "" || nonce == "" || signature == "" {
+		return ErrMissing
+	}
+	if len(nonce) < minNonceLength || len(nonce) > maxNonceLength {
+		return ErrNonce
+	}
+
+	signedAt, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return ErrExpired
+	}
+	skew := now.Sub(time.Unix(signedAt, 0))
+	if skew < 0 {
+		skew = -skew
+	}
+	if skew > maxSkew {
+		return ErrExpired
+	}
+
+	contentHash := ContentHash(body)
+	if declared := r.Header.Get(ContentHashHeader); declared != "" && !hmac.Equal([]byte(strings.ToLower(declared)), []byte(contentHash)) {
+		return ErrContentHash
+	}
+	expected := Signature(secret, StringToSign(r, appID, timestamp, nonce, contentHash))
+	if !hmac.Equal([]byte(strings.ToLower(signature)), []byte(expected)) {
+		return ErrSignature
+	}
+	return nil
+}
+
+// NewNonce 生成随机串
+func NewNonce() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("生成 nonce 失败: %v", err)
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+// 读取请求体并还原，优先使用 GetBody 以免消耗原请求体
+func readBody(r *http.Request) ([]byte, error) {
+	if r.Body == nil || r.Body == http.NoBody {
+		return nil, nil
+	}
+	if r.GetBody != nil {
+		body, err := r.GetBody()
+		if err != nil {
+			return nil, fmt.Errorf("读取请求体失败: %v", err)
+		}
+		defer body.Close()
+		return io.ReadAll(body)
+	}
+
+	body, err := io.ReadAll(r.Body)
+	r.Body.Close()
+	if err != nil {
+		return nil, fmt.Errorf("读取请求体失败: %v", err)
+	}
+	r.Body = io.NopCloser(bytes.NewReader(body))
+	r.GetBody = func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(body)), nil
+	}
+	return body, nil
+}
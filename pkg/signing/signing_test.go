@@ -0,0 +1,134 @@
+package signing
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testSecret = "partner-secret"
+
+// 签名后的请求及其请求体
+func signedRequest(t *testing.T, method, target, body string, now time.Time) (*http.Request, []byte) {
+	t.Helper()
+	var reader io.Reader
+	if body != "" {
+		reader = strings.NewReader(body)
+	}
+	r := httptest.NewRequest(method, target, reader)
+	if err := Sign(r, "erp", testSecret, now); err != nil {
+		t.Fatalf("签名失败: %v", err)
+	}
+	// 签名后请求体仍可读取
+	data, err := io.ReadAll(r.Body)
+	if err != nil || string(data) != body {
+		t.Fatalf("签名后请求体为 %q（%v）", data, err)
+	}
+	return r, data
+}
+
+func TestVerify(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	tests := []struct {
+		name    string
+		tamper  func(r *http.Request, body []byte) []byte
+		secret  string
+		at      time.Time
+		wantErr error
+	}{
+		{name: "有效"},
+		{name: "查询参数顺序不影响签名", tamper: func(r *http.Request, body []byte) []byte {
+			r.URL.RawQuery = "b=2&a=1"
+			return body
+		}},
+		{name: "方法小写", tamper: func(r *http.Request, body []byte) []byte {
+			r.Method = "post"
+			return body
+		}},
+		{name: "签名大小写不敏感", tamper: func(r *http.Request, body []byte) []byte {
+			r.Header.Set(SignatureHeader, strings.ToUpper(r.Header.Get(SignatureHeader)))
+			return body
+		}},
+		{name: "密钥错误", secret: "other", wantErr: ErrSignature},
+		{name: "篡改路径", wantErr: ErrSignature, tamper: func(r *http.Request, body []byte) []byte {
+			r.URL.Path = "/system/api/v1/users"
+			return body
+		}},
+		{name: "篡改查询串", wantErr: ErrSignature, tamper: func(r *http.Request, body []byte) []byte {
+			r.URL.RawQuery = "a=1&b=3"
+			return body
+		}},
+		{name: "篡改请求体", wantErr: ErrContentHash, tamper: func(r *http.Request, body []byte) []byte {
+			return []byte(`{"id":2}`)
+		}},
+		{name: "篡改请求体并去掉摘要请求头", wantErr: ErrSignature, tamper: func(r *http.Request, body []byte) []byte {
+			r.Header.Del(ContentHashHeader)
+			return []byte(`{"id":2}`)
+		}},
+		{name: "篡改随机串", wantErr: ErrSignature, tamper: func(r *http.Request, body []byte) []byte {
+			r.Header.Set(NonceHeader, strings.Repeat("0", 32))
+			return body
+		}},
+		{name: "随机串过短", wantErr: ErrNonce, tamper: func(r *http.Request, body []byte) []byte {
+			r.Header.Set(NonceHeader, "short")
+			return body
+		}},
+		{name: "缺少签名", wantErr: ErrMissing, tamper: func(r *http.Request, body []byte) []byte {
+			r.Header.Del(SignatureHeader)
+			return body
+		}},
+		{name: "时间戳无效", wantErr: ErrExpired, tamper: func(r *http.Request, body []byte) []byte {
+			r.Header.Set(TimestampHeader, "yesterday")
+			return body
+		}},
+		{name: "超过允许的时间偏差", at: now.Add(6 * time.Minute), wantErr: ErrExpired},
+		{name: "签名时间在未来", at: now.Add(-6 * time.Minute), wantErr: ErrExpired},
+		{name: "允许范围内的偏差", at: now.Add(5 * time.Minute)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, body := signedRequest(t, http.MethodPost, "/system/api/v1/orders?a=1&b=2", `{"id":1}`, now)
+			if tt.tamper != nil {
+				body = tt.tamper(r, body)
+			}
+			secret, at := tt.secret, tt.at
+			if secret == "" {
+				secret = testSecret
+			}
+			if at.IsZero() {
+				at = now
+			}
+			if err := Verify(r, body, secret, at, 5*time.Minute); !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Verify 返回 %v，期望 %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestContentHashOfEmptyBody(t *testing.T) {
+	r, body := signedRequest(t, http.MethodGet, "/system/api/v1/orders", "", time.Now())
+	if got := r.Header.Get(ContentHashHeader); got != ContentHash(nil) || len(body) != 0 {
+		t.Fatalf("空请求体的摘要为 %q", got)
+	}
+	if got := ContentHash(nil); got != "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855" {
+		t.Fatalf("空请求体的摘要为 %q", got)
+	}
+}
+
+func TestNewNonceIsUnique(t *testing.T) {
+	seen := make(map[string]bool)
+	for i := 0; i < 100; i++ {
+		nonce, err := NewNonce()
+		if err != nil {
+			t.Fatalf("NewNonce 返回 %v", err)
+		}
+		if len(nonce) < minNonceLength || len(nonce) > maxNonceLength || seen[nonce] {
+			t.Fatalf("随机串 %q 无效或重复", nonce)
+		}
+		seen[nonce] = true
+	}
+}
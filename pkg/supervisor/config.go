@@ -0,0 +1,75 @@
+package supervisor
+
+import (
+	"fmt"
+	"path/filepath"
+	"sky_ISService/config"
+	"sky_ISService/utils"
+	"time"
+)
+
+// NewFromConfig 按配置创建 Supervisor；未配置子服务时按 path_config 启动认证服务与系统服务，并以服务端口可连接作为就绪条件。
+// 子服务配置只在启动时读取，修改后需重启网关
+func NewFromConfig(c *config.InitStructureConfig) *Supervisor {
+	supervisorConfig := c.Supervisor
+	if supervisorConfig.Disabled {
+		return New(nil, supervisorConfig.ReadyTimeout)
+	}
+
+	services := supervisorConfig.Services
+	if len(services) == 0 {
+		services = []config.ChildServiceConfig{
+			{
+				Name:      "security",
+				Path:      c.PathConfig.Security,
+				ReadyAddr: fmt.Sprintf("%s:%s", c.Security.Addr, c.Security.Port),
+			},
+			{
+				Name:      "system",
+				Path:      c.PathConfig.System,
+				ReadyAddr: fmt.Sprintf("%s:%s", c.System.Addr, c.System.Port),
+			},
+		}
+	}
+
+	specs := make([]Spec, 0, len(services))
+	for _, service := range services {
+		path := service.Path
+		if !filepath.IsAbs(path) {
+			path = utils.GetAbsolutePath(path)
+		}
+		stopTimeout := service.StopTimeout
+		if stopTimeout <= 0 {
+			stopTimeout = supervisorConfig.StopTimeout
+		}
+		specs = append(specs, Spec{
+			Name:        service.Name,
+			Path:        path,
+			Args:        service.Args,
+			Env:         service.Env,
+			Dir:         service.Dir,
+			Restart:     RestartPolicy(service.Restart),
+			MinBackoff:  service.MinBackoff,
+			MaxBackoff:  service.MaxBackoff,
+			MaxRestarts: service.MaxRestarts,
+			ReadyURL:    service.ReadyURL,
+			ReadyAddr:   service.ReadyAddr,
+			StopTimeout: stopTimeout,
+		})
+	}
+	return New(specs, supervisorConfig.ReadyTimeout)
+}
+
+// ReadyTimeout 启动时等待子服务就绪的最长时间
+func (s *Supervisor) ReadyTimeout() time.Duration {
+	return s.readyTimeout
+}
+
+// StopTimeout 关闭时等待子服务退出的最长时间（各子服务 StopTimeout 的最大值）
+func (s *Supervisor) StopTimeout() time.Duration {
+	var timeout time.Duration
+	for _, proc := range s.processes {
+		timeout = max(timeout, proc.spec.StopTimeout)
+	}
+	return timeout
+}
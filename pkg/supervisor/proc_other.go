@@ -0,0 +1,22 @@
+//go:build !unix
+
+package supervisor
+
+import (
+	"os"
+	"os/exec"
+)
+
+func setProcessGroup(cmd *exec.Cmd) {}
+
+// 不支持 SIGTERM 的平台先尝试中断信号，失败时直接结束进程
+func terminate(cmd *exec.Cmd) error {
+	if err := cmd.Process.Signal(os.Interrupt); err != nil {
+		return cmd.Process.Kill()
+	}
+	return nil
+}
+
+func kill(cmd *exec.Cmd) error {
+	return cmd.Process.Kill()
+}
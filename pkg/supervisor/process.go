@@ -0,0 +1,304 @@
+package supervisor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"os/exec"
+	"sync"
+	"time"
+)
+
+// 子服务状态
+const (
+	StateStarting = "starting" // 尚未启动或正在启动
+	StateRunning  = "running"
+	StateBackoff  = "backoff" // 已退出，等待重启
+	StateStopping = "stopping"
+	StateStopped  = "stopped" // 随网关关闭
+	StateExited   = "exited"  // 按重启策略不再重启
+	StateFailed   = "failed"  // 连续重启次数超过上限
+)
+
+var errGaveUp = errors.New("子服务已停止重启")
+
+// Status 子服务状态
+type Status struct {
+	Name       string     `json:"name"`
+	State      string     `json:"state"`
+	PID        int        `json:"pid,omitempty"`
+	Ready      bool       `json:"ready"`
+	StartedAt  *time.Time `json:"started_at,omitempty"`
+	Uptime     string     `json:"uptime,omitempty"`
+	Restarts   int        `json:"restarts"` // 累计重启次数
+	LastExit   string     `json:"last_exit,omitempty"`
+	LastExitAt *time.Time `json:"last_exit_at,omitempty"`
+}
+
+// 一个子服务：同一时间最多运行一个进程
+type process struct {
+	spec Spec
+	sup  *Supervisor
+
+	mu         sync.Mutex
+	state      string
+	cmd        *exec.Cmd
+	exited     chan struct{} // 当前进程退出时关闭
+	startedAt  time.Time
+	ready      bool
+	restarts   int
+	lastExit   string
+	lastExitAt time.Time
+	stopping   bool
+
+	readyOnce  sync.Once
+	readyCh    chan struct{} // 首次就绪时关闭
+	gaveUpOnce sync.Once
+	gaveUpCh   chan struct{} // 不再重启时关闭
+	stopCh     chan struct{}
+	stopOnce   sync.Once
+}
+
+func newProcess(spec Spec, sup *Supervisor) *process {
+	return &process{
+		spec:     spec,
+		sup:      sup,
+		state:    StateStarting,
+		readyCh:  make(chan struct{}),
+		gaveUpCh: make(chan struct{}),
+		stopCh:   make(chan struct{}),
+	}
+}
+
+// 启动进程，退出后按重启策略指数退避重启，直到关闭或放弃
+func (p *process) run() {
+	backoff := p.spec.MinBackoff
+	consecutive := 0
+	for {
+		startedAt := time.Now()
+		err := p.runOnce()
+
+		p.mu.Lock()
+		p.lastExit = describeExit(err)
+		p.lastExitAt = time.Now()
+		stopping := p.stopping
+		p.mu.Unlock()
+		if stopping {
+			p.setState(StateStopped)
+			log.Printf("子服务 %s 已停止: %s", p.spec.Name, describeExit(err))
+			return
+		}
+		log.Printf("子服务 %s 退出: %s", p.spec.Name, describeExit(err))
+
+		if p.spec.Restart == RestartNever || (p.spec.Restart == RestartOnFailure && err == nil) {
+			p.setState(StateExited)
+			p.giveUp()
+			return
+		}
+		if time.Since(startedAt) >= defaultStableAfter {
+			backoff = p.spec.MinBackoff
+			consecutive = 0
+		}
+		consecutive++
+		if p.spec.MaxRestarts > 0 && consecutive > p.spec.MaxRestarts {
+			log.Printf("子服务 %s 连续重启 %d 次仍然退出，不再重启", p.spec.Name, p.spec.MaxRestarts)
+			p.setState(StateFailed)
+			p.giveUp()
+			return
+		}
+
+		p.setState(StateBackoff)
+		log.Printf("子服务 %s 将在 %s 后重启", p.spec.Name, backoff)
+		select {
+		case <-time.After(backoff):
+		case <-p.stopCh:
+			p.setState(StateStopped)
+			return
+		}
+		p.mu.Lock()
+		p.restarts++
+		p.mu.Unlock()
+		backoff = min(backoff*2, p.spec.MaxBackoff)
+	}
+}
+
+// 启动一次进程并等待退出，返回启动失败或退出的错误
+func (p *process) runOnce() error {
+	stdout, stderr := p.sup.writer(p.spec.Name), p.sup.writer(p.spec.Name)
+	defer stdout.Flush()
+	defer stderr.Flush()
+
+	cmd := exec.Command(p.spec.Path, p.spec.Args...)
+	cmd.Dir = p.spec.Dir
+	cmd.Env = append(os.Environ(), p.spec.Env...)
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+	setProcessGroup(cmd)
+
+	p.mu.Lock()
+	if p.stopping {
+		p.mu.Unlock()
+		return errors.New("网关正在关闭")
+	}
+	if err := cmd.Start(); err != nil {
+		p.mu.Unlock()
+		return err
+	}
+	exited := make(chan struct{})
+	p.cmd = cmd
+	p.exited = exited
+	p.startedAt = time.Now()
+	p.state = StateRunning
+	p.mu.Unlock()
+	log.Printf("子服务 %s 已启动 (PID %d)", p.spec.Name, cmd.Process.Pid)
+
+	go p.probe(exited)
+	err := cmd.Wait()
+
+	// 进程已退出：清除进程信息与就绪状态，之后完成的就绪探测不再生效
+	p.mu.Lock()
+	close(exited)
+	p.cmd = nil
+	p.startedAt = time.Time{}
+	p.ready = false
+	p.mu.Unlock()
+	return err
+}
+
+// 就绪检查：进程退出前定期探测，首次成功后标记就绪
+func (p *process) probe(exited <-chan struct{}) {
+	ticker := time.NewTicker(readyPollInterval)
+	defer ticker.Stop()
+	for {
+		if p.checkReady() {
+			p.mu.Lock()
+			select {
+			case <-exited: // 探测期间进程已退出
+				p.mu.Unlock()
+				return
+			default:
+			}
+			p.ready = true
+			p.mu.Unlock()
+			p.readyOnce.Do(func() { close(p.readyCh) })
+			return
+		}
+		select {
+		case <-exited:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (p *process) checkReady() bool {
+	switch {
+	case p.spec.ReadyURL != "":
+		client := http.Client{Timeout: time.Second}
+		resp, err := client.Get(p.spec.ReadyURL)
+		if err != nil {
+			return false
+		}
+		resp.Body.Close()
+		return resp.StatusCode >= 200 && resp.StatusCode < 300
+	case p.spec.ReadyAddr != "":
+		conn, err := net.DialTimeout("tcp", p.spec.ReadyAddr, time.Second)
+		if err != nil {
+			return false
+		}
+		conn.Close()
+		return true
+	default:
+		return true
+	}
+}
+
+// 等待首次就绪
+func (p *process) waitReady(ctx context.Context) error {
+	select {
+	case <-p.readyCh:
+		return nil
+	case <-p.gaveUpCh:
+		return errGaveUp
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// 停止：向进程组发送 SIGTERM，超时后发送 SIGKILL
+func (p *process) stop() {
+	p.stopOnce.Do(func() { close(p.stopCh) })
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.stopping = true
+	if p.cmd == nil {
+		return
+	}
+	p.state = StateStopping
+	cmd, exited := p.cmd, p.exited
+	log.Printf("向子服务 %s (PID %d) 发送终止信号", p.spec.Name, cmd.Process.Pid)
+	if err := terminate(cmd); err != nil {
+		log.Printf("向子服务 %s 发送终止信号失败: %v", p.spec.Name, err)
+	}
+	go func() {
+		select {
+		case <-exited:
+		case <-time.After(p.spec.StopTimeout):
+			log.Printf("子服务 %s 在 %s 内未退出，强制结束", p.spec.Name, p.spec.StopTimeout)
+			if err := kill(cmd); err != nil {
+				log.Printf("强制结束子服务 %s 失败: %v", p.spec.Name, err)
+			}
+		}
+	}()
+}
+
+func (p *process) giveUp() {
+	p.gaveUpOnce.Do(func() { close(p.gaveUpCh) })
+}
+
+func (p *process) setState(state string) {
+	p.mu.Lock()
+	p.state = state
+	p.mu.Unlock()
+}
+
+func (p *process) status() Status {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	status := Status{
+		Name:     p.spec.Name,
+		State:    p.state,
+		Ready:    p.ready,
+		Restarts: p.restarts,
+		LastExit: p.lastExit,
+	}
+	if p.cmd != nil {
+		startedAt := p.startedAt
+		status.PID = p.cmd.Process.Pid
+		status.StartedAt = &startedAt
+		status.Uptime = time.Since(startedAt).Round(time.Second).String()
+	}
+	if !p.lastExitAt.IsZero() {
+		lastExitAt := p.lastExitAt
+		status.LastExitAt = &lastExitAt
+	}
+	return status
+}
+
+// 退出原因
+func describeExit(err error) string {
+	var exitErr *exec.ExitError
+	switch {
+	case err == nil:
+		return "正常退出"
+	case errors.As(err, &exitErr):
+		return fmt.Sprintf("异常退出: %s", exitErr.ProcessState)
+	default:
+		return fmt.Sprintf("启动失败: %v", err)
+	}
+}
@@ -0,0 +1,112 @@
+//go:build unix
+
+package supervisor
+
+import (
+	"context"
+	"io"
+	"testing"
+	"time"
+)
+
+// 使用 sh 执行脚本的子服务，输出丢弃
+func newTestProcess(spec Spec) *process {
+	spec.Name = "test"
+	spec.Path = "/bin/sh"
+	spec.Args = []string{"-c", spec.Args[0]}
+	sup := New([]Spec{spec}, time.Second)
+	sup.output = io.Discard
+	return sup.processes[0]
+}
+
+func TestProcessRestart(t *testing.T) {
+	tests := []struct {
+		name         string
+		script       string
+		restart      RestartPolicy
+		maxRestarts  int
+		wantState    string
+		wantRestarts int
+		wantElapsed  time.Duration // 退避等待的最短总时长
+	}{
+		{name: "不重启", script: "exit 1", restart: RestartNever, wantState: StateExited},
+		{name: "失败时重启但正常退出", script: "exit 0", restart: RestartOnFailure, wantState: StateExited},
+		{name: "失败时重启直到上限", script: "exit 1", restart: RestartOnFailure, maxRestarts: 3,
+			wantState: StateFailed, wantRestarts: 3, wantElapsed: 20*time.Millisecond + 40*time.Millisecond + 40*time.Millisecond},
+		{name: "总是重启", script: "exit 0", restart: RestartAlways, maxRestarts: 1,
+			wantState: StateFailed, wantRestarts: 1, wantElapsed: 20 * time.Millisecond},
+		{name: "就绪后退出", script: "sleep 0.3; exit 1", restart: RestartNever, wantState: StateExited},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := newTestProcess(Spec{
+				Args:        []string{tt.script},
+				Restart:     tt.restart,
+				MaxRestarts: tt.maxRestarts,
+				MinBackoff:  20 * time.Millisecond,
+				MaxBackoff:  40 * time.Millisecond,
+			})
+
+			start := time.Now()
+			done := make(chan struct{})
+			go func() {
+				p.run()
+				close(done)
+			}()
+			select {
+			case <-done:
+			case <-time.After(5 * time.Second):
+				p.stop()
+				t.Fatal("子服务未按策略停止重启")
+			}
+			if elapsed := time.Since(start); elapsed < tt.wantElapsed {
+				t.Fatalf("退避总时长 %s，至少应为 %s", elapsed, tt.wantElapsed)
+			}
+
+			status := p.status()
+			if status.State != tt.wantState || status.Restarts != tt.wantRestarts {
+				t.Fatalf("状态 %s、重启 %d 次，期望 %s、%d 次", status.State, status.Restarts, tt.wantState, tt.wantRestarts)
+			}
+			// 进程退出后不再报告 PID、启动时间与就绪
+			if status.PID != 0 || status.StartedAt != nil || status.Uptime != "" || status.Ready {
+				t.Fatalf("退出后仍保留运行信息: %+v", status)
+			}
+			if status.LastExit == "" || status.LastExitAt == nil {
+				t.Fatalf("未记录退出原因: %+v", status)
+			}
+			if !p.startedAt.IsZero() || p.cmd != nil {
+				t.Fatal("退出后未清除进程与启动时间")
+			}
+		})
+	}
+}
+
+func TestProcessStatusWhileRunning(t *testing.T) {
+	p := newTestProcess(Spec{Args: []string{"sleep 5"}, Restart: RestartNever, StopTimeout: time.Second})
+	done := make(chan struct{})
+	go func() {
+		p.run()
+		close(done)
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	if err := p.waitReady(ctx); err != nil {
+		t.Fatalf("等待就绪失败: %v", err)
+	}
+	status := p.status()
+	if status.State != StateRunning || !status.Ready || status.PID == 0 || status.StartedAt == nil {
+		t.Fatalf("运行中的状态不完整: %+v", status)
+	}
+
+	p.stop()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("子服务未停止")
+	}
+	status = p.status()
+	if status.State != StateStopped || status.Ready || status.PID != 0 || status.StartedAt != nil {
+		t.Fatalf("停止后的状态: %+v", status)
+	}
+}
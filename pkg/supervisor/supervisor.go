@@ -0,0 +1,174 @@
+package supervisor
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"sync"
+	"time"
+)
+
+// RestartPolicy 子进程退出后的重启策略
+type RestartPolicy string
+
+const (
+	RestartAlways    RestartPolicy = "always"     // 无论退出码都重启（默认）
+	RestartOnFailure RestartPolicy = "on-failure" // 退出码非 0 时重启
+	RestartNever     RestartPolicy = "never"      // 不重启
+)
+
+// 默认配置
+const (
+	defaultMinBackoff   = time.Second
+	defaultMaxBackoff   = 30 * time.Second
+	defaultStableAfter  = time.Minute // 运行超过该时长后退出，退避时间从最小值重新开始
+	defaultStopTimeout  = 10 * time.Second
+	defaultReadyTimeout = 30 * time.Second
+	readyPollInterval   = 200 * time.Millisecond
+)
+
+// Spec 子服务配置
+type Spec struct {
+	Name        string
+	Path        string // 可执行文件
+	Args        []string
+	Env         []string // 追加到当前进程环境变量之后，格式 KEY=VALUE
+	Dir         string   // 工作目录，为空时使用当前目录
+	Restart     RestartPolicy
+	MinBackoff  time.Duration // 首次重启前的等待时间，之后指数增长
+	MaxBackoff  time.Duration
+	MaxRestarts int           // 连续重启次数上限（进程稳定运行后清零），0 表示不限
+	ReadyURL    string        // 就绪检查：HTTP GET 返回 2xx 视为就绪，如 http://127.0.0.1:8081/health
+	ReadyAddr   string        // 就绪检查：TCP 端口可连接视为就绪（未配置 ReadyURL 时使用）
+	StopTimeout time.Duration // 发送 SIGTERM 后等待退出的时间，超时发送 SIGKILL
+}
+
+// Supervisor 子进程管理：启动子服务并等待就绪，异常退出后按策略指数退避重启，关闭时转发终止信号
+type Supervisor struct {
+	processes    []*process
+	readyTimeout time.Duration
+	output       io.Writer
+	outputMu     sync.Mutex // 多个子进程的输出按行交错写出
+	wg           sync.WaitGroup
+}
+
+// New 创建 Supervisor，readyTimeout 为启动时等待所有子服务就绪的最长时间
+func New(specs []Spec, readyTimeout time.Duration) *Supervisor {
+	if readyTimeout <= 0 {
+		readyTimeout = defaultReadyTimeout
+	}
+	s := &Supervisor{readyTimeout: readyTimeout, output: os.Stdout}
+	for _, spec := range specs {
+		if spec.Restart == "" {
+			spec.Restart = RestartAlways
+		}
+		if spec.MinBackoff <= 0 {
+			spec.MinBackoff = defaultMinBackoff
+		}
+		if spec.MaxBackoff < spec.MinBackoff {
+			spec.MaxBackoff = max(defaultMaxBackoff, spec.MinBackoff)
+		}
+		if spec.StopTimeout <= 0 {
+			spec.StopTimeout = defaultStopTimeout
+		}
+		s.processes = append(s.processes, newProcess(spec, s))
+	}
+	return s
+}
+
+// Start 启动所有子服务并等待就绪；子服务在超时前未就绪或已放弃重启时返回错误
+func (s *Supervisor) Start(ctx context.Context) error {
+	for _, proc := range s.processes {
+		s.wg.Add(1)
+		go func(proc *process) {
+			defer s.wg.Done()
+			proc.run()
+		}(proc)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, s.readyTimeout)
+	defer cancel()
+	var errs []error
+	for _, proc := range s.processes {
+		if err := proc.waitReady(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("子服务 %s 未就绪: %v", proc.spec.Name, err))
+			continue
+		}
+		log.Printf("子服务 %s 已就绪", proc.spec.Name)
+	}
+	return errors.Join(errs...)
+}
+
+// Stop 向所有子服务发送 SIGTERM，超过各自的 StopTimeout 仍未退出时发送 SIGKILL，等待全部退出或 ctx 结束
+func (s *Supervisor) Stop(ctx context.Context) error {
+	for _, proc := range s.processes {
+		proc.stop()
+	}
+
+	done := make(chan struct{})
+	go func() {
+		s.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		log.Println("所有子服务已退出")
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("等待子服务退出超时: %v", ctx.Err())
+	}
+}
+
+// Status 返回所有子服务的状态
+func (s *Supervisor) Status() []Status {
+	statuses := make([]Status, 0, len(s.processes))
+	for _, proc := range s.processes {
+		statuses = append(statuses, proc.status())
+	}
+	return statuses
+}
+
+// 子进程输出：每行加上 [服务名] 前缀
+func (s *Supervisor) writer(name string) *prefixWriter {
+	return &prefixWriter{prefix: []byte("[" + name + "] "), out: s.output, mu: &s.outputMu}
+}
+
+// 按行加前缀的 Writer，不完整的行缓存到下次写入或 Flush
+type prefixWriter struct {
+	prefix []byte
+	out    io.Writer
+	mu     *sync.Mutex
+	buf    []byte
+}
+
+func (w *prefixWriter) Write(p []byte) (int, error) {
+	w.buf = append(w.buf, p...)
+	for {
+		i := bytes.IndexByte(w.buf, '\n')
+		if i < 0 {
+			break
+		}
+		w.writeLine(w.buf[:i+1])
+		w.buf = w.buf[i+1:]
+	}
+	return len(p), nil
+}
+
+// Flush 写出缓存中不完整的行
+func (w *prefixWriter) Flush() {
+	if len(w.buf) > 0 {
+		w.writeLine(append(w.buf, '\n'))
+		w.buf = nil
+	}
+}
+
+func (w *prefixWriter) writeLine(line []byte) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.out.Write(w.prefix)
+	w.out.Write(line)
+}
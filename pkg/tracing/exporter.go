@@ -0,0 +1,164 @@
+package tracing
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sky_ISService/config"
+	"sync"
+)
+
+const (
+	statusOK    = "STATUS_CODE_OK"
+	statusError = "STATUS_CODE_ERROR"
+
+	defaultTraceFile = "logs/traces.jsonl"
+)
+
+// SpanData 导出的调用段，每个调用段单独一行。只有 ID、时间等字段沿用 OTLP JSON 的命名（traceId、startTimeUnixNano 等），
+// 整体为扁平结构而非 OTLP 的 resourceSpans 嵌套结构，导入 OTLP 工具前需要转换
+type SpanData struct {
+	Service           string                 `json:"service.name"`
+	TraceID           string                 `json:"traceId"`
+	SpanID            string                 `json:"spanId"`
+	ParentSpanID      string                 `json:"parentSpanId,omitempty"`
+	Name              string                 `json:"name"`
+	Kind              SpanKind               `json:"kind"`
+	RequestID         string                 `json:"requestId,omitempty"`
+	StartTimeUnixNano int64                  `json:"startTimeUnixNano"`
+	EndTimeUnixNano   int64                  `json:"endTimeUnixNano"`
+	Duration          string                 `json:"duration"`
+	Attributes        map[string]interface{} `json:"attributes,omitempty"`
+	Status            SpanStatus             `json:"status"`
+}
+
+// SpanStatus 调用段状态
+type SpanStatus struct {
+	Code    string `json:"code"`
+	Message string `json:"message,omitempty"`
+}
+
+// Exporter 调用段导出器
+type Exporter interface {
+	Export(span SpanData)
+	Close() error
+}
+
+// 以 JSON Lines 格式写出调用段
+type writerExporter struct {
+	mu      sync.Mutex
+	encoder *json.Encoder
+	closer  io.Closer
+}
+
+// NewWriterExporter 创建写入 w 的导出器，每行一个调用段
+func NewWriterExporter(w io.Writer) Exporter {
+	exporter := &writerExporter{encoder: json.NewEncoder(w)}
+	if closer, ok := w.(io.Closer); ok && w != os.Stdout && w != os.Stderr {
+		exporter.closer = closer
+	}
+	return exporter
+}
+
+func (e *writerExporter) Export(span SpanData) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if err := e.encoder.Encode(span); err != nil {
+		log.Printf("导出调用段失败: %v", err)
+	}
+}
+
+func (e *writerExporter) Close() error {
+	if e.closer == nil {
+		return nil
+	}
+	return e.closer.Close()
+}
+
+var (
+	mu       sync.RWMutex
+	service  string
+	exporter Exporter
+	ratio    = 1.0
+)
+
+// Init 按配置初始化链路追踪，每个进程启动时调用一次
+// @param serviceName string: 当前服务名，写入导出的调用段
+// @param settings config.TracingConfig: 链路追踪配置
+// @return error: 无法打开输出文件时返回错误
+func Init(serviceName string, settings config.TracingConfig) error {
+	var next Exporter
+	switch settings.Exporter {
+	case "", "none":
+	case "stdout":
+		next = NewWriterExporter(os.Stdout)
+	case "file":
+		path := settings.FilePath
+		if path == "" {
+			path = defaultTraceFile
+		}
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			return fmt.Errorf("创建链路追踪目录失败: %v", err)
+		}
+		file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+		if err != nil {
+			return fmt.Errorf("打开链路追踪文件失败: %v", err)
+		}
+		next = NewWriterExporter(file)
+	default:
+		return fmt.Errorf("未知的链路追踪导出方式: %s", settings.Exporter)
+	}
+
+	SetExporter(serviceName, next)
+	mu.Lock()
+	defer mu.Unlock()
+	// 未配置时全部采样，配置为 0 时不采样
+	ratio = 1
+	if settings.SampleRatio != nil {
+		ratio = *settings.SampleRatio
+	}
+	return nil
+}
+
+// SetExporter 替换导出器（nil 表示不导出），原导出器会被关闭
+func SetExporter(serviceName string, next Exporter) {
+	mu.Lock()
+	previous := exporter
+	service, exporter = serviceName, next
+	mu.Unlock()
+	if previous != nil {
+		_ = previous.Close()
+	}
+}
+
+// Shutdown 关闭导出器，进程退出前调用
+func Shutdown() error {
+	mu.Lock()
+	previous := exporter
+	exporter = nil
+	mu.Unlock()
+	if previous == nil {
+		return nil
+	}
+	return previous.Close()
+}
+
+func export(span SpanData) {
+	mu.RLock()
+	current, name := exporter, service
+	mu.RUnlock()
+	if current == nil {
+		return
+	}
+	span.Service = name
+	current.Export(span)
+}
+
+func sampleRatio() float64 {
+	mu.RLock()
+	defer mu.RUnlock()
+	return ratio
+}
@@ -0,0 +1,68 @@
+package tracing
+
+import (
+	"context"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// MetadataCarrier gRPC metadata 载体（键统一为小写）
+type MetadataCarrier metadata.MD
+
+// Get 读取第一个值
+func (c MetadataCarrier) Get(key string) string {
+	values := metadata.MD(c).Get(key)
+	if len(values) == 0 {
+		return ""
+	}
+	return values[0]
+}
+
+// Set 设置值
+func (c MetadataCarrier) Set(key, value string) {
+	metadata.MD(c).Set(strings.ToLower(key), value)
+}
+
+// UnaryClientInterceptor gRPC 客户端拦截器：为每次调用创建调用段，并通过 metadata 传递请求 ID 与链路信息
+func UnaryClientInterceptor() grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		ctx, span := StartSpan(ctx, method, SpanKindClient)
+		defer span.End()
+		span.SetAttribute("rpc.method", method)
+		span.SetAttribute("net.peer.name", cc.Target())
+
+		md, ok := metadata.FromOutgoingContext(ctx)
+		if ok {
+			md = md.Copy()
+		} else {
+			md = metadata.MD{}
+		}
+		Inject(ctx, MetadataCarrier(md))
+		ctx = metadata.NewOutgoingContext(ctx, md)
+
+		err := invoker(ctx, method, req, reply, cc, opts...)
+		span.SetAttribute("rpc.grpc.status_code", status.Code(err).String())
+		span.SetError(err)
+		return err
+	}
+}
+
+// UnaryServerInterceptor gRPC 服务端拦截器：从 metadata 读取请求 ID 与链路信息，为每次调用创建调用段
+func UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if md, ok := metadata.FromIncomingContext(ctx); ok {
+			ctx = Extract(ctx, MetadataCarrier(md))
+		}
+		ctx, span := StartSpan(ctx, info.FullMethod, SpanKindServer)
+		defer span.End()
+		span.SetAttribute("rpc.method", info.FullMethod)
+
+		resp, err := handler(ctx, req)
+		span.SetAttribute("rpc.grpc.status_code", status.Code(err).String())
+		span.SetError(err)
+		return resp, err
+	}
+}
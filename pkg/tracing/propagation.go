@@ -0,0 +1,96 @@
+package tracing
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+const (
+	// RequestIDHeader 请求 ID 请求头，网关生成（或沿用客户端传入的值）后随请求传给所有下游
+	RequestIDHeader = "X-Request-ID"
+	// TraceparentHeader W3C Trace Context 请求头
+	TraceparentHeader = "traceparent"
+)
+
+// Carrier 链路信息的载体：HTTP 请求头、gRPC metadata、消息头
+type Carrier interface {
+	Get(key string) string
+	Set(key, value string)
+}
+
+// HeaderCarrier HTTP 请求头载体
+type HeaderCarrier http.Header
+
+// Get 读取请求头
+func (c HeaderCarrier) Get(key string) string {
+	return http.Header(c).Get(key)
+}
+
+// Set 设置请求头
+func (c HeaderCarrier) Set(key, value string) {
+	http.Header(c).Set(key, value)
+}
+
+// Inject 将请求 ID 和当前链路信息写入载体
+func Inject(ctx context.Context, carrier Carrier) {
+	if requestID := RequestID(ctx); requestID != "" {
+		carrier.Set(RequestIDHeader, requestID)
+	}
+	if sc := SpanContextFromContext(ctx); sc.IsValid() {
+		carrier.Set(TraceparentHeader, FormatTraceparent(sc))
+	}
+}
+
+// Extract 从载体读取请求 ID 和上游链路信息，无效的值会被忽略
+func Extract(ctx context.Context, carrier Carrier) context.Context {
+	if requestID := carrier.Get(RequestIDHeader); ValidRequestID(requestID) {
+		ctx = WithRequestID(ctx, requestID)
+	}
+	if sc, err := ParseTraceparent(carrier.Get(TraceparentHeader)); err == nil {
+		ctx = ContextWithRemoteSpanContext(ctx, sc)
+	}
+	return ctx
+}
+
+// FormatTraceparent 格式化为 traceparent：00-<trace-id>-<span-id>-<flags>
+func FormatTraceparent(sc SpanContext) string {
+	flags := "00"
+	if sc.Sampled {
+		flags = "01"
+	}
+	return fmt.Sprintf("00-%s-%s-%s", sc.TraceID, sc.SpanID, flags)
+}
+
+// ParseTraceparent 解析 traceparent
+func ParseTraceparent(value string) (SpanContext, error) {
+	var sc SpanContext
+	parts := strings.Split(strings.TrimSpace(value), "-")
+	if len(parts) < 4 || len(parts[0]) != 2 || parts[0] == "ff" {
+		return sc, fmt.Errorf("无效的 traceparent: %q", value)
+	}
+	// 版本 00 必须恰好 4 段，更高版本允许追加字段
+	if parts[0] == "00" && len(parts) != 4 {
+		return sc, fmt.Errorf("无效的 traceparent: %q", value)
+	}
+	if len(parts[1]) != 32 || len(parts[2]) != 16 || len(parts[3]) != 2 {
+		return sc, fmt.Errorf("无效的 traceparent: %q", value)
+	}
+	if _, err := hex.Decode(sc.TraceID[:], []byte(parts[1])); err != nil {
+		return sc, fmt.Errorf("无效的 trace-id: %v", err)
+	}
+	if _, err := hex.Decode(sc.SpanID[:], []byte(parts[2])); err != nil {
+		return sc, fmt.Errorf("无效的 parent-id: %v", err)
+	}
+	flags, err := hex.DecodeString(parts[3])
+	if err != nil {
+		return sc, fmt.Errorf("无效的 trace-flags: %v", err)
+	}
+	if !sc.IsValid() {
+		return sc, fmt.Errorf("traceparent 中的 ID 全为零: %q", value)
+	}
+	sc.Sampled = flags[0]&0x01 == 0x01
+	return sc, nil
+}
@@ -0,0 +1,265 @@
+package tracing
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	"sync"
+	"time"
+)
+
+// TraceID 链路 ID（16 字节，与 W3C Trace Context 一致）
+type TraceID [16]byte
+
+// String 十六进制表示
+func (t TraceID) String() string {
+	return hex.EncodeToString(t[:])
+}
+
+// IsValid 全零为无效 ID
+func (t TraceID) IsValid() bool {
+	return t != TraceID{}
+}
+
+// SpanID 调用段 ID（8 字节）
+type SpanID [8]byte
+
+// String 十六进制表示
+func (s SpanID) String() string {
+	return hex.EncodeToString(s[:])
+}
+
+// IsValid 全零为无效 ID
+func (s SpanID) IsValid() bool {
+	return s != SpanID{}
+}
+
+// SpanKind 调用段类型
+type SpanKind string
+
+const (
+	SpanKindServer   SpanKind = "server"   // 接收请求（HTTP、gRPC 服务端）
+	SpanKindClient   SpanKind = "client"   // 发起请求（代理转发、gRPC 客户端）
+	SpanKindProducer SpanKind = "producer" // 发送消息
+	SpanKindConsumer SpanKind = "consumer" // 消费消息
+	SpanKindInternal SpanKind = "internal" // 进程内部操作
+)
+
+// SpanContext 跨进程传递的链路信息
+type SpanContext struct {
+	TraceID TraceID
+	SpanID  SpanID
+	Sampled bool // 是否采样（导出），未采样的调用段仍然传递 ID
+}
+
+// IsValid 链路 ID 与调用段 ID 都有效
+func (sc SpanContext) IsValid() bool {
+	return sc.TraceID.IsValid() && sc.SpanID.IsValid()
+}
+
+// Span 一次调用段，通过 StartSpan 创建，结束时必须调用 End
+type Span struct {
+	name      string
+	kind      SpanKind
+	context   SpanContext
+	parent    SpanID
+	requestID string
+	start     time.Time
+
+	mu            sync.Mutex
+	attributes    map[string]interface{}
+	statusError   bool
+	statusMessage string
+	ended         bool
+}
+
+type (
+	spanKey       struct{}
+	remoteSpanKey struct{}
+	requestIDKey  struct{}
+)
+
+// StartSpan 创建调用段：上下文中有当前调用段（或从请求头解析出的远端调用段）时作为其子调用段，否则开始新的链路
+func StartSpan(ctx context.Context, name string, kind SpanKind) (context.Context, *Span) {
+	span := &Span{
+		name:       name,
+		kind:       kind,
+		requestID:  RequestID(ctx),
+		start:      time.Now(),
+		attributes: make(map[string]interface{}),
+	}
+	if parent := SpanContextFromContext(ctx); parent.IsValid() {
+		span.context.TraceID = parent.TraceID
+		span.context.Sampled = parent.Sampled
+		span.parent = parent.SpanID
+	} else {
+		span.context.TraceID = newTraceID()
+		span.context.Sampled = sample()
+	}
+	span.context.SpanID = newSpanID()
+	return context.WithValue(ctx, spanKey{}, span), span
+}
+
+// SpanFromContext 返回上下文中的当前调用段，没有时返回 nil
+func SpanFromContext(ctx context.Context) *Span {
+	span, _ := ctx.Value(spanKey{}).(*Span)
+	return span
+}
+
+// SpanContextFromContext 返回当前调用段的链路信息，没有时返回从请求头解析出的远端链路信息
+func SpanContextFromContext(ctx context.Context) SpanContext {
+	if span := SpanFromContext(ctx); span != nil {
+		return span.context
+	}
+	remote, _ := ctx.Value(remoteSpanKey{}).(SpanContext)
+	return remote
+}
+
+// ContextWithRemoteSpanContext 保存从上游解析出的链路信息，之后创建的调用段作为其子调用段
+func ContextWithRemoteSpanContext(ctx context.Context, sc SpanContext) context.Context {
+	return context.WithValue(ctx, remoteSpanKey{}, sc)
+}
+
+// SpanContext 返回调用段的链路信息
+func (s *Span) SpanContext() SpanContext {
+	return s.context
+}
+
+// SetAttribute 设置属性
+func (s *Span) SetAttribute(key string, value interface{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.attributes[key] = value
+}
+
+// SetError 将调用段标记为失败
+func (s *Span) SetError(err error) {
+	if err == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.statusError = true
+	s.statusMessage = err.Error()
+}
+
+// SetHTTPStatus 记录 HTTP 状态码，5xx 标记为失败
+func (s *Span) SetHTTPStatus(code int) {
+	s.SetAttribute("http.status_code", code)
+	if code >= 500 {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		s.statusError = true
+		if s.statusMessage == "" {
+			s.statusMessage = fmt.Sprintf("HTTP %d", code)
+		}
+	}
+}
+
+// End 结束调用段并交给导出器，重复调用无效
+func (s *Span) End() {
+	end := time.Now()
+	s.mu.Lock()
+	if s.ended {
+		s.mu.Unlock()
+		return
+	}
+	s.ended = true
+	data := SpanData{
+		TraceID:           s.context.TraceID.String(),
+		SpanID:            s.context.SpanID.String(),
+		Name:              s.name,
+		Kind:              s.kind,
+		RequestID:         s.requestID,
+		StartTimeUnixNano: s.start.UnixNano(),
+		EndTimeUnixNano:   end.UnixNano(),
+		Duration:          end.Sub(s.start).String(),
+		Attributes:        s.attributes,
+		Status:            SpanStatus{Code: statusOK, Message: s.statusMessage},
+	}
+	if s.parent.IsValid() {
+		data.ParentSpanID = s.parent.String()
+	}
+	if s.statusError {
+		data.Status.Code = statusError
+	}
+	s.mu.Unlock()
+
+	if s.context.Sampled {
+		export(data)
+	}
+}
+
+// WithRequestID 在上下文中保存请求 ID
+func WithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, requestID)
+}
+
+// RequestID 返回上下文中的请求 ID，没有时返回空字符串
+func RequestID(ctx context.Context) string {
+	requestID, _ := ctx.Value(requestIDKey{}).(string)
+	return requestID
+}
+
+// EnsureRequestID 上下文中没有请求 ID 时生成一个
+func EnsureRequestID(ctx context.Context) (context.Context, string) {
+	if requestID := RequestID(ctx); requestID != "" {
+		return ctx, requestID
+	}
+	requestID := NewRequestID()
+	return WithRequestID(ctx, requestID), requestID
+}
+
+// NewRequestID 生成请求 ID（32 位十六进制）
+func NewRequestID() string {
+	var id [16]byte
+	_, _ = rand.Read(id[:])
+	return hex.EncodeToString(id[:])
+}
+
+// ValidRequestID 校验外部传入的请求 ID：长度不超过 128，只包含可见 ASCII 字符
+func ValidRequestID(requestID string) bool {
+	if requestID == "" || len(requestID) > 128 {
+		return false
+	}
+	for i := 0; i < len(requestID); i++ {
+		if requestID[i] <= ' ' || requestID[i] > '~' {
+			return false
+		}
+	}
+	return true
+}
+
+func newTraceID() TraceID {
+	var id TraceID
+	for !id.IsValid() {
+		_, _ = rand.Read(id[:])
+	}
+	return id
+}
+
+func newSpanID() SpanID {
+	var id SpanID
+	for !id.IsValid() {
+		_, _ = rand.Read(id[:])
+	}
+	return id
+}
+
+// 按采样率决定新链路是否导出
+func sample() bool {
+	ratio := sampleRatio()
+	if ratio >= 1 {
+		return true
+	}
+	if ratio <= 0 {
+		return false
+	}
+	n, err := rand.Int(rand.Reader, big.NewInt(1_000_000))
+	if err != nil {
+		return true
+	}
+	return float64(n.Int64()) < ratio*1_000_000
+}
@@ -0,0 +1,5 @@
+package system
+
+// 修改 system.proto 后在本目录执行 go generate 重新生成代码
+// （需要 protoc、protoc-gen-go v1.36.5、protoc-gen-go-grpc v1.5.1）
+//go:generate protoc --proto_path=.. --go_out=.. --go_opt=paths=source_relative --go-grpc_out=.. --go-grpc_opt=paths=source_relative ../system/system.proto
@@ -3,13 +3,16 @@ package grpc
 import (
 	"fmt"
 	"google.golang.org/grpc"
+	"sky_ISService/pkg/metrics"
+	"sky_ISService/pkg/tracing"
 	"sky_ISService/proto/system"
 )
 
 // NewSystemClient 创建 gRPC 客户端并向 system 服务发送请求
 func NewSystemClient() (system.SystemServiceClient, error) {
 	// 创建与 system 服务的连接
-	conn, err := grpc.Dial("localhost:9999", grpc.WithInsecure()) // 连接到 system 服务
+	// 连接到 system 服务，拦截器通过 metadata 传递请求 ID 与链路信息
+	conn, err := grpc.Dial("localhost:9999", grpc.WithInsecure(), grpc.WithChainUnaryInterceptor(tracing.UnaryClientInterceptor(), metrics.UnaryClientInterceptor()))
 	if err != nil {
 		return nil, fmt.Errorf("无法连接到 system 服务: %v", err)
 	}
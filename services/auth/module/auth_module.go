@@ -4,11 +4,11 @@ import (
 	"github.com/gin-gonic/gin"
 	"go.uber.org/fx"
 	"gorm.io/gorm"
+	"sky_ISService/pkg/migrate"
 	"sky_ISService/services/auth/controller"
 	"sky_ISService/services/auth/repository"
 	"sky_ISService/services/auth/repository/models"
 	"sky_ISService/services/auth/service"
-	"sky_ISService/utils/database"
 )
 
 var AuthModule = fx.Options(
@@ -25,17 +25,9 @@ var AuthModule = fx.Options(
 		authController.AuthControllerRoutes(r)
 	}),
 
-	// 调用自动迁移，注册并迁移所有模型
+	// 执行未执行的数据库迁移（也可以通过 sky migrate 单独执行），已执行的版本记录在 schema_migrations 表
 	fx.Invoke(func(db *gorm.DB) {
-		// 将所有模型添加到迁移列表
-		database.ModelsToMigrate = append(
-			database.ModelsToMigrate,
-			&models.SkyAuthUser{},
-			&models.SkyAuthToken{},
-		)
-
-		// 执行自动迁移
-		if err := database.AutoMigrate(db); err != nil {
+		if err := migrate.Run(db, "auth", models.Migrations); err != nil {
 			panic("迁移失败: " + err.Error())
 		}
 	}),
@@ -10,5 +10,5 @@ type SkyAuthToken struct {
 	database.CommonBase           // 嵌套 CommonBase 结构体
 	UserID              uint      `gorm:"type:int;not null" json:"user_id"`        // 关联用户表
 	Token               string    `gorm:"type:varchar(512);not null" json:"token"` // 认证 token
-	ExpiresAt           time.Time `gorm:"type:timestamptz" json:"expires_at"`      // 过期时间
+	ExpiresAt           time.Time `json:"expires_at"`                              // 过期时间（PostgreSQL 中为 timestamptz）
 }
@@ -29,7 +29,7 @@ func (c *SecurityController) SecurityControllerRoutes(r *gin.Engine) {
 			utils.Error(ctx, http.StatusBadRequest, "请求数据错误")
 			return
 		}
-		token, err := c.service.AdminLogin(ctx, req)
+		token, err := c.service.AdminLogin(ctx.Request.Context(), req)
 		if err != nil {
 			utils.Error(ctx, http.StatusBadRequest, err.Error())
 			return
@@ -4,11 +4,11 @@ import (
 	"github.com/gin-gonic/gin"
 	"go.uber.org/fx"
 	"gorm.io/gorm"
+	"sky_ISService/pkg/migrate"
 	"sky_ISService/services/security/controller"
 	"sky_ISService/services/security/repository"
 	"sky_ISService/services/security/repository/models"
 	"sky_ISService/services/security/service"
-	"sky_ISService/utils/database"
 )
 
 var SecurityModule = fx.Options(
@@ -22,15 +22,9 @@ var SecurityModule = fx.Options(
 	fx.Invoke(func(securityController *controller.SecurityController, r *gin.Engine) {
 		securityController.SecurityControllerRoutes(r)
 	}),
-	// 调用自动迁移，注册并迁移所有模型
-	fx.Invoke(func(db *gorm.DB, r *gin.Engine) {
-		// 将所有模型添加到迁移列表
-		database.ModelsToMigrate = append(
-			database.ModelsToMigrate,
-			&models.SkySecurityUser{},
-		)
-		// 执行自动迁移
-		if err := database.AutoMigrate(db); err != nil {
+	// 执行未执行的数据库迁移（也可以通过 sky migrate 单独执行），已执行的版本记录在 schema_migrations 表
+	fx.Invoke(func(db *gorm.DB) {
+		if err := migrate.Run(db, "security", models.Migrations); err != nil {
 			panic("迁移失败: " + err.Error())
 		}
 	}),
@@ -0,0 +1,13 @@
+package models
+
+import "sky_ISService/pkg/migrate"
+
+// Migrations 认证服务数据库迁移，新增表或字段时追加新版本，不要修改已发布的版本
+var Migrations = []migrate.Migration{
+	{
+		Version: 1,
+		Name:    "create_security_tables",
+		Up:      migrate.CreateTables(&SkySecurityUser{}),
+		Down:    migrate.DropTables(&SkySecurityUser{}),
+	},
+}
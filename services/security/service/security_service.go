@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"github.com/gin-gonic/gin"
+	"sky_ISService/pkg/identity"
 	"sky_ISService/proto/system"
 	"sky_ISService/services/security/dto"
 	"sky_ISService/services/security/repository"
@@ -82,7 +83,7 @@ func (s *SecurityService) AdminLogin(ctx context.Context, req dto.SecurityAdminL
 		return "", fmt.Errorf("该用户不是管理员")
 	}
 
-	token, err := utils.GenerateToken(strconv.Itoa(int(user.ID)), user.Username)
+	token, err := utils.GenerateToken(strconv.Itoa(int(user.ID)), user.Username, identity.AdminRole)
 	if err != nil {
 		return "", fmt.Errorf("生成 Token 失败")
 	}
@@ -32,7 +32,7 @@ func (c *MenuController) MenuControllerRoutes(r *gin.Engine) {
 			return
 		}
 		// 2. 调用服务层创建菜单
-		menu, err := c.menuService.CreateMenu(req)
+		menu, err := c.menuService.CreateMenu(ctx.Request.Context(), req)
 		if err != nil {
 			// 处理 "当前用户已存在" 错误
 			if err.Error() == "当前菜单已存在" || err.Error() == "没有创建权限" {
@@ -69,7 +69,7 @@ func (c *MenuController) MenuControllerRoutes(r *gin.Engine) {
 		}
 
 		// 2. 调用服务层更新菜单
-		menu, err := c.menuService.UpdateMenu(req)
+		menu, err := c.menuService.UpdateMenu(ctx.Request.Context(), req)
 		if err != nil {
 			// 处理 "菜单不存在" 错误
 			if err.Error() == "菜单不存在" {
@@ -94,7 +94,7 @@ func (c *MenuController) MenuControllerRoutes(r *gin.Engine) {
 		}
 
 		// 调用服务层删除菜单
-		_, err = c.menuService.DeleteMenuByID(menuID)
+		_, err = c.menuService.DeleteMenuByID(ctx.Request.Context(), menuID)
 		if err != nil {
 			utils.Error(ctx, http.StatusInternalServerError, "删除菜单失败: "+err.Error())
 			return
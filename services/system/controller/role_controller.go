@@ -30,7 +30,7 @@ func (c *RoleController) RoleControllerRoutes(r *gin.Engine) {
 			utils.Error(ctx, http.StatusBadRequest, "请求数据错误: "+err.Error())
 			return
 		}
-		role, err := c.roleService.CreateRole(req)
+		role, err := c.roleService.CreateRole(ctx.Request.Context(), req)
 		if err != nil {
 			// 处理 "当前角色已存在" 错误
 			if err.Error() == "当前角色已存在" || err.Error() == "无法创建当前角色" {
@@ -81,7 +81,7 @@ func (c *RoleController) RoleControllerRoutes(r *gin.Engine) {
 			utils.Error(ctx, http.StatusBadRequest, "请求数据错误: "+err.Error())
 			return
 		}
-		updateRole, err := c.roleService.UpdateRole(req)
+		updateRole, err := c.roleService.UpdateRole(ctx.Request.Context(), req)
 		if err != nil {
 			// 处理 "无法修改顶级管理员账号" 错误
 			if err.Error() == "无法修改顶级角色" {
@@ -102,7 +102,7 @@ func (c *RoleController) RoleControllerRoutes(r *gin.Engine) {
 			utils.Error(ctx, http.StatusBadRequest, "无效的角色ID")
 			return
 		}
-		role, err := c.roleService.DeleteRoleByID(id)
+		role, err := c.roleService.DeleteRoleByID(ctx.Request.Context(), id)
 		if err != nil {
 			utils.Error(ctx, http.StatusInternalServerError, "删除角色失败:"+err.Error())
 			return
@@ -128,7 +128,7 @@ func (c *RoleController) RoleControllerRoutes(r *gin.Engine) {
 			utils.Error(ctx, http.StatusBadRequest, "请求错误: 菜单ID列表不能为空")
 			return
 		}
-		_, err = c.roleService.AssignMenusToRole(int(roleID), req.MenuIDs)
+		_, err = c.roleService.AssignMenusToRole(ctx.Request.Context(), int(roleID), req.MenuIDs)
 		if err != nil {
 			utils.Error(ctx, http.StatusInternalServerError, "分配权限失败: "+err.Error())
 			return
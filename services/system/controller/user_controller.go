@@ -33,7 +33,7 @@ func (c *AdminsController) UserControllerRoutes(r *gin.Engine) {
 			return
 		}
 		// 调用服务层创建管理员
-		admin, err := c.adminsService.CreateAdmin(req)
+		admin, err := c.adminsService.CreateAdmin(ctx.Request.Context(), req)
 		if err != nil {
 			// 处理 "当前用户已存在" 错误
 			if err.Error() == "当前用户已存在" || err.Error() == "无法创建顶级管理员账号" {
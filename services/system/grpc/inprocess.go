@@ -0,0 +1,46 @@
+package grpc
+
+import (
+	"context"
+	"fmt"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/test/bufconn"
+	"log"
+	"net"
+	"sky_ISService/pkg/metrics"
+	"sky_ISService/pkg/tracing"
+	"sky_ISService/proto/system"
+)
+
+// 进程内连接的缓冲区大小
+const inProcessBufferSize = 1 << 20
+
+// NewInProcessListener 创建进程内的 gRPC 连接（开发模式，不监听 9999 端口），服务端与客户端通过它通信
+func NewInProcessListener() *bufconn.Listener {
+	return bufconn.Listen(inProcessBufferSize)
+}
+
+// StartInProcessGRPCServer 在进程内连接上启动 gRPC 服务，直到 GracefulShutdown
+func StartInProcessGRPCServer(listener *bufconn.Listener) error {
+	grpcServer = newSystemGRPCServer()
+	log.Printf("gRPC 服务器开始监听进程内连接...")
+	return grpcServer.Serve(listener)
+}
+
+// InProcessDialer 返回连接进程内 gRPC 服务的拨号函数
+func InProcessDialer(listener *bufconn.Listener) func(ctx context.Context, addr string) (net.Conn, error) {
+	return func(ctx context.Context, _ string) (net.Conn, error) {
+		return listener.DialContext(ctx)
+	}
+}
+
+// NewInProcessSystemClient 创建连接进程内 system 服务的 gRPC 客户端
+func NewInProcessSystemClient(listener *bufconn.Listener) (system.SystemServiceClient, error) {
+	// 拦截器通过 metadata 传递请求 ID 与链路信息
+	conn, err := grpc.Dial("passthrough:///system", grpc.WithInsecure(), grpc.WithContextDialer(InProcessDialer(listener)),
+		grpc.WithChainUnaryInterceptor(tracing.UnaryClientInterceptor(), metrics.UnaryClientInterceptor()))
+	if err != nil {
+		return nil, fmt.Errorf("无法连接到进程内 system 服务: %v", err)
+	}
+	return system.NewSystemServiceClient(conn), nil
+}
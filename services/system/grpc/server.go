@@ -4,7 +4,10 @@ import (
 	"context"
 	"fmt"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/reflection"
 	"net"
+	"sky_ISService/pkg/metrics"
+	"sky_ISService/pkg/tracing"
 	"sky_ISService/proto/system"
 	"sky_ISService/services/system/service"
 	"sync"
@@ -21,8 +24,7 @@ func StartSystemGRPCServer() error {
 		return err
 	}
 
-	grpcServer = grpc.NewServer()
-	system.RegisterSystemServiceServer(grpcServer, &service.AdminsService{})
+	grpcServer = newSystemGRPCServer()
 
 	fmt.Println("gRPC 服务器开始监听 9999 端口...")
 	if err := grpcServer.Serve(lis); err != nil {
@@ -34,6 +36,14 @@ func StartSystemGRPCServer() error {
 	return nil
 }
 
+// 创建 gRPC 服务并注册系统服务
+func newSystemGRPCServer() *grpc.Server {
+	server := grpc.NewServer(grpc.ChainUnaryInterceptor(tracing.UnaryServerInterceptor(), metrics.UnaryServerInterceptor()))
+	system.RegisterSystemServiceServer(server, &service.AdminsService{})
+	reflection.Register(server) // 网关通过反射获取方法描述，实现 HTTP/JSON 转 gRPC
+	return server
+}
+
 // GracefulShutdown 停止 gRPC 服务并清理资源
 func GracefulShutdown(ctx context.Context) error {
 	// 使用 sync.Once 确保 Stop 只会被调用一次
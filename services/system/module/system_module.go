@@ -4,11 +4,11 @@ import (
 	"github.com/gin-gonic/gin"
 	"go.uber.org/fx"
 	"gorm.io/gorm"
+	"sky_ISService/pkg/migrate"
 	"sky_ISService/services/system/controller"
 	"sky_ISService/services/system/repository"
 	"sky_ISService/services/system/repository/models"
 	"sky_ISService/services/system/service"
-	"sky_ISService/utils/database"
 )
 
 var SystemModules = fx.Options(
@@ -38,20 +38,9 @@ var SystemModules = fx.Options(
 		menuController.MenuControllerRoutes(r)
 	}),
 
-	// 调用自动迁移，注册并迁移所有模型
+	// 执行未执行的数据库迁移（也可以通过 sky migrate 单独执行），已执行的版本记录在 schema_migrations 表
 	fx.Invoke(func(db *gorm.DB) {
-		// 将所有模型添加到迁移列表
-		database.ModelsToMigrate = append(
-			database.ModelsToMigrate,
-			&models.SkySystemAdmins{},
-			&models.SkySystemMenus{},
-			&models.SkySystemRoles{},
-			&models.AdminsRoles{},
-			&models.RolesMenus{},
-		)
-
-		// 执行自动迁移
-		if err := database.AutoMigrate(db); err != nil {
+		if err := migrate.Run(db, "system", models.Migrations); err != nil {
 			panic("迁移失败: " + err.Error())
 		}
 	}),
@@ -0,0 +1,72 @@
+package repository
+
+import (
+	"errors"
+	"gorm.io/gorm"
+	"sky_ISService/services/system/repository/models"
+)
+
+// SuperAdminRoleKey 超级管理员角色的权限字符串，拥有全部菜单
+const SuperAdminRoleKey = "admin"
+
+// 系统管理菜单，上级菜单按名称关联
+var seedMenus = []struct {
+	models.SkySystemMenus
+	parent string
+}{
+	{SkySystemMenus: models.SkySystemMenus{MenuName: "系统管理", MenuURL: "/system", MenuSort: 1, MenuType: 1, MenuIcon: "setting"}},
+	{SkySystemMenus: models.SkySystemMenus{MenuName: "管理员管理", MenuURL: "/system/user", MenuSort: 1, MenuType: 2, MenuIcon: "user"}, parent: "系统管理"},
+	{SkySystemMenus: models.SkySystemMenus{MenuName: "角色管理", MenuURL: "/system/role", MenuSort: 2, MenuType: 2, MenuIcon: "team"}, parent: "系统管理"},
+	{SkySystemMenus: models.SkySystemMenus{MenuName: "菜单管理", MenuURL: "/system/menu", MenuSort: 3, MenuType: 2, MenuIcon: "menu"}, parent: "系统管理"},
+}
+
+// Seed 写入系统服务的初始数据：超级管理员角色、系统管理菜单及角色菜单权限；可重复执行，已存在的记录不会重复写入
+func Seed(db *gorm.DB) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		role, err := EnsureSuperAdminRole(tx)
+		if err != nil {
+			return err
+		}
+
+		menuIDs := make(map[string]int, len(seedMenus))
+		for _, seed := range seedMenus {
+			menu := seed.SkySystemMenus
+			menu.ParentID = menuIDs[seed.parent]
+			err := tx.Where("menu_name = ? AND parent_id = ? AND is_deleted = false", menu.MenuName, menu.ParentID).
+				Attrs(menu).
+				FirstOrCreate(&menu).Error
+			if err != nil {
+				return err
+			}
+			menuIDs[menu.MenuName] = menu.ID
+
+			roleMenu := models.RolesMenus{RoleID: role.ID, MenuID: menu.ID}
+			if err := tx.Where("role_id = ? AND menu_id = ?", role.ID, menu.ID).FirstOrCreate(&roleMenu).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// EnsureSuperAdminRole 返回超级管理员角色，不存在时创建
+func EnsureSuperAdminRole(db *gorm.DB) (*models.SkySystemRoles, error) {
+	var role models.SkySystemRoles
+	err := db.Where("role_key = ? AND is_deleted = false", SuperAdminRoleKey).First(&role).Error
+	if err == nil {
+		return &role, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+	role = models.SkySystemRoles{
+		RoleName:    "超级管理员",
+		RoleKey:     SuperAdminRoleKey,
+		RoleSort:    1,
+		Description: "拥有全部权限",
+	}
+	if err := db.Create(&role).Error; err != nil {
+		return nil, err
+	}
+	return &role, nil
+}
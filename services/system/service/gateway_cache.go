@@ -0,0 +1,30 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"sky_ISService/shared/cache"
+	"sky_ISService/shared/mq"
+)
+
+// 菜单、角色相关接口在网关中的路径前缀，写操作后失效对应的网关响应缓存
+const (
+	menuCachePath      = "/system/menu"
+	roleMenusCachePath = "/system/menus"
+	roleCachePath      = "/system/role"
+)
+
+// invalidateGatewayCache 通知网关失效指定路径前缀的响应缓存（消息携带 ctx 中的请求 ID），失败只记录日志，不影响写操作结果
+func invalidateGatewayCache(ctx context.Context, rabbitClient *mq.RabbitMQClient, paths ...string) {
+	if rabbitClient == nil {
+		return
+	}
+	message, err := json.Marshal(cache.Invalidation{Paths: paths})
+	if err != nil {
+		return
+	}
+	if err := rabbitClient.SendMessageContext(ctx, cache.InvalidationQueue, string(message)); err != nil {
+		log.Printf("发送网关缓存失效消息失败: %v", err)
+	}
+}
@@ -1,24 +1,27 @@
 package service
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"sky_ISService/services/system/dto"
 	"sky_ISService/services/system/repository"
 	"sky_ISService/services/system/repository/models"
+	"sky_ISService/shared/mq"
 	"sky_ISService/utils/database"
 	"time"
 )
 
 type MenuService struct {
 	menuRepository *repository.MenuRepository
+	rabbitClient   *mq.RabbitMQClient
 }
 
-func NewMenuService(menuRepository *repository.MenuRepository) *MenuService {
-	return &MenuService{menuRepository: menuRepository}
+func NewMenuService(menuRepository *repository.MenuRepository, rabbitClient *mq.RabbitMQClient) *MenuService {
+	return &MenuService{menuRepository: menuRepository, rabbitClient: rabbitClient}
 }
 
-func (s *MenuService) CreateMenu(req dto.CreateSkySystemMenuRequest) (*models.SkySystemMenus, error) {
+func (s *MenuService) CreateMenu(ctx context.Context, req dto.CreateSkySystemMenuRequest) (*models.SkySystemMenus, error) {
 	// 查寻菜单是否已经存在
 	isMenu, err := s.menuRepository.IsMenuExist(req.MenuName)
 	if err != nil {
@@ -47,6 +50,7 @@ func (s *MenuService) CreateMenu(req dto.CreateSkySystemMenuRequest) (*models.Sk
 	if err := s.menuRepository.BaseCreate(menu); err != nil {
 		return nil, err
 	}
+	invalidateGatewayCache(ctx, s.rabbitClient, menuCachePath, roleMenusCachePath)
 
 	return menu, nil
 }
@@ -57,7 +61,7 @@ func (s *MenuService) GetMenuList() ([]dto.MenuItem, error) {
 }
 
 // UpdateMenu 修改菜单
-func (s *MenuService) UpdateMenu(req dto.UpdateSkySystemMenuRequest) (*models.SkySystemMenus, error) {
+func (s *MenuService) UpdateMenu(ctx context.Context, req dto.UpdateSkySystemMenuRequest) (*models.SkySystemMenus, error) {
 	// 检查菜单是否存在
 	menu, err := s.menuRepository.BaseGetByID(int(req.ID))
 	if err != nil {
@@ -97,12 +101,13 @@ func (s *MenuService) UpdateMenu(req dto.UpdateSkySystemMenuRequest) (*models.Sk
 	if err := s.menuRepository.BaseUpdate(menu, int(req.ID)); err != nil {
 		return nil, err
 	}
+	invalidateGatewayCache(ctx, s.rabbitClient, menuCachePath, roleMenusCachePath)
 
 	return menu, nil
 }
 
 // DeleteMenuByID 软删除菜单
-func (s *MenuService) DeleteMenuByID(id int) (*models.SkySystemMenus, error) {
+func (s *MenuService) DeleteMenuByID(ctx context.Context, id int) (*models.SkySystemMenus, error) {
 	// 获取菜单
 	menu, err := s.menuRepository.BaseGetByID(id)
 	if err != nil {
@@ -118,6 +123,7 @@ func (s *MenuService) DeleteMenuByID(id int) (*models.SkySystemMenus, error) {
 	if err := s.menuRepository.BaseSoftDelete(id); err != nil {
 		return nil, fmt.Errorf("删除菜单失败: %v", err)
 	}
+	invalidateGatewayCache(ctx, s.rabbitClient, menuCachePath, roleMenusCachePath)
 
 	return menu, nil
 }
@@ -1,11 +1,13 @@
 package service
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"sky_ISService/services/system/dto"
 	"sky_ISService/services/system/repository"
 	"sky_ISService/services/system/repository/models"
+	"sky_ISService/shared/mq"
 	"sky_ISService/utils"
 	"sky_ISService/utils/database"
 	"time"
@@ -13,14 +15,15 @@ import (
 
 type RoleService struct {
 	roleRepository *repository.RoleRepository
+	rabbitClient   *mq.RabbitMQClient
 }
 
-func NewRoleService(roleRepository *repository.RoleRepository) *RoleService {
-	return &RoleService{roleRepository: roleRepository}
+func NewRoleService(roleRepository *repository.RoleRepository, rabbitClient *mq.RabbitMQClient) *RoleService {
+	return &RoleService{roleRepository: roleRepository, rabbitClient: rabbitClient}
 }
 
 // CreateRole 添加角色
-func (s *RoleService) CreateRole(req dto.CreateSkySystemRoleRequest) (*models.SkySystemRoles, error) {
+func (s *RoleService) CreateRole(ctx context.Context, req dto.CreateSkySystemRoleRequest) (*models.SkySystemRoles, error) {
 	// 查询角色是否已存在
 	isRole, err := s.roleRepository.IsRoleNameExists(req.RoleName)
 	if err != nil {
@@ -46,6 +49,7 @@ func (s *RoleService) CreateRole(req dto.CreateSkySystemRoleRequest) (*models.Sk
 	if err := s.roleRepository.BaseCreate(role); err != nil {
 		return nil, err
 	}
+	invalidateGatewayCache(ctx, s.rabbitClient, roleCachePath)
 
 	return role, nil
 }
@@ -65,7 +69,7 @@ func (s *RoleService) GetRolesWithPagination(page int, size int, conditions map[
 }
 
 // UpdateRole 修改角色
-func (s *RoleService) UpdateRole(req dto.UpdateSkySystemRoleRequest) (*models.SkySystemRoles, error) {
+func (s *RoleService) UpdateRole(ctx context.Context, req dto.UpdateSkySystemRoleRequest) (*models.SkySystemRoles, error) {
 	// 检查角色是否存在
 	role, err := s.GetRoleByID(int(req.ID))
 	if err != nil {
@@ -94,12 +98,13 @@ func (s *RoleService) UpdateRole(req dto.UpdateSkySystemRoleRequest) (*models.Sk
 	if err != nil {
 		return nil, err
 	}
+	invalidateGatewayCache(ctx, s.rabbitClient, roleCachePath)
 
 	return role, nil
 }
 
 // DeleteRoleByID 删除角色
-func (s *RoleService) DeleteRoleByID(id int) (*models.SkySystemRoles, error) {
+func (s *RoleService) DeleteRoleByID(ctx context.Context, id int) (*models.SkySystemRoles, error) {
 	role, err := s.roleRepository.BaseGetByID(id)
 	if err != nil {
 		return nil, fmt.Errorf("管理员不存在: %v", err)
@@ -109,13 +114,14 @@ func (s *RoleService) DeleteRoleByID(id int) (*models.SkySystemRoles, error) {
 	if err := s.roleRepository.BaseSoftDelete(id); err != nil {
 		return nil, err
 	}
+	invalidateGatewayCache(ctx, s.rabbitClient, roleCachePath, roleMenusCachePath)
 
 	return role, nil
 }
 
 // AssignMenusToRole 给角色分配可以打开菜单或者查看某些菜单中的部分数据还有可以读或写的权限
 // AssignMenusToRole 给角色分配可以查看的菜单
-func (s *RoleService) AssignMenusToRole(roleID int, menuIDs []int) (*models.SkySystemRoles, error) {
+func (s *RoleService) AssignMenusToRole(ctx context.Context, roleID int, menuIDs []int) (*models.SkySystemRoles, error) {
 	// 检查传入的菜单列表是否为空
 	if len(menuIDs) == 0 {
 		return nil, errors.New("分配权限失败: 菜单ID列表为空")
@@ -136,6 +142,7 @@ func (s *RoleService) AssignMenusToRole(roleID int, menuIDs []int) (*models.SkyS
 	if err := s.roleRepository.AssignMenusToRole(roleID, menuIDs); err != nil {
 		return nil, err
 	}
+	invalidateGatewayCache(ctx, s.rabbitClient, roleCachePath, roleMenusCachePath)
 
 	return role, nil
 }
@@ -29,7 +29,7 @@ func NewUserService(adminsRepository *repository.AdminsRepository, rabbitClient
 }
 
 // CreateAdmin 添加管理员
-func (s *AdminsService) CreateAdmin(req dto.CreateAdminsRequest) (*dto.SkySystemAdminsResponse, error) {
+func (s *AdminsService) CreateAdmin(ctx context.Context, req dto.CreateAdminsRequest) (*dto.SkySystemAdminsResponse, error) {
 	// 1. 查询用户名是否已存在
 	exists, err := s.adminsRepository.IsUsernameExists(req.Username)
 	if err != nil {
@@ -90,7 +90,7 @@ func (s *AdminsService) CreateAdmin(req dto.CreateAdminsRequest) (*dto.SkySystem
 		"full_name": req.FullName,
 	}
 	message, _ := json.Marshal(messageData)
-	if err := s.rabbitClient.SendMessage("admin_created_queue", string(message)); err != nil {
+	if err := s.rabbitClient.SendMessageContext(ctx, "admin_created_queue", string(message)); err != nil {
 		return nil, errors.New("管理员创建成功，但发布消息失败: " + err.Error())
 	}
 
@@ -0,0 +1,10 @@
+package cache
+
+// InvalidationQueue 网关响应缓存失效消息队列
+const InvalidationQueue = "gateway_cache_invalidation"
+
+// Invalidation 网关响应缓存失效消息，服务写操作完成后发送到 InvalidationQueue
+type Invalidation struct {
+	Paths []string `json:"paths"` // 网关请求路径前缀，按路径段匹配，/system/menu 会同时失效 /system/menu/tree
+	Tags  []string `json:"tags"`  // 路由配置的缓存标签，route:<路由名> 可失效整个路由
+}
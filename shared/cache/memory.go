@@ -0,0 +1,22 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
+)
+
+// NewMemoryRedis 启动进程内的 Redis 服务（miniredis，支持限流等使用的 Lua 脚本）并返回连接到它的客户端，
+// 用于开发模式；数据只保存在内存中，关闭客户端时停止服务
+func NewMemoryRedis() (*RedisClient, error) {
+	server, err := miniredis.Run()
+	if err != nil {
+		return nil, fmt.Errorf("无法启动进程内 Redis: %v", err)
+	}
+	return &RedisClient{
+		Client: redis.NewClient(&redis.Options{Addr: server.Addr()}),
+		Ctx:    context.Background(),
+		memory: server,
+	}, nil
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"github.com/alicebob/miniredis/v2"
 	"github.com/go-redis/redis/v8"
 	"sky_ISService/config"
 	"sync"
@@ -12,8 +13,9 @@ import (
 
 // RedisClient 封装 Redis 客户端
 type RedisClient struct {
-	Client *redis.Client   // Redis 客户端实例
-	Ctx    context.Context // 上下文对象，用于管理 Redis 操作的生命周期
+	Client *redis.Client        // Redis 客户端实例
+	Ctx    context.Context      // 上下文对象，用于管理 Redis 操作的生命周期
+	memory *miniredis.Miniredis // 进程内的 Redis 服务（开发模式），关闭客户端时一并停止
 }
 
 // 单例模式: 只有一个 RedisClient 实例
@@ -106,6 +108,9 @@ func (r *RedisClient) Get(ctx context.Context, key string) (string, error) {
 // @return error: 如果关闭失败，则返回错误
 func (r *RedisClient) Close() error {
 	err := r.Client.Close()
+	if r.memory != nil {
+		r.memory.Close()
+	}
 	if err != nil {
 		return fmt.Errorf("redis 关闭连接失败: %v", err)
 	}
@@ -15,6 +15,7 @@ import (
 type ElasticsearchClient struct {
 	Client *elasticsearch.Client // Elasticsearch 客户端实例
 	Ctx    context.Context       // 上下文对象，用于管理 Elasticsearch 操作的生命周期
+	memory *memoryStore          // 进程内文档存储（开发模式），不为空时不连接 Elasticsearch
 }
 
 // 单例模式: 只有一个 ElasticsearchClient 实例
@@ -89,6 +90,10 @@ func (es *ElasticsearchClient) CreateIndex(index string, docID string, document
 		return nil, fmt.Errorf("编码文档失败: %v", err)
 	}
 
+	if es.memory != nil {
+		return es.memory.index(index, docID, buf.Bytes()), nil
+	}
+
 	// 执行创建文档请求
 	res, err := es.Client.Index(
 		index,                                 // 索引名称
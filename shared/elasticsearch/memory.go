@@ -0,0 +1,58 @@
+package elasticsearch
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strconv"
+	"sync"
+)
+
+// 进程内每个索引保留的最近文档数
+const memoryIndexSize = 1000
+
+// NewMemoryElasticsearch 创建进程内的 Elasticsearch 客户端（开发模式使用）：不连接 Elasticsearch，
+// 每个索引在内存中保留最近的文档，可通过 Documents 查看
+func NewMemoryElasticsearch() *ElasticsearchClient {
+	return &ElasticsearchClient{
+		Ctx:    context.Background(),
+		memory: &memoryStore{indexes: make(map[string][]json.RawMessage)},
+	}
+}
+
+// Documents 返回进程内索引中最近的文档（从旧到新），连接 Elasticsearch 时返回 nil
+func (es *ElasticsearchClient) Documents(index string) []json.RawMessage {
+	if es.memory == nil {
+		return nil
+	}
+	es.memory.mu.Lock()
+	defer es.memory.mu.Unlock()
+	return append([]json.RawMessage(nil), es.memory.indexes[index]...)
+}
+
+// 进程内的文档存储
+type memoryStore struct {
+	mu      sync.Mutex
+	indexes map[string][]json.RawMessage // 索引名称 -> 最近的文档
+	nextID  int64
+}
+
+// 保存文档，超过保留数量时丢弃最早的文档；返回与 Elasticsearch 相同格式的结果
+func (m *memoryStore) index(index, docID string, document []byte) map[string]interface{} {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if docID == "" {
+		m.nextID++
+		docID = strconv.FormatInt(m.nextID, 10)
+	}
+	documents := append(m.indexes[index], json.RawMessage(bytes.TrimSpace(document)))
+	if len(documents) > memoryIndexSize {
+		documents = documents[len(documents)-memoryIndexSize:]
+	}
+	m.indexes[index] = documents
+	return map[string]interface{}{
+		"_index": index,
+		"_id":    docID,
+		"result": "created",
+	}
+}
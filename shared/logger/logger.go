@@ -68,15 +68,25 @@ func InitLogger(serviceName string, configPath string, client *elasticsearch.Cli
 	Logger.SetFormatter(&logrus.JSONFormatter{}) // 设置日志格式为 JSON
 
 	// 设置日志级别
-	logLevel := logrus.InfoLevel
-	if len(serviceConfig.LoggerConfig.Level) > 0 {
-		logLevel, err = logrus.ParseLevel(strings.ToLower(serviceConfig.LoggerConfig.Level[0]))
-		if err != nil {
-			return nil, fmt.Errorf("无效的日志级别配置: %v", err)
-		}
+	logLevel, err := parseLevel(serviceConfig.LoggerConfig)
+	if err != nil {
+		return nil, err
 	}
 	Logger.SetLevel(logLevel)
 
+	// 配置文件重新加载后更新日志级别，级别无效的配置文件不会生效
+	config.RegisterValidator(func(next *config.InitStructureConfig) error {
+		_, err := parseLevel(next.Logger[serviceName].LoggerConfig)
+		return err
+	})
+	config.Subscribe(func(prev, next *config.InitStructureConfig) {
+		level, _ := parseLevel(next.Logger[serviceName].LoggerConfig)
+		if level != Logger.GetLevel() {
+			Logger.SetLevel(level)
+			Logger.Infof("日志级别已更新为 %s", level)
+		}
+	})
+
 	// 设置日志输出路径
 	logFilePath := "logfile.log"
 	if serviceConfig.LoggerConfig.Filepath != "" {
@@ -110,6 +120,18 @@ func InitLogger(serviceName string, configPath string, client *elasticsearch.Cli
 	return Logger, nil
 }
 
+// 解析配置中的日志级别，未配置时为 info
+func parseLevel(loggerConfig config.LoggerConfig) (logrus.Level, error) {
+	if len(loggerConfig.Level) == 0 {
+		return logrus.InfoLevel, nil
+	}
+	level, err := logrus.ParseLevel(strings.ToLower(loggerConfig.Level[0]))
+	if err != nil {
+		return logrus.InfoLevel, fmt.Errorf("无效的日志级别配置: %v", err)
+	}
+	return level, nil
+}
+
 // SetLogger 用于设置全局 Logger
 func SetLogger(logger *logrus.Logger) {
 	Logger = logger
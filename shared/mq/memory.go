@@ -0,0 +1,84 @@
+package mq
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/streadway/amqp"
+)
+
+// 进程内每个队列最多缓存的消息数，超过时发送失败
+const memoryQueueSize = 1024
+
+// NewMemoryRabbitMQ 创建进程内的消息队列客户端（开发模式使用）：不连接 RabbitMQ，消息保存在内存中，
+// 发送、消费的行为与 RabbitMQ 客户端一致（队列按名称自动创建，同一队列的多个消费者竞争消费），进程退出后未消费的消息丢失
+func NewMemoryRabbitMQ() *RabbitMQClient {
+	return &RabbitMQClient{memory: &memoryBroker{
+		queues: make(map[string]chan memoryMessage),
+		closed: make(chan struct{}),
+	}}
+}
+
+// 进程内的一条消息
+type memoryMessage struct {
+	headers amqp.Table
+	body    []byte
+}
+
+// 进程内的消息队列
+type memoryBroker struct {
+	mu        sync.Mutex
+	queues    map[string]chan memoryMessage // 队列名 -> 消息
+	closed    chan struct{}
+	closeOnce sync.Once
+}
+
+// 获取（必要时创建）队列
+func (b *memoryBroker) queue(name string) chan memoryMessage {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	queue, ok := b.queues[name]
+	if !ok {
+		queue = make(chan memoryMessage, memoryQueueSize)
+		b.queues[name] = queue
+	}
+	return queue
+}
+
+// 发送消息，队列已满或已关闭时返回错误
+func (b *memoryBroker) publish(queueName string, headers amqp.Table, body []byte) error {
+	select {
+	case <-b.closed:
+		return fmt.Errorf("消息队列已关闭")
+	default:
+	}
+	select {
+	case b.queue(queueName) <- memoryMessage{headers: headers, body: body}:
+		return nil
+	default:
+		return fmt.Errorf("队列 %s 已满（%d 条消息未消费）", queueName, memoryQueueSize)
+	}
+}
+
+// 持续消费队列消息，直到 ctx 取消或消息队列关闭；handler 返回错误时丢弃该消息
+func (b *memoryBroker) consume(ctx context.Context, queueName string, handler func(headers amqp.Table, body []byte) error) error {
+	queue := b.queue(queueName)
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-b.closed:
+			return fmt.Errorf("队列 %s 的消费通道已关闭", queueName)
+		case message := <-queue:
+			_ = handler(message.headers, message.body)
+		}
+	}
+}
+
+// 关闭消息队列，之后发送失败、消费者退出
+func (b *memoryBroker) close() {
+	b.closeOnce.Do(func() {
+		close(b.closed)
+	})
+}
@@ -1,18 +1,21 @@
 package mq
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"sync"
 
 	"github.com/streadway/amqp"
 	"sky_ISService/config"
+	"sky_ISService/pkg/tracing"
 )
 
 // RabbitMQClient 封装 RabbitMQ 客户端
 type RabbitMQClient struct {
 	Connection  *amqp.Connection
 	ChannelPool chan *amqp.Channel // 连接池，维护多个 Channel
+	memory      *memoryBroker      // 进程内消息队列（开发模式），不为空时不连接 RabbitMQ
 }
 
 // 单例模式: 只有一个 RabbitMQClient 实例
@@ -115,9 +118,36 @@ func (r *RabbitMQClient) ReleaseChannel(ch *amqp.Channel) {
 // @param message string: 要发送的消息
 // @return error: 如果发送消息失败，返回错误
 func (r *RabbitMQClient) SendMessage(queueName, message string) error {
+	return r.SendMessageContext(context.Background(), queueName, message)
+}
+
+// SendMessageContext 发送消息到 RabbitMQ 队列，并将 ctx 中的请求 ID 与链路信息写入消息头
+// @param ctx context.Context: 请求上下文
+// @param queueName string: 队列名称
+// @param message string: 要发送的消息
+// @return error: 如果发送消息失败，返回错误
+func (r *RabbitMQClient) SendMessageContext(ctx context.Context, queueName, message string) error {
+	ctx, span := tracing.StartSpan(ctx, "send "+queueName, tracing.SpanKindProducer)
+	defer span.End()
+	span.SetAttribute("messaging.destination", queueName)
+
+	headers := amqp.Table{}
+	tracing.Inject(ctx, tableCarrier(headers))
+
+	if r.memory != nil {
+		if err := r.memory.publish(queueName, headers, []byte(message)); err != nil {
+			log.Printf("消息发送失败: %s", err)
+			span.SetError(err)
+			return err
+		}
+		log.Printf("消息发送成功: %s", message)
+		return nil
+	}
+
 	ch, err := r.GetChannel()
 	if err != nil {
 		log.Printf("无法获取通道: %s", err)
+		span.SetError(err)
 		return err
 	}
 	defer r.ReleaseChannel(ch) // 确保释放通道回到池中
@@ -127,11 +157,13 @@ func (r *RabbitMQClient) SendMessage(queueName, message string) error {
 		"", queueName, false, false,
 		amqp.Publishing{
 			ContentType: "text/plain",
+			Headers:     headers,
 			Body:        []byte(message),
 		},
 	)
 	if err != nil {
 		log.Printf("消息发送失败: %s", err)
+		span.SetError(err)
 		return err
 	}
 
@@ -139,9 +171,94 @@ func (r *RabbitMQClient) SendMessage(queueName, message string) error {
 	return nil
 }
 
+// Consume 持续消费队列消息（队列不存在时自动创建），直到 ctx 取消或连接断开
+// handler 返回错误时丢弃该消息，避免无法处理的消息反复重投
+// handler 收到的 ctx 带有发送方的请求 ID 与链路信息
+// @param ctx context.Context: 取消时停止消费
+// @param queueName string: 队列名称
+// @param handler func(ctx context.Context, body []byte) error: 消息处理函数
+// @return error: 如果消费失败或连接断开，返回错误
+func (r *RabbitMQClient) Consume(ctx context.Context, queueName string, handler func(ctx context.Context, body []byte) error) error {
+	if r.memory != nil {
+		return r.memory.consume(ctx, queueName, func(headers amqp.Table, body []byte) error {
+			return r.handleMessage(ctx, queueName, headers, body, handler)
+		})
+	}
+
+	// 消费者独占一个 channel，不占用连接池
+	ch, err := r.Connection.Channel()
+	if err != nil {
+		return fmt.Errorf("无法创建通道: %v", err)
+	}
+	defer ch.Close()
+
+	if _, err := ch.QueueDeclare(queueName, true, false, false, false, nil); err != nil {
+		return fmt.Errorf("声明队列 %s 失败: %v", queueName, err)
+	}
+	deliveries, err := ch.Consume(queueName, "", false, false, false, false, nil)
+	if err != nil {
+		return fmt.Errorf("消费队列 %s 失败: %v", queueName, err)
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case delivery, ok := <-deliveries:
+			if !ok {
+				return fmt.Errorf("队列 %s 的消费通道已关闭", queueName)
+			}
+			r.handleDelivery(ctx, queueName, delivery, handler)
+		}
+	}
+}
+
+// 处理一条消息，按结果确认或丢弃
+func (r *RabbitMQClient) handleDelivery(ctx context.Context, queueName string, delivery amqp.Delivery, handler func(ctx context.Context, body []byte) error) {
+	if err := r.handleMessage(ctx, queueName, delivery.Headers, delivery.Body, handler); err != nil {
+		_ = delivery.Nack(false, false)
+		return
+	}
+	_ = delivery.Ack(false)
+}
+
+// 恢复发送方的链路信息后交给 handler，返回 handler 的错误
+func (r *RabbitMQClient) handleMessage(ctx context.Context, queueName string, headers amqp.Table, body []byte, handler func(ctx context.Context, body []byte) error) error {
+	if headers != nil {
+		ctx = tracing.Extract(ctx, tableCarrier(headers))
+	}
+	ctx, span := tracing.StartSpan(ctx, "receive "+queueName, tracing.SpanKindConsumer)
+	defer span.End()
+	span.SetAttribute("messaging.destination", queueName)
+
+	if err := handler(ctx, body); err != nil {
+		log.Printf("处理队列 %s 的消息失败 [%s]: %v", queueName, tracing.RequestID(ctx), err)
+		span.SetError(err)
+		return err
+	}
+	return nil
+}
+
+// 消息头载体，用于传递请求 ID 与链路信息
+type tableCarrier amqp.Table
+
+func (c tableCarrier) Get(key string) string {
+	value, _ := c[key].(string)
+	return value
+}
+
+func (c tableCarrier) Set(key, value string) {
+	c[key] = value
+}
+
 // Close 关闭 RabbitMQ 连接和通道池
 // @return error: 如果关闭过程中出现错误，返回错误
 func (r *RabbitMQClient) Close() error {
+	if r.memory != nil {
+		r.memory.close()
+		log.Println("进程内消息队列已关闭")
+		return nil
+	}
 	close(r.ChannelPool) // 关闭连接池
 	for ch := range r.ChannelPool {
 		err := ch.Close()
@@ -4,7 +4,9 @@ import (
 	"fmt"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
+	"log"
 	"sky_ISService/config"
+	"sky_ISService/pkg/metrics"
 	"time"
 )
 
@@ -25,12 +27,22 @@ func initPostgres(serviceName string) (*gorm.DB, error) {
 		serviceConfig.PostgreSQL.Host, serviceConfig.PostgreSQL.Username, serviceConfig.PostgreSQL.Password, serviceConfig.PostgreSQL.Database, serviceConfig.PostgreSQL.Port)
 
 	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{})
+	if err != nil {
+		return nil, fmt.Errorf("无法连接到 PostgreSQL: %v", err)
+	}
 	sqlDB, err := db.DB()
+	if err != nil {
+		return nil, fmt.Errorf("无法连接到 PostgreSQL: %v", err)
+	}
 	sqlDB.SetMaxOpenConns(25)                 // 最大打开连接数
 	sqlDB.SetMaxIdleConns(25)                 // 最大空闲连接数
 	sqlDB.SetConnMaxLifetime(5 * time.Second) // 连接最大存活时间
-	if err != nil {
-		return nil, fmt.Errorf("无法连接到 PostgreSQL: %v", err)
+
+	// 注册语句耗时与连接池监控指标
+	if err := metrics.RegisterDB(db, serviceName); err != nil {
+		log.Printf("注册数据库监控指标失败: %v", err)
+		sqlDB.Close()
+		return nil, fmt.Errorf("注册数据库监控指标失败: %v", err)
 	}
 
 	fmt.Println("成功连接到 PostgreSQL")
@@ -4,7 +4,10 @@ import (
 	"fmt"
 	"github.com/hashicorp/consul/api"
 	"log"
+	"net"
 	"sky_ISService/config"
+	"strconv"
+	"time"
 )
 
 // InitConsul 初始化 Consul 客户端
@@ -33,21 +36,65 @@ func InitConsul() (*api.Client, error) {
 	return client, nil
 }
 
-// 注册服务到 Consul
+const (
+	// MetaWeight 服务元数据中的节点权重键，网关据此进行加权负载均衡
+	MetaWeight = "weight"
+	// MetaVersion 服务元数据中的部署版本键，网关据此划分灰度分组
+	MetaVersion = "version"
+)
+
+// 服务实例的健康检查：Consul 定期连接实例端口，连接失败的实例不会出现在健康实例列表中，持续失败后自动注销
+const (
+	checkInterval                  = 10 * time.Second
+	checkTimeout                   = 3 * time.Second
+	deregisterCriticalServiceAfter = time.Minute
+)
+
+// RegisterServiceConsul 注册服务到 Consul
 func RegisterServiceConsul(client *api.Client, serviceName, serviceID, address string, port int) error {
-	registration := &api.AgentServiceRegistration{
+	return register(client, &api.AgentServiceRegistration{
 		ID:      serviceID,
 		Name:    serviceName,
 		Address: address,
 		Port:    port,
-	}
+	})
+}
 
+// RegisterServiceConsulWithMeta 注册服务到 Consul，并附带元数据（如 weight）与端口健康检查，需在端口开始监听后调用
+func RegisterServiceConsulWithMeta(client *api.Client, serviceName, serviceID, address string, port int, meta map[string]string) error {
+	return register(client, &api.AgentServiceRegistration{
+		ID:      serviceID,
+		Name:    serviceName,
+		Address: address,
+		Port:    port,
+		Meta:    meta,
+		Check: &api.AgentServiceCheck{
+			CheckID:                        serviceID + "-tcp",
+			TCP:                            net.JoinHostPort(address, strconv.Itoa(port)),
+			Interval:                       checkInterval.String(),
+			Timeout:                        checkTimeout.String(),
+			DeregisterCriticalServiceAfter: deregisterCriticalServiceAfter.String(),
+		},
+	})
+}
+
+func register(client *api.Client, registration *api.AgentServiceRegistration) error {
 	err := client.Agent().ServiceRegister(registration)
 	if err != nil {
 		return fmt.Errorf("服务注册失败: %v", err)
 	}
 
-	log.Printf("服务 %s 注册成功", serviceName)
+	log.Printf("服务 %s 注册成功", registration.Name)
+	return nil
+}
+
+// DeregisterServiceConsul 从 Consul 注销服务实例
+func DeregisterServiceConsul(client *api.Client, serviceID string) error {
+	if err := client.Agent().ServiceDeregister(serviceID); err != nil {
+		return fmt.Errorf("服务注销失败: %v", err)
+	}
+
+	log.Printf("服务实例 %s 已注销", serviceID)
 	return nil
 }
 
@@ -0,0 +1,43 @@
+package sqlite
+
+import (
+	"fmt"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"log"
+	"os"
+	"path/filepath"
+	"sky_ISService/pkg/metrics"
+)
+
+// InitSQLite 打开（不存在时创建）SQLite 数据库文件，用于开发模式；多个服务可以共用同一个文件，
+// 迁移记录按服务区分。使用 WAL 模式并等待写锁，避免多个连接同时写入时报 database is locked
+// @param serviceName string: 服务名，用于区分监控指标
+// @param path string: 数据库文件路径
+// @return *gorm.DB: 数据库连接
+// @return error: 创建目录、打开数据库或注册监控指标失败时返回错误
+func InitSQLite(serviceName, path string) (*gorm.DB, error) {
+	if dir := filepath.Dir(path); dir != "." {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return nil, fmt.Errorf("无法创建 SQLite 数据库目录: %v", err)
+		}
+	}
+
+	dsn := fmt.Sprintf("file:%s?_busy_timeout=5000&_journal_mode=WAL&_foreign_keys=on", path)
+	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{})
+	if err != nil {
+		return nil, fmt.Errorf("无法打开 SQLite 数据库 %s: %v", path, err)
+	}
+
+	// 注册语句耗时与连接池监控指标
+	if err := metrics.RegisterDB(db, serviceName); err != nil {
+		log.Printf("注册数据库监控指标失败: %v", err)
+		if sqlDB, dbErr := db.DB(); dbErr == nil {
+			sqlDB.Close()
+		}
+		return nil, fmt.Errorf("注册数据库监控指标失败: %v", err)
+	}
+
+	fmt.Printf("成功打开 SQLite 数据库 %s\n", path)
+	return db, nil
+}
@@ -0,0 +1,186 @@
+package utils
+
+import (
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"net/netip"
+	"sky_ISService/config"
+	"slices"
+	"strings"
+	"sync/atomic"
+)
+
+// ParseIPOrCIDR 解析单个 IP 或 CIDR（支持 IPv4/IPv6），单个 IP 转为 /32 或 /128 的网段
+func ParseIPOrCIDR(value string) (netip.Prefix, error) {
+	value = strings.TrimSpace(value)
+	if strings.Contains(value, "/") {
+		prefix, err := netip.ParsePrefix(value)
+		if err != nil {
+			return netip.Prefix{}, fmt.Errorf("无效的 CIDR %s: %v", value, err)
+		}
+		if prefix.Addr().Is4In6() {
+			// 前缀短于 96 位时网段超出 IPv4 映射地址的范围，无法转为 IPv4 网段
+			if prefix.Bits() < 96 {
+				return netip.Prefix{}, fmt.Errorf("无效的 CIDR %s: IPv4 映射地址的前缀不能短于 96 位", value)
+			}
+			prefix = netip.PrefixFrom(prefix.Addr().Unmap(), prefix.Bits()-96)
+		}
+		return prefix.Masked(), nil
+	}
+
+	addr, err := ParseIP(value)
+	if err != nil {
+		return netip.Prefix{}, err
+	}
+	return netip.PrefixFrom(addr, addr.BitLen()), nil
+}
+
+// ParseIP 解析 IP 地址，IPv4 映射的 IPv6 地址（::ffff:1.2.3.4）转为 IPv4，去掉 IPv6 的 zone
+func ParseIP(value string) (netip.Addr, error) {
+	addr, err := netip.ParseAddr(strings.TrimSpace(value))
+	if err != nil {
+		return netip.Addr{}, fmt.Errorf("无效的 IP %s: %v", value, err)
+	}
+	return addr.Unmap().WithZone(""), nil
+}
+
+// ParseCIDRs 批量解析 IP 或 CIDR 列表
+func ParseCIDRs(values []string) ([]netip.Prefix, error) {
+	prefixes := make([]netip.Prefix, 0, len(values))
+	for _, value := range values {
+		prefix, err := ParseIPOrCIDR(value)
+		if err != nil {
+			return nil, err
+		}
+		prefixes = append(prefixes, prefix)
+	}
+	return prefixes, nil
+}
+
+// IPInPrefixes 判断 IP 是否落在任一网段内
+func IPInPrefixes(addr netip.Addr, prefixes []netip.Prefix) bool {
+	for _, prefix := range prefixes {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// RealIPHeader 网关解析出的客户端 IP 通过该请求头传给下游服务
+const RealIPHeader = "X-Real-IP"
+
+// 可信代理传递客户端地址使用的请求头
+const (
+	XForwardedForHeader = "X-Forwarded-For"
+	ForwardedHeader     = "Forwarded"
+)
+
+// 已解析的可信代理网段，配置变更（热加载）后重新解析
+type trustedProxyList struct {
+	source   []string
+	prefixes []netip.Prefix
+}
+
+var trustedProxies atomic.Pointer[trustedProxyList]
+
+// TrustedProxies 返回配置的可信代理网段，无效的项会被忽略；配置变更后重新解析
+func TrustedProxies() []netip.Prefix {
+	values := config.GetConfig().TrustedProxies
+	if cached := trustedProxies.Load(); cached != nil && slices.Equal(cached.source, values) {
+		return cached.prefixes
+	}
+
+	list := &trustedProxyList{source: slices.Clone(values)}
+	for _, value := range values {
+		prefix, err := ParseIPOrCIDR(value)
+		if err != nil {
+			log.Printf("忽略无效的可信代理配置: %v", err)
+			continue
+		}
+		list.prefixes = append(list.prefixes, prefix)
+	}
+	trustedProxies.Store(list)
+	return list.prefixes
+}
+
+// TrustedForwardedHeader 返回可信代理传递客户端地址的请求头（forwarded_header），默认 X-Forwarded-For
+func TrustedForwardedHeader() string {
+	if strings.EqualFold(config.GetConfig().ForwardedHeader, ForwardedHeader) {
+		return ForwardedHeader
+	}
+	return XForwardedForHeader
+}
+
+// ResolveClientIP 按可信代理链解析客户端 IP：
+// 直连地址不可信时直接使用直连地址；否则从右向左遍历 forwardedHeader（Forwarded 或 X-Forwarded-For，只使用可信代理实际追加的那个），
+// 返回第一个不可信的地址，链路上全部可信时返回最左侧的地址。本机地址始终视为可信代理
+func ResolveClientIP(remoteAddr string, header http.Header, trusted []netip.Prefix, forwardedHeader string) netip.Addr {
+	host, _, err := net.SplitHostPort(remoteAddr)
+	if err != nil {
+		host = remoteAddr
+	}
+	client, err := ParseIP(host)
+	if err != nil {
+		return netip.Addr{}
+	}
+
+	hops := forwardedFor(header, forwardedHeader)
+	for i := len(hops) - 1; i >= 0 && isTrustedProxy(client, trusted); i-- {
+		addr, err := ParseIP(hops[i])
+		if err != nil {
+			// 可信代理传来的地址无法解析，停在最后一个可确认的地址
+			break
+		}
+		client = addr
+	}
+	return client
+}
+
+// 判断地址是否为可信代理
+func isTrustedProxy(addr netip.Addr, trusted []netip.Prefix) bool {
+	return addr.IsLoopback() || IPInPrefixes(addr, trusted)
+}
+
+// 读取转发链上的地址（从左到右），只读取指定的请求头，另一个请求头可能由客户端伪造
+func forwardedFor(header http.Header, name string) []string {
+	var hops []string
+	if !strings.EqualFold(name, ForwardedHeader) {
+		for _, value := range header.Values(XForwardedForHeader) {
+			for _, hop := range strings.Split(value, ",") {
+				if hop = strings.TrimSpace(hop); hop != "" {
+					hops = append(hops, hop)
+				}
+			}
+		}
+		return hops
+	}
+
+	for _, value := range header.Values(ForwardedHeader) {
+		for _, element := range strings.Split(value, ",") {
+			for _, pair := range strings.Split(element, ";") {
+				key, val, ok := strings.Cut(strings.TrimSpace(pair), "=")
+				if ok && strings.EqualFold(key, "for") {
+					hops = append(hops, forwardedNode(val))
+				}
+			}
+		}
+	}
+	return hops
+}
+
+// 解析 Forwarded 的 for 节点：192.0.2.60、"192.0.2.60:4711"、"[2001:db8::1]:4711"
+func forwardedNode(value string) string {
+	value = strings.Trim(strings.TrimSpace(value), `"`)
+	if strings.HasPrefix(value, "[") {
+		if end := strings.Index(value, "]"); end > 0 {
+			return value[1:end]
+		}
+	}
+	if host, _, err := net.SplitHostPort(value); err == nil {
+		return host
+	}
+	return value
+}
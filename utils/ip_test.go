@@ -0,0 +1,134 @@
+package utils
+
+import (
+	"net/http"
+	"net/netip"
+	"testing"
+)
+
+func TestParseIPOrCIDR(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    string
+		wantErr bool
+	}{
+		{value: "10.0.0.1", want: "10.0.0.1/32"},
+		{value: " 10.0.0.1 ", want: "10.0.0.1/32"},
+		{value: "10.1.2.3/8", want: "10.0.0.0/8"},
+		{value: "2001:db8::1", want: "2001:db8::1/128"},
+		{value: "2001:db8::1/32", want: "2001:db8::/32"},
+		{value: "fe80::1%eth0", want: "fe80::1/128"},
+		// IPv4 映射地址转为 IPv4
+		{value: "::ffff:192.168.1.10", want: "192.168.1.10/32"},
+		{value: "::ffff:192.168.1.0/120", want: "192.168.1.0/24"},
+		{value: "::ffff:0.0.0.0/96", want: "0.0.0.0/0"},
+		{value: "::ffff:0.0.0.0/64", wantErr: true},
+		{value: "10.0.0.1/33", wantErr: true},
+		{value: "10.0.0", wantErr: true},
+		{value: "", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			prefix, err := ParseIPOrCIDR(tt.value)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("期望解析失败，得到 %s", prefix)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("解析失败: %v", err)
+			}
+			if prefix.String() != tt.want {
+				t.Fatalf("得到 %s，期望 %s", prefix, tt.want)
+			}
+		})
+	}
+}
+
+func TestIPInPrefixes(t *testing.T) {
+	prefixes := mustPrefixes(t, "10.0.0.0/8", "::ffff:192.168.1.0/120", "2001:db8::/32")
+	tests := []struct {
+		ip   string
+		want bool
+	}{
+		{ip: "10.20.30.40", want: true},
+		{ip: "11.0.0.1", want: false},
+		{ip: "192.168.1.200", want: true},
+		{ip: "::ffff:192.168.1.200", want: true},
+		{ip: "192.168.2.1", want: false},
+		{ip: "2001:db8:1::1", want: true},
+		{ip: "2001:db9::1", want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.ip, func(t *testing.T) {
+			addr, err := ParseIP(tt.ip)
+			if err != nil {
+				t.Fatalf("解析 IP 失败: %v", err)
+			}
+			if got := IPInPrefixes(addr, prefixes); got != tt.want {
+				t.Fatalf("得到 %v，期望 %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// 测试用的网段列表
+func mustPrefixes(t *testing.T, values ...string) []netip.Prefix {
+	t.Helper()
+	prefixes, err := ParseCIDRs(values)
+	if err != nil {
+		t.Fatalf("解析网段失败: %v", err)
+	}
+	return prefixes
+}
+
+func TestResolveClientIP(t *testing.T) {
+	trusted := mustPrefixes(t, "10.0.0.0/8", "2001:db8::/32")
+	tests := []struct {
+		name       string
+		remoteAddr string
+		xff        []string
+		forwarded  []string
+		headerName string
+		want       string
+	}{
+		{name: "直连不可信时忽略转发头", remoteAddr: "203.0.113.5:4000", xff: []string{"1.1.1.1"}, want: "203.0.113.5"},
+		{name: "可信代理追加的地址", remoteAddr: "10.0.0.2:4000", xff: []string{"198.51.100.7"}, want: "198.51.100.7"},
+		{name: "跳过链路上的可信代理", remoteAddr: "10.0.0.2:4000", xff: []string{"1.1.1.1, 198.51.100.7, 10.0.0.3"}, want: "198.51.100.7"},
+		{name: "多个请求头按顺序拼接", remoteAddr: "10.0.0.2:4000", xff: []string{"1.1.1.1", "198.51.100.7"}, want: "198.51.100.7"},
+		{name: "链路全部可信时取最左侧", remoteAddr: "10.0.0.2:4000", xff: []string{"10.0.0.9, 10.0.0.3"}, want: "10.0.0.9"},
+		{name: "无法解析的地址停在上一跳", remoteAddr: "10.0.0.2:4000", xff: []string{"1.1.1.1, unknown"}, want: "10.0.0.2"},
+		{name: "本机地址视为可信代理", remoteAddr: "127.0.0.1:4000", xff: []string{"198.51.100.7"}, want: "198.51.100.7"},
+		{name: "IPv4 映射的直连地址", remoteAddr: "[::ffff:10.0.0.2]:4000", xff: []string{"198.51.100.7"}, want: "198.51.100.7"},
+		{name: "IPv6 可信代理", remoteAddr: "[2001:db8::1]:4000", xff: []string{"2001:db9::7"}, want: "2001:db9::7"},
+		{name: "无端口的直连地址", remoteAddr: "10.0.0.2", xff: []string{"198.51.100.7"}, want: "198.51.100.7"},
+		{name: "无效的直连地址", remoteAddr: "invalid", want: "invalid IP"},
+		{name: "使用 X-Forwarded-For 时忽略 Forwarded", remoteAddr: "10.0.0.2:4000",
+			forwarded: []string{"for=1.1.1.1"}, want: "10.0.0.2"},
+		{name: "Forwarded", remoteAddr: "10.0.0.2:4000", headerName: ForwardedHeader,
+			forwarded: []string{`for=1.1.1.1;proto=https, for="198.51.100.7:4711"`}, want: "198.51.100.7"},
+		{name: "Forwarded IPv6", remoteAddr: "10.0.0.2:4000", headerName: ForwardedHeader,
+			forwarded: []string{`For="[2001:db9::7]:4711"`}, want: "2001:db9::7"},
+		{name: "使用 Forwarded 时忽略 X-Forwarded-For", remoteAddr: "10.0.0.2:4000", headerName: ForwardedHeader,
+			xff: []string{"1.1.1.1"}, want: "10.0.0.2"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			header := http.Header{}
+			for _, value := range tt.xff {
+				header.Add(XForwardedForHeader, value)
+			}
+			for _, value := range tt.forwarded {
+				header.Add(ForwardedHeader, value)
+			}
+			headerName := tt.headerName
+			if headerName == "" {
+				headerName = XForwardedForHeader
+			}
+			if got := ResolveClientIP(tt.remoteAddr, header, trusted, headerName); got.String() != tt.want {
+				t.Fatalf("得到 %s，期望 %s", got, tt.want)
+			}
+		})
+	}
+}
@@ -1,19 +1,28 @@
 package utils
 
 import (
+	"crypto/rand"
+	"encoding/hex"
 	"github.com/dgrijalva/jwt-go"
 	"log"
 	"sky_ISService/config"
+	"strings"
 	"time"
 )
 
-// GenerateToken 生成 JWT Token
-func GenerateToken(userID string, role string) (string, error) {
+// GenerateToken 生成 JWT Token，多个角色以逗号分隔保存在 role 中
+func GenerateToken(userID string, username string, roles ...string) (string, error) {
+	tokenID := make([]byte, 16)
+	if _, err := rand.Read(tokenID); err != nil {
+		return "", err
+	}
 	claims := jwt.MapClaims{
-		"sub_id": userID,                                // 用户ID
-		"role":   role,                                  // 用户角色
-		"exp":    time.Now().Add(time.Hour * 72).Unix(), // 72 小时过期
-		"iat":    time.Now().Unix(),                     // 签发时间
+		"sub_id":   userID,                                // 用户ID
+		"username": username,                              // 用户名
+		"role":     strings.Join(roles, ","),              // 用户角色
+		"jti":      hex.EncodeToString(tokenID),           // Token ID
+		"exp":      time.Now().Add(time.Hour * 72).Unix(), // 72 小时过期
+		"iat":      time.Now().Unix(),                     // 签发时间
 	}
 
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
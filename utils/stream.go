@@ -0,0 +1,26 @@
+package utils
+
+import (
+	"net/http"
+	"strings"
+)
+
+// IsStreamingRequest 是否为 WebSocket 升级或 SSE 请求
+func IsStreamingRequest(r *http.Request) bool {
+	return isUpgradeRequest(r) || strings.Contains(r.Header.Get("Accept"), "text/event-stream")
+}
+
+// 是否为协议升级请求（WebSocket）
+func isUpgradeRequest(r *http.Request) bool {
+	if r.Header.Get("Upgrade") == "" {
+		return false
+	}
+	for _, value := range r.Header.Values("Connection") {
+		for _, token := range strings.Split(value, ",") {
+			if strings.EqualFold(strings.TrimSpace(token), "upgrade") {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -137,9 +137,18 @@ func IsValidEmail(email string) bool {
 }
 
 // GetClientIP 获取客户端的真实 IP 地址
+// 只有请求来自可信代理（网关）或本机时才使用网关传入的 X-Real-IP，避免客户端伪造
 func GetClientIP(c *gin.Context) string {
-	ip := c.ClientIP() // Gin提供的内置方法，直接获取客户端 IP
-	return ip
+	remote, err := ParseIP(c.RemoteIP())
+	if err != nil {
+		return c.RemoteIP()
+	}
+	if isTrustedProxy(remote, TrustedProxies()) {
+		if realIP, err := ParseIP(c.GetHeader(RealIPHeader)); err == nil {
+			return realIP.String()
+		}
+	}
+	return remote.String()
 }
 
 // ExtractConditions 从请求 URL 中提取分页和查询条件